@@ -12,6 +12,8 @@ import (
 	"portal/internal/body"
 	"portal/internal/drink"
 	"portal/internal/ieb"
+	"portal/internal/invoice"
+	"portal/internal/organization"
 	"portal/internal/portal"
 	"portal/internal/program_planner"
 	selectoptions "portal/internal/select_options"
@@ -62,6 +64,7 @@ func main() {
 	termService := term.NewTermService(consulClient)
 	topicService := topic.NewTopicService(consulClient)
 	attendanceService := attendance.NewAttendanceService(consulClient)
+	organizationService := organization.NewOrganizationService(consulClient)
 
 	drinkCollection := mongoClient.Database(cfg.MongoDB).Collection("drinks")
 	drinkRepository := drink.NewDrinkRepository(drinkCollection)
@@ -75,13 +78,17 @@ func main() {
 
 	timerCollection := mongoClient.Database(cfg.MongoDB).Collection("timers")
 	isTimeCollection := mongoClient.Database(cfg.MongoDB).Collection("is_times")
-	timerRepository := timer.NewTimerRepository(timerCollection, isTimeCollection)
+	timerTemplateCollection := mongoClient.Database(cfg.MongoDB).Collection("timer_templates")
+	timerRunCollection := mongoClient.Database(cfg.MongoDB).Collection("timer_runs")
+	scheduleCollection := mongoClient.Database(cfg.MongoDB).Collection("schedules")
+	timerRepository := timer.NewTimerRepository(timerCollection, isTimeCollection, timerTemplateCollection, timerRunCollection, scheduleCollection)
 	timerService := timer.NewTimerService(timerRepository, userService, imageService)
 	timerHandler := timer.NewTimerHandler(timerService)
 
 	bodyCollection := mongoClient.Database(cfg.MongoDB).Collection("bodies")
-	bodyRepository := body.NewBodyRepository(bodyCollection)
-	bodyService := body.NewBodyService(bodyRepository, userService)
+	bodyIncidentCollection := mongoClient.Database(cfg.MongoDB).Collection("body_incidents")
+	bodyRepository := body.NewBodyRepository(bodyCollection, bodyIncidentCollection)
+	bodyService := body.NewBodyService(bodyRepository, userService, imageService)
 	bodyHandler := body.NewBodyHandler(bodyService)
 
 	portalCollection := mongoClient.Database(cfg.MongoDB).Collection("portals")
@@ -90,13 +97,23 @@ func main() {
 	portalHandler := portal.NewPortalHandlers(portalService)
 
 	iebCollection := mongoClient.Database(cfg.MongoDB).Collection("iebs")
-	iebRepository := ieb.NewIEBRepository(iebCollection)
-	iebService := ieb.NewIEBService(iebRepository)
+	iebRevisionCollection := mongoClient.Database(cfg.MongoDB).Collection("ieb_revisions")
+	iebRepository := ieb.NewIEBRepository(iebCollection, iebRevisionCollection)
+	if err := iebRepository.EnsureIndexes(context.Background()); err != nil {
+		logger.Errorf("Failed to create ieb indexes: %v", err)
+	}
+	iebService := ieb.NewIEBService(iebRepository, termService, userService, organizationService)
+	if err := iebService.BackfillIDs(context.Background()); err != nil {
+		logger.Errorf("Failed to backfill ieb ids: %v", err)
+	}
 	iebHandler := ieb.NewIEBHandler(iebService)
 
 	programPlannerCollection := mongoClient.Database(cfg.MongoDB).Collection("program_planners")
-	programPlannerRepository := program_planner.NewProgramPlanerRepository(programPlannerCollection)
-	programPlannerService := program_planner.NewProgramPlanerService(programPlannerRepository)
+	timeSlotCollection := mongoClient.Database(cfg.MongoDB).Collection("time_slots")
+	closureCollection := mongoClient.Database(cfg.MongoDB).Collection("closures")
+	slotCounterCollection := mongoClient.Database(cfg.MongoDB).Collection("slot_counters")
+	programPlannerRepository := program_planner.NewProgramPlanerRepository(programPlannerCollection, timeSlotCollection, closureCollection, slotCounterCollection)
+	programPlannerService := program_planner.NewProgramPlanerService(programPlannerRepository, attendanceService)
 	programPlannerHandler := program_planner.NewProgramPlanerHandler(programPlannerService)
 
 	teacherAssignmentCollection := mongoClient.Database(cfg.MongoDB).Collection("teacher_assignments")
@@ -109,6 +126,15 @@ func main() {
 	studyProgramService := studyprogram.NewStudyProgramService(studyProgramRepository)
 	studyProgramHandler := studyprogram.NewStudyProgramHandler(studyProgramService)
 
+	invoiceCollection := mongoClient.Database(cfg.MongoDB).Collection("invoices")
+	invoiceCounterCollection := mongoClient.Database(cfg.MongoDB).Collection("invoice_counters")
+	invoiceRepository := invoice.NewInvoiceRepository(invoiceCollection, invoiceCounterCollection)
+	if err := invoiceRepository.EnsureIndexes(context.Background()); err != nil {
+		logger.Errorf("Failed to create invoice indexes: %v", err)
+	}
+	invoiceService := invoice.NewInvoiceService(invoiceRepository, programPlannerService, teacherAssignmentService, studyProgramService)
+	invoiceHandler := invoice.NewInvoiceHandler(invoiceService)
+
 	selectOptionsCollection := mongoClient.Database(cfg.MongoDB).Collection("select_options")
 	selectOptionsRepository := selectoptions.NewSelectOptionsRepository(selectOptionsCollection)
 	selectOptionsService := selectoptions.NewSelectOptionsService(selectOptionsRepository, termService)
@@ -130,6 +156,7 @@ func main() {
 	program_planner.RegisterRoutes(router, programPlannerHandler)
 	teacherassign.RegisterRoutes(router, teacherAssignmentHandler)
 	studyprogram.RegisterRoutes(router, studyProgramHandler)
+	invoice.RegisterRoutes(router, invoiceHandler)
 	selectoptions.RegisterRoutes(router, selectOptionsHandler)
 	studypreference.RegisterRoutes(router, studyPreferenceHandler)
 
@@ -5,8 +5,12 @@ import (
 )
 
 const (
-	ErrInvalidOperation = "ERR_INVALID_OPERATION"
-	ErrInvalidRequest   = "ERR_INVALID_REQUEST"
+	ErrInvalidOperation     = "ERR_INVALID_OPERATION"
+	ErrInvalidRequest       = "ERR_INVALID_REQUEST"
+	ErrConfirmationRequired = "ERR_CONFIRMATION_REQUIRED"
+	ErrVersionConflict      = "ERR_VERSION_CONFLICT"
+	ErrNotFound             = "ERR_NOT_FOUND"
+	ErrAlreadyExists        = "ERR_ALREADY_EXISTS"
 )
 
 type APIResponse struct {
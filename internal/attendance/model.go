@@ -8,3 +8,10 @@ type AttendanceUserInfo struct {
 	Date         string  `json:"date"`
 	Temperature  float64 `json:"temperature"`
 }
+
+type Holiday struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
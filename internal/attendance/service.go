@@ -18,6 +18,7 @@ import (
 
 type AttendanceService interface {
 	GetAttendanceInfor(ctx context.Context, userID string) ([]*AttendanceUserInfo, error)
+	GetHolidays(ctx context.Context, organizationID string, year int) ([]*Holiday, error)
 	// GetStudentInfor(ctx context.Context, studentID string) (*AttendanceInfor, error)
 	// GetTeacherInfor(ctx context.Context, studentID string) (*AttendanceInfor, error)
 	// GetStaffInfor(ctx context.Context, studentID string) (*AttendanceInfor, error)
@@ -179,6 +180,66 @@ func (u *attendanceService) GetAttendanceInfor(ctx context.Context, userID strin
 	return attendanceInfos, nil
 }
 
+// GetHolidays is not fail-safe: callers replace their copy of the calendar with the result,
+// so an unreachable service must not look like a year without holidays.
+func (u *attendanceService) GetHolidays(ctx context.Context, organizationID string, year int) ([]*Holiday, error) {
+
+	token, ok := ctx.Value(constants.TokenKey).(string)
+	if !ok || token == "" {
+		return nil, fmt.Errorf("token not found in context")
+	}
+
+	data, err := u.client.getHolidays(organizationID, year, token)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fmt.Errorf("%s is not available", mainService)
+	}
+
+	records, ok := data["data"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid response from %s: 'data' field is not an array", mainService)
+	}
+
+	holidays := make([]*Holiday, 0, len(records))
+	for _, record := range records {
+		recordMap, ok := record.(map[string]interface{})
+		if !ok {
+			log.Printf("[attendanceService] invalid holiday format, skipping")
+			continue
+		}
+
+		holiday := &Holiday{
+			ID:        getString(recordMap, "id"),
+			Name:      getString(recordMap, "name"),
+			StartDate: getString(recordMap, "start_date"),
+			EndDate:   getString(recordMap, "end_date"),
+		}
+
+		// Single-day holidays only carry a date.
+		if holiday.StartDate == "" {
+			holiday.StartDate = getString(recordMap, "date")
+		}
+		if holiday.EndDate == "" {
+			holiday.EndDate = holiday.StartDate
+		}
+
+		if holiday.StartDate == "" {
+			log.Printf("[attendanceService] skipping holiday with missing date")
+			continue
+		}
+
+		holidays = append(holidays, holiday)
+	}
+
+	return holidays, nil
+}
+
+func (c *callAPI) getHolidays(organizationID string, year int, token string) (map[string]interface{}, error) {
+	return c.getJSON(fmt.Sprintf("/api/v1/gateway/holidays?organization_id=%s&year=%d", url.QueryEscape(organizationID), year), token)
+}
+
 func (c *callAPI) getAttendanceInfor(userID string, token string) (map[string]interface{}, error) {
 	return c.getJSON(fmt.Sprintf("/api/v1/gateway/student-temperature?student-id=%s", url.QueryEscape(userID)), token)
 }
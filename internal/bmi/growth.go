@@ -0,0 +1,170 @@
+package bmi
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// lmsPoint holds the Box-Cox L, M and S parameters of a growth reference at a given age.
+type lmsPoint struct {
+	AgeMonths float64
+	L         float64
+	M         float64
+	S         float64
+}
+
+const (
+	indicatorHeight = "height"
+	indicatorWeight = "weight"
+	indicatorBMI    = "bmi"
+
+	daysPerMonth = 30.4375
+)
+
+// percentileZScores maps the reported percentiles to their standard normal z-scores.
+var percentileZScores = []struct {
+	Name string
+	Z    float64
+}{
+	{"p3", -1.8808},
+	{"p15", -1.0364},
+	{"p50", 0},
+	{"p85", 1.0364},
+	{"p97", 1.8808},
+}
+
+// growthReferences is sampled yearly from the WHO Child Growth Standards (0-5y) and the
+// WHO Growth Reference (5-19y). Weight-for-age is only published up to 10 years.
+var growthReferences = map[string]map[string][]lmsPoint{
+	"male": {
+		indicatorHeight: {
+			{0, 1, 49.9, 0.0380}, {12, 1, 75.7, 0.0310}, {24, 1, 87.1, 0.0350}, {36, 1, 96.1, 0.0380},
+			{48, 1, 103.3, 0.0400}, {60, 1, 110.0, 0.0415}, {72, 1, 116.0, 0.0420}, {84, 1, 121.7, 0.0425},
+			{96, 1, 127.3, 0.0430}, {108, 1, 132.6, 0.0435}, {120, 1, 137.8, 0.0440}, {132, 1, 143.1, 0.0450},
+			{144, 1, 149.1, 0.0465}, {156, 1, 156.0, 0.0470}, {168, 1, 163.2, 0.0450}, {180, 1, 169.0, 0.0425},
+			{192, 1, 172.9, 0.0405}, {204, 1, 175.2, 0.0400}, {216, 1, 176.1, 0.0395}, {228, 1, 176.5, 0.0395},
+		},
+		indicatorWeight: {
+			{0, 0.3487, 3.3464, 0.1460}, {12, 0.1, 9.6, 0.1080}, {24, 0, 12.2, 0.1090}, {36, -0.1, 14.3, 0.1120},
+			{48, -0.2, 16.3, 0.1170}, {60, -0.3, 18.3, 0.1220}, {72, -0.4, 20.5, 0.1300}, {84, -0.5, 22.9, 0.1400},
+			{96, -0.6, 25.4, 0.1500}, {108, -0.7, 28.1, 0.1600}, {120, -0.8, 31.2, 0.1700},
+		},
+		indicatorBMI: {
+			{0, -0.3053, 13.4, 0.0956}, {12, -0.2, 16.8, 0.0801}, {24, -0.6, 16.0, 0.0779}, {36, -0.5, 15.6, 0.0766},
+			{48, -0.6, 15.4, 0.0784}, {60, -0.7387, 15.3, 0.0839}, {72, -1.0, 15.3, 0.0870}, {84, -1.2, 15.5, 0.0920},
+			{96, -1.4, 15.7, 0.0990}, {108, -1.5, 16.0, 0.1060}, {120, -1.6, 16.4, 0.1130}, {132, -1.6, 16.9, 0.1190},
+			{144, -1.6, 17.5, 0.1240}, {156, -1.5, 18.2, 0.1270}, {168, -1.4, 19.0, 0.1280}, {180, -1.3, 19.8, 0.1270},
+			{192, -1.2, 20.5, 0.1250}, {204, -1.1, 21.1, 0.1230}, {216, -1.0, 21.7, 0.1210}, {228, -0.9, 22.2, 0.1190},
+		},
+	},
+	"female": {
+		indicatorHeight: {
+			{0, 1, 49.1, 0.0379}, {12, 1, 74.0, 0.0326}, {24, 1, 85.7, 0.0366}, {36, 1, 95.1, 0.0390},
+			{48, 1, 102.7, 0.0410}, {60, 1, 109.4, 0.0425}, {72, 1, 115.1, 0.0430}, {84, 1, 120.8, 0.0440},
+			{96, 1, 126.6, 0.0450}, {108, 1, 132.5, 0.0460}, {120, 1, 138.6, 0.0470}, {132, 1, 144.9, 0.0470},
+			{144, 1, 151.2, 0.0460}, {156, 1, 156.4, 0.0430}, {168, 1, 159.8, 0.0405}, {180, 1, 161.7, 0.0390},
+			{192, 1, 162.5, 0.0385}, {204, 1, 162.9, 0.0385}, {216, 1, 163.1, 0.0385}, {228, 1, 163.2, 0.0385},
+		},
+		indicatorWeight: {
+			{0, 0.3809, 3.2322, 0.1417}, {12, 0, 8.9, 0.1150}, {24, -0.1, 11.5, 0.1170}, {36, -0.2, 13.9, 0.1220},
+			{48, -0.3, 16.1, 0.1290}, {60, -0.4, 18.2, 0.1360}, {72, -0.5, 20.2, 0.1430}, {84, -0.6, 22.4, 0.1510},
+			{96, -0.7, 25.0, 0.1600}, {108, -0.8, 28.2, 0.1700}, {120, -0.9, 31.9, 0.1800},
+		},
+		indicatorBMI: {
+			{0, -0.0631, 13.3, 0.0927}, {12, -0.4, 16.4, 0.0850}, {24, -0.5, 15.7, 0.0820}, {36, -0.6, 15.4, 0.0830},
+			{48, -0.7, 15.2, 0.0860}, {60, -0.8, 15.2, 0.0930}, {72, -1.0, 15.3, 0.0980}, {84, -1.1, 15.4, 0.1050},
+			{96, -1.2, 15.7, 0.1130}, {108, -1.3, 16.1, 0.1210}, {120, -1.4, 16.6, 0.1280}, {132, -1.4, 17.2, 0.1340},
+			{144, -1.4, 18.0, 0.1380}, {156, -1.3, 18.8, 0.1400}, {168, -1.2, 19.6, 0.1400}, {180, -1.1, 20.2, 0.1390},
+			{192, -1.0, 20.7, 0.1380}, {204, -0.9, 21.0, 0.1370}, {216, -0.8, 21.3, 0.1360}, {228, -0.7, 21.4, 0.1350},
+		},
+	},
+}
+
+func normalizeGender(gender string) string {
+	switch strings.ToLower(strings.TrimSpace(gender)) {
+	case "male", "m", "boy":
+		return "male"
+	case "female", "f", "girl":
+		return "female"
+	default:
+		return ""
+	}
+}
+
+func ageInMonths(birthDate time.Time, at time.Time) float64 {
+	return at.Sub(birthDate).Hours() / 24 / daysPerMonth
+}
+
+// interpolateLMS returns the LMS parameters at the given age, or false when the age
+// falls outside the reference table.
+func interpolateLMS(table []lmsPoint, ageMonths float64) (lmsPoint, bool) {
+
+	if len(table) == 0 || ageMonths < table[0].AgeMonths || ageMonths > table[len(table)-1].AgeMonths {
+		return lmsPoint{}, false
+	}
+
+	for i := 1; i < len(table); i++ {
+		prev, next := table[i-1], table[i]
+		if ageMonths > next.AgeMonths {
+			continue
+		}
+		ratio := (ageMonths - prev.AgeMonths) / (next.AgeMonths - prev.AgeMonths)
+		return lmsPoint{
+			AgeMonths: ageMonths,
+			L:         prev.L + (next.L-prev.L)*ratio,
+			M:         prev.M + (next.M-prev.M)*ratio,
+			S:         prev.S + (next.S-prev.S)*ratio,
+		}, true
+	}
+
+	return table[0], true
+}
+
+func valueAtZScore(p lmsPoint, z float64) float64 {
+	if p.L == 0 {
+		return p.M * math.Exp(p.S*z)
+	}
+	return p.M * math.Pow(1+p.L*p.S*z, 1/p.L)
+}
+
+func buildPercentileCurve(gender string, indicator string, birthDate time.Time, from time.Time, to time.Time) []PercentileCurvePoint {
+
+	table := growthReferences[gender][indicator]
+	curve := make([]PercentileCurvePoint, 0)
+
+	dates := make([]time.Time, 0)
+	for date := from; date.Before(to); date = date.AddDate(0, 1, 0) {
+		dates = append(dates, date)
+	}
+	dates = append(dates, to)
+
+	for _, date := range dates {
+		age := ageInMonths(birthDate, date)
+		lms, ok := interpolateLMS(table, age)
+		if !ok {
+			continue
+		}
+
+		values := make(map[string]float64, len(percentileZScores))
+		for _, p := range percentileZScores {
+			values[p.Name] = round2(valueAtZScore(lms, p.Z))
+		}
+
+		curve = append(curve, PercentileCurvePoint{
+			Date:      date.Format("2006-01-02"),
+			AgeMonths: round2(age),
+			P3:        values["p3"],
+			P15:       values["p15"],
+			P50:       values["p50"],
+			P85:       values["p85"],
+			P97:       values["p97"],
+		})
+	}
+
+	return curve
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -2,6 +2,7 @@ package bmi
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"portal/helper"
 	"portal/pkg/constants"
@@ -43,6 +44,11 @@ func (h *BMIHandler) CreateBMI(c *gin.Context) {
 
 	bmiID, err := h.BMIService.CreateBMI(ctx, &req, userID.(string))
 	if err != nil {
+		var plausibilityErr *PlausibilityError
+		if errors.As(err, &plausibilityErr) {
+			helper.SendError(c, 409, err, helper.ErrConfirmationRequired)
+			return
+		}
 		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
 		return
 	}
@@ -55,6 +61,7 @@ func (h *BMIHandler) GetBMIs(c *gin.Context) {
 
 	student_id := c.Query("student")
 	date := c.Query("date")
+	units := c.Query("units")
 
 	token, exists := c.Get(constants.Token)
 	if !exists {
@@ -64,7 +71,7 @@ func (h *BMIHandler) GetBMIs(c *gin.Context) {
 
 	ctx := context.WithValue(c, constants.TokenKey, token)
 
-	bmis, err := h.BMIService.GetBMIs(ctx, student_id, date)
+	bmis, err := h.BMIService.GetBMIs(ctx, student_id, date, units)
 
 	if err != nil {
 		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
@@ -78,6 +85,7 @@ func (h *BMIHandler) GetBMIs(c *gin.Context) {
 func (h *BMIHandler) GetBMI(c *gin.Context) {
 
 	id := c.Param("id")
+	units := c.Query("units")
 
 	token, exists := c.Get(constants.Token)
 	if !exists {
@@ -87,7 +95,7 @@ func (h *BMIHandler) GetBMI(c *gin.Context) {
 
 	ctx := context.WithValue(c, constants.TokenKey, token)
 
-	bmi, err := h.BMIService.GetBMI(ctx, id)
+	bmi, err := h.BMIService.GetBMI(ctx, id, units)
 
 	if err != nil {
 		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
@@ -96,4 +104,106 @@ func (h *BMIHandler) GetBMI(c *gin.Context) {
 
 	helper.SendSuccess(c, 200, "Get bmi successfully", bmi)
 	
-}
\ No newline at end of file
+}
+
+func (h *BMIHandler) GetGrowthChart(c *gin.Context) {
+
+	studentID := c.Query("student_id")
+	from := c.Query("from")
+	to := c.Query("to")
+	gender := c.Query("gender")
+	birthDate := c.Query("birth_date")
+	units := c.Query("units")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	chart, err := h.BMIService.GetGrowthChart(ctx, studentID, from, to, gender, birthDate, units)
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Get growth chart successfully", chart)
+
+}
+
+func (h *BMIHandler) UpdateBMI(c *gin.Context) {
+
+	id := c.Param("id")
+
+	var req UpdateBMIRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	err := h.BMIService.UpdateBMI(ctx, id, &req, userID.(string))
+	if err != nil {
+		var plausibilityErr *PlausibilityError
+		if errors.As(err, &plausibilityErr) {
+			helper.SendError(c, 409, err, helper.ErrConfirmationRequired)
+			return
+		}
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Update bmi successfully", nil)
+
+}
+
+func (h *BMIHandler) DeleteBMI(c *gin.Context) {
+
+	id := c.Param("id")
+
+	var req DeleteBMIRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+			return
+		}
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	err := h.BMIService.DeleteBMI(ctx, id, &req, userID.(string))
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Delete bmi successfully", nil)
+
+}
@@ -14,6 +14,25 @@ type BMI struct {
 	Weight    float64            `json:"weight" bson:"weight"`
 	CreatedBy string             `json:"created_by" bson:"created_by"`
 	BMI       float64            `json:"bmi" bson:"bmi"`
+	InputUnit string             `json:"input_unit" bson:"input_unit"`
+	Flags     []string           `json:"flags" bson:"flags"`
+	Confirmed bool               `json:"confirmed" bson:"confirmed"`
+	AuditLogs []AuditLog         `json:"audit_logs" bson:"audit_logs"`
+	IsDeleted bool               `json:"is_deleted" bson:"is_deleted"`
 	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
 }
+
+type AuditLog struct {
+	Action    string        `json:"action" bson:"action"` // create, update, delete
+	Changes   []FieldChange `json:"changes" bson:"changes"`
+	Reason    string        `json:"reason" bson:"reason"`
+	ChangedBy string        `json:"changed_by" bson:"changed_by"`
+	ChangedAt time.Time     `json:"changed_at" bson:"changed_at"`
+}
+
+type FieldChange struct {
+	Field string      `json:"field" bson:"field"`
+	From  interface{} `json:"from" bson:"from"`
+	To    interface{} `json:"to" bson:"to"`
+}
@@ -0,0 +1,117 @@
+package bmi
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	minHeightCm = 40.0
+	maxHeightCm = 220.0
+	minWeightKg = 2.0
+	maxWeightKg = 200.0
+
+	heightShrinkToleranceCm = 1.5
+
+	// A child's weight may swing by a few percent between two close measurements (meals,
+	// clothing, illness); beyond that, the allowed change grows with the time elapsed.
+	baseWeightChangeRatio    = 0.05
+	monthlyWeightChangeRatio = 0.03
+	maxWeightChangeRatio     = 0.6
+)
+
+// implausibleZScores are the WHO cut-offs beyond which a measurement is considered
+// biologically implausible for the child's age.
+var implausibleZScores = map[string][2]float64{
+	indicatorHeight: {-6, 6},
+	indicatorWeight: {-6, 5},
+	indicatorBMI:    {-5, 5},
+}
+
+// PlausibilityError is returned when a measurement looks like a typo and has not been
+// confirmed by the caller.
+type PlausibilityError struct {
+	Warnings []string
+}
+
+func (e *PlausibilityError) Error() string {
+	return fmt.Sprintf("measurement requires confirmation: %s", strings.Join(e.Warnings, "; "))
+}
+
+func validatePhysiologicalRange(height float64, weight float64) error {
+
+	if height < minHeightCm || height > maxHeightCm {
+		return fmt.Errorf("height must be between %.0f and %.0f cm", minHeightCm, maxHeightCm)
+	}
+
+	if weight < minWeightKg || weight > maxWeightKg {
+		return fmt.Errorf("weight must be between %.0f and %.0f kg", minWeightKg, maxWeightKg)
+	}
+
+	return nil
+}
+
+// checkPlausibility compares a new measurement with the previous one and, when the
+// child's gender and birth date are known, with the growth references for their age.
+func checkPlausibility(height float64, weight float64, date time.Time, previous *BMI, gender string, birthDate *time.Time) []string {
+
+	warnings := make([]string, 0)
+
+	if previous != nil {
+		if previous.Height-height > heightShrinkToleranceCm {
+			warnings = append(warnings, fmt.Sprintf("height decreased by %.1f cm since %s", previous.Height-height, previous.Date.Format("2006-01-02")))
+		}
+
+		if previous.Weight > 0 {
+			change := (weight - previous.Weight) / previous.Weight
+			if math.Abs(change) > allowedWeightChange(date.Sub(previous.Date)) {
+				warnings = append(warnings, fmt.Sprintf("weight changed by %.0f%% since %s", change*100, previous.Date.Format("2006-01-02")))
+			}
+		}
+	}
+
+	if gender == "" || birthDate == nil {
+		return warnings
+	}
+
+	age := ageInMonths(*birthDate, date)
+	values := map[string]float64{
+		indicatorHeight: height,
+		indicatorWeight: weight,
+		indicatorBMI:    calculateBMI(height, weight),
+	}
+
+	for _, indicator := range []string{indicatorHeight, indicatorWeight, indicatorBMI} {
+		lms, ok := interpolateLMS(growthReferences[gender][indicator], age)
+		if !ok {
+			continue
+		}
+		z := zScore(lms, values[indicator])
+		bounds := implausibleZScores[indicator]
+		if z < bounds[0] || z > bounds[1] {
+			warnings = append(warnings, fmt.Sprintf("%s is outside the plausible range for age (z-score %.1f)", indicator, z))
+		}
+	}
+
+	return warnings
+}
+
+// allowedWeightChange is the largest relative weight change expected over the interval.
+func allowedWeightChange(elapsed time.Duration) float64 {
+
+	months := elapsed.Hours() / 24 / daysPerMonth
+	if months < 0 {
+		months = 0
+	}
+
+	return math.Min(baseWeightChangeRatio+monthlyWeightChangeRatio*months, maxWeightChangeRatio)
+}
+
+func zScore(p lmsPoint, value float64) float64 {
+	if p.L == 0 {
+		return math.Log(value/p.M) / p.S
+	}
+	return (math.Pow(value/p.M, p.L) - 1) / (p.L * p.S)
+}
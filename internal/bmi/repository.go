@@ -2,6 +2,7 @@ package bmi
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -14,6 +15,10 @@ type BMIRepo interface {
 	CreateBMI(ctx context.Context, bmi *BMI) (string, error)
 	GetBMIs(ctx context.Context, student_id string, date *time.Time) ([]*BMI, error)
 	GetBMI(ctx context.Context, id primitive.ObjectID) (*BMI, error)
+	GetBMIsByRange(ctx context.Context, studentID string, from *time.Time, to *time.Time) ([]*BMI, error)
+	GetLatestBMIBefore(ctx context.Context, studentID string, date time.Time, excludeID primitive.ObjectID) (*BMI, error)
+	UpdateBMI(ctx context.Context, id primitive.ObjectID, bmi *BMI, audit AuditLog) error
+	DeleteBMI(ctx context.Context, id primitive.ObjectID, audit AuditLog) error
 }
 
 type bmiRepository struct {
@@ -39,7 +44,9 @@ func (b *bmiRepository) CreateBMI(ctx context.Context, bmi *BMI) (string, error)
 
 func (b *bmiRepository) GetBMIs(ctx context.Context, student_id string, date *time.Time) ([]*BMI, error) {
 
-	filter := bson.M{}
+	filter := bson.M{
+		"is_deleted": bson.M{"$ne": true},
+	}
 
 	if student_id != "" {
 		filter["student_id"] = student_id
@@ -85,11 +92,124 @@ func (b *bmiRepository) GetBMI(ctx context.Context, id primitive.ObjectID) (*BMI
 
 	var bmi BMI
 
-	err := b.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&bmi)
+	err := b.collection.FindOne(ctx, bson.M{"_id": id, "is_deleted": bson.M{"$ne": true}}).Decode(&bmi)
 	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("bmi not found")
+		}
 		return nil, err
 	}
 
 	return &bmi, nil
 	
-}
\ No newline at end of file
+}
+
+func (b *bmiRepository) GetBMIsByRange(ctx context.Context, studentID string, from *time.Time, to *time.Time) ([]*BMI, error) {
+
+	filter := bson.M{
+		"student_id": studentID,
+		"is_deleted": bson.M{"$ne": true},
+	}
+
+	dateFilter := bson.M{}
+	if from != nil {
+		dateFilter["$gte"] = *from
+	}
+	if to != nil {
+		dateFilter["$lt"] = to.Add(24 * time.Hour)
+	}
+	if len(dateFilter) > 0 {
+		filter["date"] = dateFilter
+	}
+
+	findOpts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}, {Key: "created_at", Value: 1}})
+
+	cursor, err := b.collection.Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var bmis []*BMI
+	if err := cursor.All(ctx, &bmis); err != nil {
+		return nil, err
+	}
+
+	return bmis, nil
+}
+
+// GetLatestBMIBefore returns the student's last measurement up to the date, leaving out
+// excludeID so an edited measurement is not compared with itself.
+func (b *bmiRepository) GetLatestBMIBefore(ctx context.Context, studentID string, date time.Time, excludeID primitive.ObjectID) (*BMI, error) {
+
+	filter := bson.M{
+		"_id":        bson.M{"$ne": excludeID},
+		"student_id": studentID,
+		"is_deleted": bson.M{"$ne": true},
+		"date":       bson.M{"$lte": date},
+	}
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "date", Value: -1}, {Key: "created_at", Value: -1}})
+
+	var bmi BMI
+	err := b.collection.FindOne(ctx, filter, opts).Decode(&bmi)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &bmi, nil
+}
+
+func (b *bmiRepository) UpdateBMI(ctx context.Context, id primitive.ObjectID, bmi *BMI, audit AuditLog) error {
+
+	filter := bson.M{"_id": id, "is_deleted": bson.M{"$ne": true}}
+	update := bson.M{
+		"$set": bson.M{
+			"date":       bmi.Date,
+			"height":     bmi.Height,
+			"weight":     bmi.Weight,
+			"bmi":        bmi.BMI,
+			"flags":      bmi.Flags,
+			"confirmed":  bmi.Confirmed,
+			"updated_at": bmi.UpdatedAt,
+		},
+		"$push": bson.M{"audit_logs": audit},
+	}
+
+	result, err := b.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("bmi not found")
+	}
+
+	return nil
+}
+
+func (b *bmiRepository) DeleteBMI(ctx context.Context, id primitive.ObjectID, audit AuditLog) error {
+
+	filter := bson.M{"_id": id, "is_deleted": bson.M{"$ne": true}}
+	update := bson.M{
+		"$set": bson.M{
+			"is_deleted": true,
+			"updated_at": audit.ChangedAt,
+		},
+		"$push": bson.M{"audit_logs": audit},
+	}
+
+	result, err := b.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("bmi not found")
+	}
+
+	return nil
+}
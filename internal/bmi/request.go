@@ -5,4 +5,25 @@ type CreateBMIStudentRequest struct {
 	Date      string  `json:"date" bson:"date"`
 	Height    float64 `json:"height" bson:"height"`
 	Weight    float64 `json:"weight" bson:"weight"`
+	Unit      string  `json:"unit" bson:"unit"`               // metric (cm, kg) or imperial (in, lb)
+	HeightFt  float64 `json:"height_feet" bson:"height_feet"` // imperial only, added to height in inches
+	Gender    string  `json:"gender" bson:"gender"`
+	BirthDate string  `json:"birth_date" bson:"birth_date"`
+	Confirm   bool    `json:"confirm" bson:"confirm"`
+}
+
+type UpdateBMIRequest struct {
+	Date      *string  `json:"date" bson:"date"`
+	Height    *float64 `json:"height" bson:"height"`
+	Weight    *float64 `json:"weight" bson:"weight"`
+	Unit      string   `json:"unit" bson:"unit"`
+	HeightFt  float64  `json:"height_feet" bson:"height_feet"`
+	Reason    string   `json:"reason" bson:"reason"`
+	Gender    string   `json:"gender" bson:"gender"`
+	BirthDate string   `json:"birth_date" bson:"birth_date"`
+	Confirm   bool     `json:"confirm" bson:"confirm"`
+}
+
+type DeleteBMIRequest struct {
+	Reason string `json:"reason" bson:"reason"`
 }
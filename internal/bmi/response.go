@@ -13,8 +13,60 @@ type BMIStudentResponse struct {
 	Date      string             `json:"date" bson:"date"`
 	Height    float64            `json:"height" bson:"height"`
 	Weight    float64            `json:"weight" bson:"weight"`
+	Units     *MeasurementUnits  `json:"units" bson:"units"`
 	Teacher   *user.UserInfor    `json:"teacher" bson:"teacher"`
 	BMI       float64            `json:"bmi" bson:"bmi"`
+	Flags     []string           `json:"flags" bson:"flags"`
+	AuditLogs []AuditLog         `json:"audit_logs" bson:"audit_logs"`
 	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
 }
+
+type GrowthChartResponse struct {
+	Student    *user.UserInfor   `json:"student"`
+	From       string            `json:"from"`
+	To         string            `json:"to"`
+	Units      *MeasurementUnits `json:"units"`
+	Height     []GrowthPoint     `json:"height"`
+	Weight     []GrowthPoint     `json:"weight"`
+	BMI        []GrowthPoint     `json:"bmi"`
+	Velocities []GrowthVelocity  `json:"velocities"`
+	References *GrowthReferences `json:"references"`
+}
+
+type GrowthPoint struct {
+	Date      string   `json:"date"`
+	AgeMonths *float64 `json:"age_months"`
+	Value     float64  `json:"value"`
+}
+
+type GrowthVelocity struct {
+	FromDate       string  `json:"from_date"`
+	ToDate         string  `json:"to_date"`
+	Months         float64 `json:"months"`
+	HeightPerMonth float64 `json:"height_per_month"`
+	WeightPerMonth float64 `json:"weight_per_month"`
+}
+
+type GrowthReferences struct {
+	Gender string                 `json:"gender"`
+	Height []PercentileCurvePoint `json:"height"`
+	Weight []PercentileCurvePoint `json:"weight"`
+	BMI    []PercentileCurvePoint `json:"bmi"`
+}
+
+type PercentileCurvePoint struct {
+	Date      string  `json:"date"`
+	AgeMonths float64 `json:"age_months"`
+	P3        float64 `json:"p3"`
+	P15       float64 `json:"p15"`
+	P50       float64 `json:"p50"`
+	P85       float64 `json:"p85"`
+	P97       float64 `json:"p97"`
+}
+
+type MeasurementUnits struct {
+	System string `json:"system"`
+	Height string `json:"height"`
+	Weight string `json:"weight"`
+}
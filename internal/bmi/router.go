@@ -10,9 +10,10 @@ func RegisterRoutes(r *gin.Engine, BMIHandler *BMIHandler) {
 	group := r.Group("/api/v1/bmi", middleware.Secured())
 	{
 		group.GET("/", BMIHandler.GetBMIs)
+		group.GET("/growth", BMIHandler.GetGrowthChart)
 		group.GET("/:id", BMIHandler.GetBMI)
 		group.POST("", BMIHandler.CreateBMI)
-		// group.PUT("/:id", BMIHandler.UpdateBMI)
-		// group.DELETE("/:id", BMIHandler.DeleteBMI)
+		group.PUT("/:id", BMIHandler.UpdateBMI)
+		group.DELETE("/:id", BMIHandler.DeleteBMI)
 	}
 }
\ No newline at end of file
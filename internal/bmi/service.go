@@ -12,8 +12,11 @@ import (
 
 type BMIService interface {
 	CreateBMI(ctx context.Context, req *CreateBMIStudentRequest, userID string) (string, error)
-	GetBMIs(ctx context.Context, student_id string, date string) ([]*BMIStudentResponse, error)
-	GetBMI(ctx context.Context, id string) (*BMIStudentResponse, error)
+	GetBMIs(ctx context.Context, student_id string, date string, units string) ([]*BMIStudentResponse, error)
+	GetBMI(ctx context.Context, id string, units string) (*BMIStudentResponse, error)
+	GetGrowthChart(ctx context.Context, studentID string, from string, to string, gender string, birthDate string, units string) (*GrowthChartResponse, error)
+	UpdateBMI(ctx context.Context, id string, req *UpdateBMIRequest, userID string) error
+	DeleteBMI(ctx context.Context, id string, req *DeleteBMIRequest, userID string) error
 }
 
 type bmiService struct {
@@ -39,31 +42,93 @@ func (s *bmiService) CreateBMI(ctx context.Context, req *CreateBMIStudentRequest
 		return "", fmt.Errorf("invalid date format")
 	}
 
-	if req.Height == 0 {
+	unit, err := normalizeUnit(req.Unit)
+	if err != nil {
+		return "", err
+	}
+
+	if req.HeightFt != 0 && unit != UnitImperial {
+		return "", fmt.Errorf("height_feet is only allowed with imperial unit")
+	}
+
+	// Imperial heights may be given in feet only, so check what the parts add up to.
+	height := heightToMetric(req.Height, req.HeightFt, unit)
+	weight := weightToMetric(req.Weight, unit)
+
+	if height == 0 {
 		return "", fmt.Errorf("height is required")
 	}
 
-	if req.Weight == 0 {
+	if weight == 0 {
 		return "", fmt.Errorf("weight is required")
 	}
 
+	if err := validatePhysiologicalRange(height, weight); err != nil {
+		return "", err
+	}
+
+	gender := ""
+	if req.Gender != "" {
+		gender = normalizeGender(req.Gender)
+		if gender == "" {
+			return "", fmt.Errorf("gender must be male or female")
+		}
+	}
+
+	var birthDate *time.Time
+	if req.BirthDate != "" {
+		parseBirthDate, err := time.Parse("2006-01-02", req.BirthDate)
+		if err != nil {
+			return "", fmt.Errorf("invalid birth_date format")
+		}
+		birthDate = &parseBirthDate
+	}
+
+	previous, err := s.BMIRepo.GetLatestBMIBefore(ctx, req.StudentID, parseDate, primitive.NilObjectID)
+	if err != nil {
+		return "", err
+	}
+
+	flags := checkPlausibility(height, weight, parseDate, previous, gender, birthDate)
+	if len(flags) > 0 && !req.Confirm {
+		return "", &PlausibilityError{Warnings: flags}
+	}
+
+	now := time.Now()
+
 	bmi := &BMI{
 		ID:        primitive.NewObjectID(),
 		StudentID: req.StudentID,
 		Date:      parseDate,
-		Height:    req.Height,
-		Weight:    req.Weight,
+		Height:    height,
+		Weight:    weight,
 		CreatedBy: userID,
-		BMI:       calculateBMI(req.Height, req.Weight),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		BMI:       calculateBMI(height, weight),
+		InputUnit: unit,
+		Flags:     flags,
+		Confirmed: len(flags) > 0,
+		AuditLogs: []AuditLog{
+			{
+				Action:    "create",
+				Changes:   []FieldChange{},
+				ChangedBy: userID,
+				ChangedAt: now,
+			},
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
 	}
 
 	return s.BMIRepo.CreateBMI(ctx, bmi)
 }
 
-func (s *bmiService) GetBMIs(ctx context.Context, student_id string, date string) ([]*BMIStudentResponse, error) {
-	
+func (s *bmiService) GetBMIs(ctx context.Context, student_id string, date string, units string) ([]*BMIStudentResponse, error) {
+
+	unit, err := normalizeUnit(units)
+	if err != nil {
+		return nil, err
+	}
+
 	var dateRepo *time.Time
 
 	if date != "" {
@@ -99,9 +164,12 @@ func (s *bmiService) GetBMIs(ctx context.Context, student_id string, date string
 			Student:   student,
 			Date:      bmi.Date.Format("2006-01-02"),
 			BMI:       math.Round(bmi.BMI*100) / 100,
-			Height:    bmi.Height,
-			Weight:    bmi.Weight,
+			Height:    heightFromMetric(bmi.Height, unit),
+			Weight:    weightFromMetric(bmi.Weight, unit),
+			Units:     measurementUnits(unit),
 			Teacher:   teacher,
+			Flags:     bmi.Flags,
+			AuditLogs: bmi.AuditLogs,
 			CreatedAt: bmi.CreatedAt,
 			UpdatedAt: bmi.UpdatedAt,
 		})
@@ -110,7 +178,12 @@ func (s *bmiService) GetBMIs(ctx context.Context, student_id string, date string
 	return result, nil
 }
 
-func (s *bmiService) GetBMI(ctx context.Context, id string) (*BMIStudentResponse, error) {
+func (s *bmiService) GetBMI(ctx context.Context, id string, units string) (*BMIStudentResponse, error) {
+
+	unit, err := normalizeUnit(units)
+	if err != nil {
+		return nil, err
+	}
 
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -137,15 +210,292 @@ func (s *bmiService) GetBMI(ctx context.Context, id string) (*BMIStudentResponse
 		Student:   student,
 		Date:      bmi.Date.Format("2006-01-02"),
 		BMI:       math.Round(bmi.BMI*100) / 100,
-		Height:    bmi.Height,
-		Weight:    bmi.Weight,
+		Height:    heightFromMetric(bmi.Height, unit),
+		Weight:    weightFromMetric(bmi.Weight, unit),
+		Units:     measurementUnits(unit),
 		Teacher:   teacher,
+		Flags:     bmi.Flags,
+		AuditLogs: bmi.AuditLogs,
 		CreatedAt: bmi.CreatedAt,
 		UpdatedAt: bmi.UpdatedAt,
 	}, nil
 	
 }
 
+func (s *bmiService) GetGrowthChart(ctx context.Context, studentID string, from string, to string, gender string, birthDate string, units string) (*GrowthChartResponse, error) {
+
+	if studentID == "" {
+		return nil, fmt.Errorf("student_id is required")
+	}
+
+	unit, err := normalizeUnit(units)
+	if err != nil {
+		return nil, err
+	}
+
+	var fromDate, toDate *time.Time
+
+	if from != "" {
+		parseDate, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from date format")
+		}
+		fromDate = &parseDate
+	}
+
+	if to != "" {
+		parseDate, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to date format")
+		}
+		toDate = &parseDate
+	}
+
+	if fromDate != nil && toDate != nil && toDate.Before(*fromDate) {
+		return nil, fmt.Errorf("to date must not be before from date")
+	}
+
+	var dob *time.Time
+	if birthDate != "" {
+		parseDate, err := time.Parse("2006-01-02", birthDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid birth_date format")
+		}
+		dob = &parseDate
+	}
+
+	normalizedGender := ""
+	if gender != "" {
+		normalizedGender = normalizeGender(gender)
+		if normalizedGender == "" {
+			return nil, fmt.Errorf("gender must be male or female")
+		}
+	}
+
+	bmis, err := s.BMIRepo.GetBMIsByRange(ctx, studentID, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the latest measurement of each day, the same way GetBMIs does for a single date.
+	measurements := make([]*BMI, 0, len(bmis))
+	for _, bmi := range bmis {
+		if n := len(measurements); n > 0 && measurements[n-1].Date.Equal(bmi.Date) {
+			measurements[n-1] = bmi
+			continue
+		}
+		measurements = append(measurements, bmi)
+	}
+
+	student, err := s.UserService.GetStudentInfor(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GrowthChartResponse{
+		Student:    student,
+		Units:      measurementUnits(unit),
+		Height:     []GrowthPoint{},
+		Weight:     []GrowthPoint{},
+		BMI:        []GrowthPoint{},
+		Velocities: []GrowthVelocity{},
+	}
+
+	for i, bmi := range measurements {
+
+		date := bmi.Date.Format("2006-01-02")
+
+		var age *float64
+		if dob != nil {
+			months := round2(ageInMonths(*dob, bmi.Date))
+			age = &months
+		}
+
+		result.Height = append(result.Height, GrowthPoint{Date: date, AgeMonths: age, Value: heightFromMetric(bmi.Height, unit)})
+		result.Weight = append(result.Weight, GrowthPoint{Date: date, AgeMonths: age, Value: weightFromMetric(bmi.Weight, unit)})
+		result.BMI = append(result.BMI, GrowthPoint{Date: date, AgeMonths: age, Value: round2(bmi.BMI)})
+
+		if i == 0 {
+			continue
+		}
+
+		prev := measurements[i-1]
+		months := bmi.Date.Sub(prev.Date).Hours() / 24 / daysPerMonth
+		if months <= 0 {
+			continue
+		}
+
+		result.Velocities = append(result.Velocities, GrowthVelocity{
+			FromDate:       prev.Date.Format("2006-01-02"),
+			ToDate:         date,
+			Months:         round2(months),
+			HeightPerMonth: round2(heightFromMetric(bmi.Height-prev.Height, unit) / months),
+			WeightPerMonth: round2(weightFromMetric(bmi.Weight-prev.Weight, unit) / months),
+		})
+	}
+
+	rangeStart, rangeEnd := fromDate, toDate
+	if len(measurements) > 0 {
+		if rangeStart == nil {
+			rangeStart = &measurements[0].Date
+		}
+		if rangeEnd == nil {
+			rangeEnd = &measurements[len(measurements)-1].Date
+		}
+	}
+
+	if rangeStart != nil {
+		result.From = rangeStart.Format("2006-01-02")
+	}
+	if rangeEnd != nil {
+		result.To = rangeEnd.Format("2006-01-02")
+	}
+
+	if dob != nil && normalizedGender != "" && rangeStart != nil && rangeEnd != nil {
+		result.References = &GrowthReferences{
+			Gender: normalizedGender,
+			Height: convertCurve(buildPercentileCurve(normalizedGender, indicatorHeight, *dob, *rangeStart, *rangeEnd), func(v float64) float64 { return heightFromMetric(v, unit) }),
+			Weight: convertCurve(buildPercentileCurve(normalizedGender, indicatorWeight, *dob, *rangeStart, *rangeEnd), func(v float64) float64 { return weightFromMetric(v, unit) }),
+			BMI:    buildPercentileCurve(normalizedGender, indicatorBMI, *dob, *rangeStart, *rangeEnd),
+		}
+	}
+
+	return result, nil
+}
+
+func (s *bmiService) UpdateBMI(ctx context.Context, id string, req *UpdateBMIRequest, userID string) error {
+
+	if userID == "" {
+		return fmt.Errorf("user_id is required")
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	bmi, err := s.BMIRepo.GetBMI(ctx, objectID)
+	if err != nil {
+		return err
+	}
+
+	unit, err := normalizeUnit(req.Unit)
+	if err != nil {
+		return err
+	}
+
+	if req.HeightFt != 0 && unit != UnitImperial {
+		return fmt.Errorf("height_feet is only allowed with imperial unit")
+	}
+
+	changes := make([]FieldChange, 0)
+
+	if req.Date != nil {
+		parseDate, err := time.Parse("2006-01-02", *req.Date)
+		if err != nil {
+			return fmt.Errorf("invalid date format")
+		}
+		if !parseDate.Equal(bmi.Date) {
+			changes = append(changes, FieldChange{Field: "date", From: bmi.Date.Format("2006-01-02"), To: *req.Date})
+			bmi.Date = parseDate
+		}
+	}
+
+	if req.Height != nil || req.HeightFt != 0 {
+		value := 0.0
+		if req.Height != nil {
+			value = *req.Height
+		}
+		height := heightToMetric(value, req.HeightFt, unit)
+		if height != bmi.Height {
+			changes = append(changes, FieldChange{Field: "height", From: bmi.Height, To: height})
+			bmi.Height = height
+		}
+	}
+
+	if req.Weight != nil {
+		weight := weightToMetric(*req.Weight, unit)
+		if weight != bmi.Weight {
+			changes = append(changes, FieldChange{Field: "weight", From: bmi.Weight, To: weight})
+			bmi.Weight = weight
+		}
+	}
+
+	if len(changes) == 0 {
+		return fmt.Errorf("nothing to update")
+	}
+
+	if err := validatePhysiologicalRange(bmi.Height, bmi.Weight); err != nil {
+		return err
+	}
+
+	gender := ""
+	if req.Gender != "" {
+		gender = normalizeGender(req.Gender)
+		if gender == "" {
+			return fmt.Errorf("gender must be male or female")
+		}
+	}
+
+	var birthDate *time.Time
+	if req.BirthDate != "" {
+		parseBirthDate, err := time.Parse("2006-01-02", req.BirthDate)
+		if err != nil {
+			return fmt.Errorf("invalid birth_date format")
+		}
+		birthDate = &parseBirthDate
+	}
+
+	previous, err := s.BMIRepo.GetLatestBMIBefore(ctx, bmi.StudentID, bmi.Date, bmi.ID)
+	if err != nil {
+		return err
+	}
+
+	// An edit must not bring back a value that creating it would have rejected.
+	flags := checkPlausibility(bmi.Height, bmi.Weight, bmi.Date, previous, gender, birthDate)
+	if len(flags) > 0 && !req.Confirm {
+		return &PlausibilityError{Warnings: flags}
+	}
+
+	now := time.Now()
+	bmi.BMI = calculateBMI(bmi.Height, bmi.Weight)
+	bmi.Flags = flags
+	bmi.Confirmed = len(flags) > 0
+	bmi.UpdatedAt = now
+
+	audit := AuditLog{
+		Action:    "update",
+		Changes:   changes,
+		Reason:    req.Reason,
+		ChangedBy: userID,
+		ChangedAt: now,
+	}
+
+	return s.BMIRepo.UpdateBMI(ctx, objectID, bmi, audit)
+}
+
+func (s *bmiService) DeleteBMI(ctx context.Context, id string, req *DeleteBMIRequest, userID string) error {
+
+	if userID == "" {
+		return fmt.Errorf("user_id is required")
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	audit := AuditLog{
+		Action:    "delete",
+		Changes:   []FieldChange{},
+		Reason:    req.Reason,
+		ChangedBy: userID,
+		ChangedAt: time.Now(),
+	}
+
+	return s.BMIRepo.DeleteBMI(ctx, objectID, audit)
+}
+
 func calculateBMI(height float64, weight float64) float64 {
 	height = height / 100
 	return weight / (height * height)
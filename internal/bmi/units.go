@@ -0,0 +1,88 @@
+package bmi
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	UnitMetric   = "metric"   // centimetres and kilograms
+	UnitImperial = "imperial" // inches and pounds
+
+	cmPerInch  = 2.54
+	kgPerPound = 0.45359237
+)
+
+func normalizeUnit(unit string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(unit)) {
+	case "", UnitMetric:
+		return UnitMetric, nil
+	case UnitImperial:
+		return UnitImperial, nil
+	default:
+		return "", fmt.Errorf("unit must be metric or imperial")
+	}
+}
+
+func heightUnitLabel(unit string) string {
+	if unit == UnitImperial {
+		return "in"
+	}
+	return "cm"
+}
+
+func weightUnitLabel(unit string) string {
+	if unit == UnitImperial {
+		return "lb"
+	}
+	return "kg"
+}
+
+// heightToMetric converts a height given in the request unit to centimetres. Imperial
+// heights may be split across feet and inches.
+func heightToMetric(height float64, feet float64, unit string) float64 {
+	if unit == UnitImperial {
+		return (feet*12 + height) * cmPerInch
+	}
+	return height
+}
+
+func weightToMetric(weight float64, unit string) float64 {
+	if unit == UnitImperial {
+		return weight * kgPerPound
+	}
+	return weight
+}
+
+func heightFromMetric(height float64, unit string) float64 {
+	if unit == UnitImperial {
+		return round2(height / cmPerInch)
+	}
+	return height
+}
+
+func weightFromMetric(weight float64, unit string) float64 {
+	if unit == UnitImperial {
+		return round2(weight / kgPerPound)
+	}
+	return weight
+}
+
+func measurementUnits(unit string) *MeasurementUnits {
+	return &MeasurementUnits{
+		System: unit,
+		Height: heightUnitLabel(unit),
+		Weight: weightUnitLabel(unit),
+	}
+}
+
+func convertCurve(curve []PercentileCurvePoint, convert func(float64) float64) []PercentileCurvePoint {
+	for i := range curve {
+		curve[i].P3 = convert(curve[i].P3)
+		curve[i].P15 = convert(curve[i].P15)
+		curve[i].P50 = convert(curve[i].P50)
+		curve[i].P85 = convert(curve[i].P85)
+		curve[i].P97 = convert(curve[i].P97)
+	}
+	return curve
+}
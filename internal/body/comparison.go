@@ -0,0 +1,159 @@
+package body
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	ContextHome            = "home"
+	ContextSchool          = "school"
+	ContextSchoolArrival   = "school_arrival"
+	ContextSchoolDeparture = "school_departure"
+)
+
+const (
+	phaseHome      = "home"
+	phaseArrival   = "arrival"
+	phaseDeparture = "departure"
+)
+
+// isSchoolContext accepts both the plain school context and arrival/departure variants.
+func isSchoolContext(context string) bool {
+	return checkInPhase(context) == phaseArrival || checkInPhase(context) == phaseDeparture
+}
+
+// checkInPhase places a check-in in the day: at home, on arrival at school or on departure.
+// A plain school check-in is the one taken on arrival.
+func checkInPhase(context string) string {
+
+	context = strings.ToLower(strings.TrimSpace(context))
+
+	switch {
+	case context == ContextHome:
+		return phaseHome
+	case strings.Contains(context, phaseDeparture):
+		return phaseDeparture
+	case strings.HasPrefix(context, ContextSchool), strings.Contains(context, phaseArrival):
+		return phaseArrival
+	}
+
+	return ""
+}
+
+// compareCheckIns lines up the marks of a single day for each body view: home against
+// arrival at school, and arrival against departure, so marks first seen at departure can
+// be reported as happening on premises. Feelings are not body marks and are left out.
+func compareCheckIns(checkIns []*CheckIn) []*ViewComparison {
+
+	views := map[string]*ViewComparison{}
+	marks := map[string]map[string]map[string]Mark{}
+
+	for _, checkIn := range checkIns {
+
+		if checkIn.Type == CheckInTypeFeeling {
+			continue
+		}
+
+		view, ok := views[checkIn.Type]
+		if !ok {
+			view = &ViewComparison{
+				Type:             checkIn.Type,
+				HomeMarks:        []Mark{},
+				ArrivalMarks:     []Mark{},
+				DepartureMarks:   []Mark{},
+				NewAtSchool:      []Mark{},
+				NewAtHome:        []Mark{},
+				NewOnPremises:    []Mark{},
+				InBoth:           []MarkComparison{},
+				DepartureChanges: []MarkComparison{},
+			}
+			views[checkIn.Type] = view
+			marks[checkIn.Type] = map[string]map[string]Mark{
+				phaseHome:      {},
+				phaseArrival:   {},
+				phaseDeparture: {},
+			}
+		}
+
+		phase := checkInPhase(checkIn.Context)
+
+		switch phase {
+		case phaseHome:
+			view.HomeMarks = append(view.HomeMarks, checkIn.Marks...)
+		case phaseArrival:
+			view.ArrivalRecorded = true
+			view.ArrivalMarks = append(view.ArrivalMarks, checkIn.Marks...)
+		case phaseDeparture:
+			view.DepartureMarks = append(view.DepartureMarks, checkIn.Marks...)
+		default:
+			continue
+		}
+
+		for _, mark := range checkIn.Marks {
+			marks[checkIn.Type][phase][mark.Name] = mark
+		}
+	}
+
+	result := make([]*ViewComparison, 0, len(views))
+
+	for viewType, view := range views {
+
+		home := marks[viewType][phaseHome]
+		arrival := marks[viewType][phaseArrival]
+		departure := marks[viewType][phaseDeparture]
+
+		view.NewAtSchool, view.InBoth = compareMarks(home, arrival)
+
+		for name, homeMark := range home {
+			if _, ok := arrival[name]; !ok {
+				view.NewAtHome = append(view.NewAtHome, homeMark)
+			}
+		}
+
+		// Without an arrival check-in there is nothing to tell on-premises marks apart by.
+		if view.ArrivalRecorded {
+			view.NewOnPremises, view.DepartureChanges = compareMarks(arrival, departure)
+		}
+
+		sortMarks(view.NewAtSchool)
+		sortMarks(view.NewAtHome)
+		sortMarks(view.NewOnPremises)
+		sort.Slice(view.InBoth, func(i, j int) bool { return view.InBoth[i].Name < view.InBoth[j].Name })
+		sort.Slice(view.DepartureChanges, func(i, j int) bool { return view.DepartureChanges[i].Name < view.DepartureChanges[j].Name })
+
+		result = append(result, view)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Type < result[j].Type })
+
+	return result
+}
+
+// compareMarks returns the marks found only in after, and the severity change of the marks
+// found in both.
+func compareMarks(before map[string]Mark, after map[string]Mark) ([]Mark, []MarkComparison) {
+
+	added := []Mark{}
+	both := []MarkComparison{}
+
+	for name, afterMark := range after {
+		beforeMark, ok := before[name]
+		if !ok {
+			added = append(added, afterMark)
+			continue
+		}
+		both = append(both, MarkComparison{
+			Name:           name,
+			BeforeSeverity: beforeMark.Severity,
+			AfterSeverity:  afterMark.Severity,
+			SeverityChange: afterMark.Severity - beforeMark.Severity,
+		})
+	}
+
+	return added, both
+}
+
+func sortMarks(marks []Mark) {
+	sort.Slice(marks, func(i, j int) bool { return marks[i].Name < marks[j].Name })
+}
@@ -0,0 +1,284 @@
+package body
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	CheckInTypeFeeling = "feeling"
+
+	TimeOfDayMorning   = "morning"   // before 12:00
+	TimeOfDayAfternoon = "afternoon" // 12:00 - 16:59
+	TimeOfDayEvening   = "evening"   // from 17:00
+
+	MoodNone     = "none"
+	MoodPositive = "positive"
+	MoodNegative = "negative"
+	MoodMixed    = "mixed"
+
+	// NegativeMoodAlertDays is the number of days with a negative feeling in the period
+	// from which a child is flagged on the class heatmap.
+	NegativeMoodAlertDays = 3
+
+	maxHeatmapDays = 62
+)
+
+// negativeFeelings lists the feeling names treated as negative. Anything else reported on
+// a feeling check-in counts as positive or neutral.
+var negativeFeelings = map[string]bool{
+	"sad":         true,
+	"angry":       true,
+	"scared":      true,
+	"afraid":      true,
+	"worried":     true,
+	"anxious":     true,
+	"upset":       true,
+	"lonely":      true,
+	"frustrated":  true,
+	"hurt":        true,
+	"tired":       true,
+	"sick":        true,
+	"embarrassed": true,
+	"confused":    true,
+}
+
+var timesOfDay = []string{TimeOfDayMorning, TimeOfDayAfternoon, TimeOfDayEvening}
+
+func isNegativeFeeling(name string) bool {
+	return negativeFeelings[strings.ToLower(strings.TrimSpace(name))]
+}
+
+func timeOfDay(t time.Time) string {
+	switch hour := t.Hour(); {
+	case hour < 12:
+		return TimeOfDayMorning
+	case hour < 17:
+		return TimeOfDayAfternoon
+	default:
+		return TimeOfDayEvening
+	}
+}
+
+// feelingReport is a single time a child reported a feeling. A feeling mark submitted
+// several times on the same check-in counts once per submission; staff corrections do not
+// count as reports.
+type feelingReport struct {
+	StudentID   string
+	Feeling     string
+	Context     string
+	Date        string
+	SubmittedAt time.Time
+}
+
+func feelingReports(checkIns []*CheckIn, loc *time.Location) []feelingReport {
+
+	reports := make([]feelingReport, 0)
+
+	for _, checkIn := range checkIns {
+
+		if checkIn.Type != CheckInTypeFeeling {
+			continue
+		}
+
+		date := checkIn.Date.Format("2006-01-02")
+
+		for _, mark := range checkIn.Marks {
+
+			submissions := make([]time.Time, 0, len(mark.History))
+			for _, revision := range mark.History {
+				if isStaffEdit(revision) {
+					continue
+				}
+				submissions = append(submissions, revision.SubmittedAt)
+			}
+			if len(mark.History) == 0 {
+				submissions = append(submissions, mark.SubmittedAt)
+			}
+
+			for _, submittedAt := range submissions {
+				reports = append(reports, feelingReport{
+					StudentID:   checkIn.StudentID,
+					Feeling:     strings.ToLower(strings.TrimSpace(mark.Name)),
+					Context:     checkIn.Context,
+					Date:        date,
+					SubmittedAt: submittedAt.In(loc),
+				})
+			}
+		}
+	}
+
+	return reports
+}
+
+// isStaffEdit reports whether the revision came from a staff edit rather than the child.
+func isStaffEdit(revision MarkRevision) bool {
+	return revision.Source == RevisionSourceStaffEdit
+}
+
+func buildFeelingAnalytics(reports []feelingReport) *FeelingAnalytics {
+
+	analytics := &FeelingAnalytics{
+		Frequencies:  []FeelingFrequency{},
+		TimeOfDay:    []TimeOfDayPattern{},
+		HomeVsSchool: []FeelingContextDifference{},
+	}
+
+	counts := map[string]int{}
+	periods := map[string]map[string]int{}
+	home := map[string]int{}
+	school := map[string]int{}
+	homeTotal, schoolTotal := 0, 0
+
+	for _, report := range reports {
+
+		analytics.Total++
+		if isNegativeFeeling(report.Feeling) {
+			analytics.NegativeCount++
+		}
+
+		counts[report.Feeling]++
+
+		period := timeOfDay(report.SubmittedAt)
+		if periods[period] == nil {
+			periods[period] = map[string]int{}
+		}
+		periods[period][report.Feeling]++
+
+		if isSchoolContext(report.Context) {
+			school[report.Feeling]++
+			schoolTotal++
+		} else {
+			home[report.Feeling]++
+			homeTotal++
+		}
+	}
+
+	for feeling, count := range counts {
+		analytics.Frequencies = append(analytics.Frequencies, FeelingFrequency{
+			Feeling:    feeling,
+			Count:      count,
+			Percentage: percentage(count, analytics.Total),
+			Negative:   isNegativeFeeling(feeling),
+		})
+
+		homeShare := percentage(home[feeling], homeTotal)
+		schoolShare := percentage(school[feeling], schoolTotal)
+		analytics.HomeVsSchool = append(analytics.HomeVsSchool, FeelingContextDifference{
+			Feeling:     feeling,
+			Home:        home[feeling],
+			School:      school[feeling],
+			HomeShare:   homeShare,
+			SchoolShare: schoolShare,
+			Difference:  math.Round((schoolShare-homeShare)*100) / 100,
+		})
+	}
+
+	sort.Slice(analytics.Frequencies, func(i, j int) bool {
+		if analytics.Frequencies[i].Count != analytics.Frequencies[j].Count {
+			return analytics.Frequencies[i].Count > analytics.Frequencies[j].Count
+		}
+		return analytics.Frequencies[i].Feeling < analytics.Frequencies[j].Feeling
+	})
+
+	sort.Slice(analytics.HomeVsSchool, func(i, j int) bool {
+		a, b := math.Abs(analytics.HomeVsSchool[i].Difference), math.Abs(analytics.HomeVsSchool[j].Difference)
+		if a != b {
+			return a > b
+		}
+		return analytics.HomeVsSchool[i].Feeling < analytics.HomeVsSchool[j].Feeling
+	})
+
+	for _, period := range timesOfDay {
+		pattern := TimeOfDayPattern{Period: period, Feelings: map[string]int{}}
+		for feeling, count := range periods[period] {
+			pattern.Feelings[feeling] = count
+			pattern.Total += count
+			if isNegativeFeeling(feeling) {
+				pattern.NegativeCount += count
+			}
+		}
+		analytics.TimeOfDay = append(analytics.TimeOfDay, pattern)
+	}
+
+	return analytics
+}
+
+// buildMoodHeatmap lays out one row per student and one cell per day of the period.
+// Students with no feeling check-ins still get an empty row so gaps are visible.
+func buildMoodHeatmap(studentIDs []string, reports []feelingReport, from time.Time, to time.Time) []*MoodHeatmapRow {
+
+	byStudent := map[string]map[string][]feelingReport{}
+	for _, report := range reports {
+		if byStudent[report.StudentID] == nil {
+			byStudent[report.StudentID] = map[string][]feelingReport{}
+		}
+		byStudent[report.StudentID][report.Date] = append(byStudent[report.StudentID][report.Date], report)
+	}
+
+	rows := make([]*MoodHeatmapRow, 0, len(studentIDs))
+
+	for _, studentID := range studentIDs {
+
+		row := &MoodHeatmapRow{StudentID: studentID, Cells: []MoodHeatmapCell{}}
+		streak := 0
+
+		for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+
+			date := day.Format("2006-01-02")
+			cell := MoodHeatmapCell{Date: date, Mood: MoodNone, Feelings: []string{}}
+
+			for _, report := range byStudent[studentID][date] {
+				cell.Total++
+				if isNegativeFeeling(report.Feeling) {
+					cell.Negative++
+				}
+				cell.Feelings = append(cell.Feelings, report.Feeling)
+			}
+
+			switch {
+			case cell.Total == 0:
+				// No check-in that day; the streak carries over.
+			case cell.Negative == cell.Total:
+				cell.Mood = MoodNegative
+			case cell.Negative == 0:
+				cell.Mood = MoodPositive
+			default:
+				cell.Mood = MoodMixed
+			}
+
+			if cell.Negative > 0 {
+				row.NegativeDays++
+				streak++
+				if streak > row.LongestNegativeStreak {
+					row.LongestNegativeStreak = streak
+				}
+			} else if cell.Total > 0 {
+				streak = 0
+			}
+
+			row.Cells = append(row.Cells, cell)
+		}
+
+		row.Flagged = row.NegativeDays >= NegativeMoodAlertDays
+		rows = append(rows, row)
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Flagged != rows[j].Flagged {
+			return rows[i].Flagged
+		}
+		return rows[i].NegativeDays > rows[j].NegativeDays
+	})
+
+	return rows
+}
+
+func percentage(count int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(count)/float64(total)*10000) / 100
+}
@@ -2,9 +2,12 @@ package body
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"portal/helper"
+	"portal/internal/middleware"
 	"portal/pkg/constants"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -64,7 +67,9 @@ func (h *BodyHandler) GetCheckIns(c *gin.Context) {
 
 	ctx := context.WithValue(c, constants.TokenKey, token)
 
-	checkIns, err := h.BodyService.GetCheckIns(ctx, student_id, date)
+	includeImages := middleware.HasAnyRole(c, constants.StaffRoles...)
+
+	checkIns, err := h.BodyService.GetCheckIns(ctx, student_id, date, includeImages)
 
 	if err != nil {
 		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
@@ -73,4 +78,422 @@ func (h *BodyHandler) GetCheckIns(c *gin.Context) {
 
 	helper.SendSuccess(c, 200, "Get check ins successfully", checkIns)
 	
-}
\ No newline at end of file
+}
+
+func (h *BodyHandler) GetCheckIn(c *gin.Context) {
+
+	id := c.Param("id")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	includeImages := middleware.HasAnyRole(c, constants.StaffRoles...)
+
+	checkIn, err := h.BodyService.GetCheckIn(ctx, id, includeImages)
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Get check in successfully", checkIn)
+
+}
+
+func (h *BodyHandler) UpdateCheckIn(c *gin.Context) {
+
+	id := c.Param("id")
+
+	var req UpdateCheckInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	err := h.BodyService.UpdateCheckIn(ctx, id, &req, userID.(string))
+	if errors.Is(err, ErrCheckInExists) {
+		helper.SendError(c, 409, err, helper.ErrAlreadyExists)
+		return
+	}
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Update check in successfully", nil)
+
+}
+
+func (h *BodyHandler) DeleteCheckIn(c *gin.Context) {
+
+	id := c.Param("id")
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	err := h.BodyService.DeleteCheckIn(ctx, id, userID.(string))
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Delete check in successfully", nil)
+
+}
+
+func (h *BodyHandler) UpdateMark(c *gin.Context) {
+
+	id := c.Param("id")
+	markName := c.Param("name")
+
+	var req UpdateMarkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	err := h.BodyService.UpdateMark(ctx, id, markName, &req, userID.(string))
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Update mark successfully", nil)
+
+}
+
+func (h *BodyHandler) RemoveMark(c *gin.Context) {
+
+	id := c.Param("id")
+	markName := c.Param("name")
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	err := h.BodyService.RemoveMark(ctx, id, markName, userID.(string))
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Remove mark successfully", nil)
+
+}
+
+func (h *BodyHandler) AddMarkImage(c *gin.Context) {
+
+	id := c.Param("id")
+	markName := c.Param("name")
+
+	var req AddMarkImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	err := h.BodyService.AddMarkImage(ctx, id, markName, &req)
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Add mark image successfully", nil)
+
+}
+
+func (h *BodyHandler) RemoveMarkImage(c *gin.Context) {
+
+	id := c.Param("id")
+	markName := c.Param("name")
+	imageKey := c.Query("key")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	err := h.BodyService.RemoveMarkImage(ctx, id, markName, imageKey)
+	if errors.Is(err, ErrMarkImageNotFound) {
+		helper.SendError(c, 404, err, helper.ErrNotFound)
+		return
+	}
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Remove mark image successfully", nil)
+
+}
+
+func (h *BodyHandler) GetMarkImages(c *gin.Context) {
+
+	id := c.Param("id")
+	markName := c.Param("name")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	images, err := h.BodyService.GetMarkImages(ctx, id, markName)
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Get mark images successfully", images)
+
+}
+
+func (h *BodyHandler) GetCheckInComparison(c *gin.Context) {
+
+	studentID := c.Query("student")
+	date := c.Query("date")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	includeImages := middleware.HasAnyRole(c, constants.StaffRoles...)
+
+	comparison, err := h.BodyService.GetCheckInComparison(ctx, studentID, date, includeImages)
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Get check in comparison successfully", comparison)
+
+}
+
+func (h *BodyHandler) GetMarkTimeline(c *gin.Context) {
+
+	studentID := c.Query("student")
+	checkInType := c.Query("type")
+	from := c.Query("from")
+	to := c.Query("to")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	timeline, err := h.BodyService.GetMarkTimeline(ctx, studentID, checkInType, from, to)
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Get mark timeline successfully", timeline)
+
+}
+
+func (h *BodyHandler) GetIncidents(c *gin.Context) {
+
+	studentID := c.Query("student")
+	status := c.Query("status")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	incidents, err := h.BodyService.GetIncidents(ctx, studentID, status)
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Get incidents successfully", incidents)
+
+}
+
+func (h *BodyHandler) GetIncident(c *gin.Context) {
+
+	id := c.Param("id")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	incident, err := h.BodyService.GetIncident(ctx, id)
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Get incident successfully", incident)
+
+}
+
+func (h *BodyHandler) TransitionIncident(c *gin.Context) {
+
+	id := c.Param("id")
+
+	var req TransitionIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	err := h.BodyService.TransitionIncident(ctx, id, &req, userID.(string), middleware.GetRoles(c))
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Update incident status successfully", nil)
+
+}
+
+func (h *BodyHandler) GetFeelingAnalytics(c *gin.Context) {
+
+	studentID := c.Query("student")
+	from := c.Query("from")
+	to := c.Query("to")
+	timezone := c.Query("tz")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	analytics, err := h.BodyService.GetFeelingAnalytics(ctx, studentID, from, to, timezone)
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Get feeling analytics successfully", analytics)
+
+}
+
+func (h *BodyHandler) GetMoodHeatmap(c *gin.Context) {
+
+	studentIDs := make([]string, 0)
+	for _, id := range strings.Split(c.Query("students"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			studentIDs = append(studentIDs, id)
+		}
+	}
+	from := c.Query("from")
+	to := c.Query("to")
+	timezone := c.Query("tz")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	heatmap, err := h.BodyService.GetMoodHeatmap(ctx, studentIDs, from, to, timezone)
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Get mood heatmap successfully", heatmap)
+
+}
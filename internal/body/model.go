@@ -3,6 +3,8 @@ package body
 import (
 	"time"
 
+	"portal/pkg/constants"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -14,15 +16,109 @@ type CheckIn struct {
 	Gender    *string            `json:"gender" bson:"gender"`   // male, female
 	Type      string             `json:"type" bson:"type"`       // dressed_front, dressed_back || body_front, body_back || face, feeling
 	Marks     []Mark             `json:"marks" bson:"marks"`
+	AuditLogs []AuditLog         `json:"audit_logs" bson:"audit_logs"`
+	IsDeleted bool               `json:"is_deleted" bson:"is_deleted"`
 	CreatedBy string             `json:"created_by" bson:"created_by"`
 	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+
+	// RemovedMarks are marks staff removed; they stay on the document with their history.
+	RemovedMarks []Mark `json:"removed_marks,omitempty" bson:"-"`
+}
+
+type AuditLog struct {
+	Action    string        `json:"action" bson:"action"` // update_check_in, update_mark, remove_mark, delete_check_in
+	MarkName  string        `json:"mark_name,omitempty" bson:"mark_name,omitempty"`
+	Changes   []FieldChange `json:"changes" bson:"changes"`
+	ChangedBy string        `json:"changed_by" bson:"changed_by"`
+	ChangedAt time.Time     `json:"changed_at" bson:"changed_at"`
+}
+
+type FieldChange struct {
+	Field string      `json:"field" bson:"field"`
+	From  interface{} `json:"from" bson:"from"`
+	To    interface{} `json:"to" bson:"to"`
 }
 
 type Mark struct {
-	Name        string    `json:"name" bson:"name"`
+	Name        string         `json:"name" bson:"name"`
+	Note        *string        `json:"note" bson:"note"`
+	Color       string         `json:"color" bson:"color"`
+	Severity    int            `json:"severity" bson:"severity"` // 0 records the mark as healed
+	SubmittedAt time.Time      `json:"submitted_at" bson:"submitted_at"`
+	ImageKeys   []string       `json:"image_keys" bson:"image_keys"`
+	Images      []MarkImage    `json:"images,omitempty" bson:"-"`
+	History     []MarkRevision `json:"history" bson:"history"`
+	DeletedAt   *time.Time     `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	DeletedBy   string         `json:"deleted_by,omitempty" bson:"deleted_by,omitempty"`
+}
+
+type MarkImage struct {
+	Key string `json:"key" bson:"key"`
+	Url string `json:"url" bson:"url"`
+}
+
+type MarkRevision struct {
 	Note        *string   `json:"note" bson:"note"`
 	Color       string    `json:"color" bson:"color"`
 	Severity    int       `json:"severity" bson:"severity"`
+	SubmittedBy string    `json:"submitted_by" bson:"submitted_by"`
 	SubmittedAt time.Time `json:"submitted_at" bson:"submitted_at"`
+	Source      string    `json:"source,omitempty" bson:"source,omitempty"` // check_in, staff_edit
+}
+
+const (
+	RevisionSourceCheckIn   = "check_in"
+	RevisionSourceStaffEdit = "staff_edit"
+)
+
+const (
+	IncidentStatusOpen           = "open"
+	IncidentStatusReviewed       = "reviewed"
+	IncidentStatusParentNotified = "parent_notified"
+	IncidentStatusClosed         = "closed"
+
+	// IncidentSeverityThreshold is the mark severity from which a check-in raises an incident.
+	IncidentSeverityThreshold = 3
+)
+
+// incidentTransitions lists the next status allowed from each incident status.
+var incidentTransitions = map[string]string{
+	IncidentStatusOpen:           IncidentStatusReviewed,
+	IncidentStatusReviewed:       IncidentStatusParentNotified,
+	IncidentStatusParentNotified: IncidentStatusClosed,
+}
+
+// incidentTransitionRoles lists who may move an incident into each status. Reviewing is the
+// safeguarding lead's acknowledgement. The portal sends no message itself: moving to
+// parent_notified records that staff have told the parents, and how.
+var incidentTransitionRoles = map[string][]string{
+	IncidentStatusReviewed:       {constants.RoleCoordinator, constants.RoleAdmin},
+	IncidentStatusParentNotified: constants.StaffRoles,
+	IncidentStatusClosed:         constants.StaffRoles,
+}
+
+type Incident struct {
+	ID          primitive.ObjectID   `json:"id" bson:"_id"`
+	StudentID   string               `json:"student_id" bson:"student_id"`
+	Date        time.Time            `json:"date" bson:"date"`
+	Context     string               `json:"context" bson:"context"`
+	Type        string               `json:"type" bson:"type"`
+	MarkName    string               `json:"mark_name" bson:"mark_name"`
+	Color       string               `json:"color" bson:"color"`
+	Severity    int                  `json:"severity" bson:"severity"`
+	Note        *string              `json:"note" bson:"note"`
+	Status      string               `json:"status" bson:"status"`
+	Transitions []IncidentTransition `json:"transitions" bson:"transitions"`
+	CreatedBy   string               `json:"created_by" bson:"created_by"`
+	CreatedAt   time.Time            `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at" bson:"updated_at"`
+}
+
+type IncidentTransition struct {
+	From      string    `json:"from" bson:"from"`
+	To        string    `json:"to" bson:"to"`
+	Comment   string    `json:"comment" bson:"comment"`
+	ChangedBy string    `json:"changed_by" bson:"changed_by"`
+	ChangedAt time.Time `json:"changed_at" bson:"changed_at"`
 }
@@ -2,26 +2,52 @@ package body
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+var (
+	// ErrMarkImageNotFound is returned when the image key is not attached to the mark.
+	ErrMarkImageNotFound = errors.New("mark image not found")
+	// ErrCheckInExists is returned when another check-in already has the same date, context and gender.
+	ErrCheckInExists = errors.New("a check in already exists for this date, context and gender")
+)
+
 type BodyRepository interface {
 	PushCheckIn(ctx context.Context, checkIn *CheckIn) error
 	GetCheckIns(ctx context.Context, student_id string, date *time.Time) ([]*CheckIn, error)
+	GetCheckIn(ctx context.Context, id primitive.ObjectID) (*CheckIn, error)
+	AddMarkImage(ctx context.Context, id primitive.ObjectID, markName string, imageKey string) error
+	RemoveMarkImage(ctx context.Context, id primitive.ObjectID, markName string, imageKey string) error
+	UpdateCheckIn(ctx context.Context, id primitive.ObjectID, checkIn *CheckIn, audit AuditLog) error
+	CheckInExists(ctx context.Context, checkIn *CheckIn, excludeID primitive.ObjectID) (bool, error)
+	MoveIncidents(ctx context.Context, from *CheckIn, to *CheckIn) error
+	UpdateMark(ctx context.Context, id primitive.ObjectID, mark *Mark, revision MarkRevision, audit AuditLog) error
+	RemoveMark(ctx context.Context, id primitive.ObjectID, markName string, audit AuditLog) error
+	DeleteCheckIn(ctx context.Context, id primitive.ObjectID, audit AuditLog) error
+	GetCheckInsByRange(ctx context.Context, studentID string, checkInType string, from *time.Time, to *time.Time) ([]*CheckIn, error)
+	GetCheckInsByStudents(ctx context.Context, studentIDs []string, checkInType string, from time.Time, to time.Time) ([]*CheckIn, error)
+	UpsertIncident(ctx context.Context, incident *Incident) error
+	GetIncidents(ctx context.Context, studentID string, status string) ([]*Incident, error)
+	GetIncident(ctx context.Context, id primitive.ObjectID) (*Incident, error)
+	TransitionIncident(ctx context.Context, id primitive.ObjectID, transition IncidentTransition) error
 }
 
 type bodyRepository struct {
-	collection *mongo.Collection
+	collection         *mongo.Collection
+	incidentCollection *mongo.Collection
 }
 
-func NewBodyRepository(collection *mongo.Collection) BodyRepository {
+func NewBodyRepository(collection *mongo.Collection, incidentCollection *mongo.Collection) BodyRepository {
 	return &bodyRepository{
-		collection: collection,
+		collection:         collection,
+		incidentCollection: incidentCollection,
 	}
 }
 
@@ -33,18 +59,35 @@ func (r *bodyRepository) PushCheckIn(ctx context.Context, checkIn *CheckIn) erro
 		"date":       checkIn.Date,
 		"context":    checkIn.Context,
 		"gender":     checkIn.Gender,
+		"is_deleted": bson.M{"$ne": true},
 	}
 
 	now := time.Now()
 
 	for _, mark := range checkIn.Marks {
 
+		revision := MarkRevision{
+			Note:        mark.Note,
+			Color:       mark.Color,
+			Severity:    mark.Severity,
+			SubmittedBy: checkIn.CreatedBy,
+			SubmittedAt: now,
+			Source:      RevisionSourceCheckIn,
+		}
+
+		imageKeys := mark.ImageKeys
+		if imageKeys == nil {
+			imageKeys = []string{}
+		}
+
 		markData := bson.M{
 			"name":         mark.Name,
 			"note":         mark.Note,
 			"color":        mark.Color,
 			"severity":     mark.Severity,
 			"submitted_at": now,
+			"image_keys":   imageKeys,
+			"history":      []MarkRevision{revision},
 		}
 
 		filterWithMark := bson.M{
@@ -53,13 +96,29 @@ func (r *bodyRepository) PushCheckIn(ctx context.Context, checkIn *CheckIn) erro
 			"date":       checkIn.Date,
 			"context":    checkIn.Context,
 			"gender":     checkIn.Gender,
+			"is_deleted": bson.M{"$ne": true},
 			"marks.name": mark.Name, 
 		}
 
+		// Keep the previous values of the mark by appending each submission to its history.
 		updateExisting := bson.M{
 			"$set": bson.M{
-				"marks.$":    markData, 
-				"updated_at": now,
+				"marks.$.note":         mark.Note,
+				"marks.$.color":        mark.Color,
+				"marks.$.severity":     mark.Severity,
+				"marks.$.submitted_at": now,
+				"updated_at":           now,
+			},
+			"$push": bson.M{
+				"marks.$.history": revision,
+			},
+			"$addToSet": bson.M{
+				"marks.$.image_keys": bson.M{"$each": imageKeys},
+			},
+			// A mark reported again after staff removed it is active again, with its history.
+			"$unset": bson.M{
+				"marks.$.deleted_at": "",
+				"marks.$.deleted_by": "",
 			},
 		}
 
@@ -79,6 +138,7 @@ func (r *bodyRepository) PushCheckIn(ctx context.Context, checkIn *CheckIn) erro
 					"created_by": checkIn.CreatedBy,
 					"created_at": now,
 					"marks":      []interface{}{}, 
+					"audit_logs": []interface{}{},
 				},
 				"$set": bson.M{
 					"updated_at": now,
@@ -113,6 +173,7 @@ func (r *bodyRepository) GetCheckIns(ctx context.Context, student_id string, dat
 
 	filter := bson.M{
 		"student_id": student_id,
+		"is_deleted": bson.M{"$ne": true},
 	}
 
 	if date != nil {
@@ -137,6 +198,458 @@ func (r *bodyRepository) GetCheckIns(ctx context.Context, student_id string, dat
 		return nil, err
 	}
 
+	splitRemovedMarks(checkIns...)
+
 	return checkIns, nil
 	
-}
\ No newline at end of file
+}
+
+func (r *bodyRepository) GetCheckIn(ctx context.Context, id primitive.ObjectID) (*CheckIn, error) {
+
+	var checkIn CheckIn
+
+	err := r.collection.FindOne(ctx, bson.M{"_id": id, "is_deleted": bson.M{"$ne": true}}).Decode(&checkIn)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("check in not found")
+		}
+		return nil, err
+	}
+
+	splitRemovedMarks(&checkIn)
+
+	return &checkIn, nil
+}
+
+func (r *bodyRepository) AddMarkImage(ctx context.Context, id primitive.ObjectID, markName string, imageKey string) error {
+
+	filter := bson.M{
+		"_id":        id,
+		"is_deleted": bson.M{"$ne": true},
+		"marks":      activeMark(markName),
+	}
+
+	update := bson.M{
+		"$addToSet": bson.M{
+			"marks.$.image_keys": imageKey,
+		},
+		"$set": bson.M{
+			"updated_at": time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("error when add mark image: %v", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("mark not found")
+	}
+
+	return nil
+}
+
+func (r *bodyRepository) RemoveMarkImage(ctx context.Context, id primitive.ObjectID, markName string, imageKey string) error {
+
+	// Match the key on the mark itself so only an image the mark really holds is pulled.
+	filter := bson.M{
+		"_id":        id,
+		"is_deleted": bson.M{"$ne": true},
+		"marks": bson.M{
+			"$elemMatch": bson.M{"name": markName, "image_keys": imageKey, "deleted_at": bson.M{"$exists": false}},
+		},
+	}
+
+	update := bson.M{
+		"$pull": bson.M{
+			"marks.$.image_keys": imageKey,
+		},
+		"$set": bson.M{
+			"updated_at": time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("error when remove mark image: %v", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrMarkImageNotFound
+	}
+
+	return nil
+}
+
+func (r *bodyRepository) UpdateCheckIn(ctx context.Context, id primitive.ObjectID, checkIn *CheckIn, audit AuditLog) error {
+
+	filter := bson.M{"_id": id, "is_deleted": bson.M{"$ne": true}}
+	update := bson.M{
+		"$set": bson.M{
+			"date":       checkIn.Date,
+			"context":    checkIn.Context,
+			"gender":     checkIn.Gender,
+			"updated_at": audit.ChangedAt,
+		},
+		"$push": bson.M{"audit_logs": audit},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("error when update check in: %v", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("check in not found")
+	}
+
+	return nil
+}
+
+func (r *bodyRepository) CheckInExists(ctx context.Context, checkIn *CheckIn, excludeID primitive.ObjectID) (bool, error) {
+
+	filter := bson.M{
+		"_id":        bson.M{"$ne": excludeID},
+		"student_id": checkIn.StudentID,
+		"type":       checkIn.Type,
+		"date":       checkIn.Date,
+		"context":    checkIn.Context,
+		"gender":     checkIn.Gender,
+		"is_deleted": bson.M{"$ne": true},
+	}
+
+	count, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("error when check check in: %v", err)
+	}
+
+	return count > 0, nil
+}
+
+// MoveIncidents re-keys the incidents raised from a check-in after its date or context changed.
+func (r *bodyRepository) MoveIncidents(ctx context.Context, from *CheckIn, to *CheckIn) error {
+
+	filter := bson.M{
+		"student_id": from.StudentID,
+		"type":       from.Type,
+		"date":       from.Date,
+		"context":    from.Context,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"date":       to.Date,
+			"context":    to.Context,
+			"updated_at": time.Now(),
+		},
+	}
+
+	if _, err := r.incidentCollection.UpdateMany(ctx, filter, update); err != nil {
+		return fmt.Errorf("error when move incidents: %v", err)
+	}
+
+	return nil
+}
+
+func (r *bodyRepository) UpdateMark(ctx context.Context, id primitive.ObjectID, mark *Mark, revision MarkRevision, audit AuditLog) error {
+
+	filter := bson.M{
+		"_id":        id,
+		"is_deleted": bson.M{"$ne": true},
+		"marks":      activeMark(mark.Name),
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"marks.$.note":         mark.Note,
+			"marks.$.color":        mark.Color,
+			"marks.$.severity":     mark.Severity,
+			"marks.$.submitted_at": revision.SubmittedAt,
+			"updated_at":           audit.ChangedAt,
+		},
+		"$push": bson.M{
+			"marks.$.history": revision,
+			"audit_logs":      audit,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("error when update mark: %v", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("mark not found")
+	}
+
+	return nil
+}
+
+// RemoveMark soft-deletes the mark so its history stays on record for safeguarding. Its
+// photos are deleted with it, so the image keys are cleared.
+func (r *bodyRepository) RemoveMark(ctx context.Context, id primitive.ObjectID, markName string, audit AuditLog) error {
+
+	filter := bson.M{
+		"_id":        id,
+		"is_deleted": bson.M{"$ne": true},
+		"marks":      activeMark(markName),
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"marks.$.deleted_at": audit.ChangedAt,
+			"marks.$.deleted_by": audit.ChangedBy,
+			"marks.$.image_keys": []string{},
+			"updated_at":         audit.ChangedAt,
+		},
+		"$push": bson.M{"audit_logs": audit},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("error when remove mark: %v", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("mark not found")
+	}
+
+	return nil
+}
+
+func (r *bodyRepository) DeleteCheckIn(ctx context.Context, id primitive.ObjectID, audit AuditLog) error {
+
+	filter := bson.M{"_id": id, "is_deleted": bson.M{"$ne": true}}
+	update := bson.M{
+		"$set": bson.M{
+			"is_deleted": true,
+			"updated_at": audit.ChangedAt,
+		},
+		"$push": bson.M{"audit_logs": audit},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("error when delete check in: %v", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("check in not found")
+	}
+
+	return nil
+}
+
+func (r *bodyRepository) GetCheckInsByRange(ctx context.Context, studentID string, checkInType string, from *time.Time, to *time.Time) ([]*CheckIn, error) {
+
+	filter := bson.M{
+		"student_id": studentID,
+		"is_deleted": bson.M{"$ne": true},
+	}
+
+	if checkInType != "" {
+		filter["type"] = checkInType
+	}
+
+	dateFilter := bson.M{}
+	if from != nil {
+		dateFilter["$gte"] = *from
+	}
+	if to != nil {
+		dateFilter["$lt"] = to.Add(24 * time.Hour)
+	}
+	if len(dateFilter) > 0 {
+		filter["date"] = dateFilter
+	}
+
+	findOpts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}, {Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var checkIns []*CheckIn
+	if err := cursor.All(ctx, &checkIns); err != nil {
+		return nil, err
+	}
+
+	splitRemovedMarks(checkIns...)
+
+	return checkIns, nil
+}
+
+func (r *bodyRepository) UpsertIncident(ctx context.Context, incident *Incident) error {
+
+	// A mark that is re-submitted on the same check-in keeps a single incident as long as it is not closed.
+	filter := bson.M{
+		"student_id": incident.StudentID,
+		"date":       incident.Date,
+		"context":    incident.Context,
+		"type":       incident.Type,
+		"mark_name":  incident.MarkName,
+		"status":     bson.M{"$ne": IncidentStatusClosed},
+	}
+
+	update := bson.M{
+		"$setOnInsert": bson.M{
+			"_id":         incident.ID,
+			"student_id":  incident.StudentID,
+			"date":        incident.Date,
+			"context":     incident.Context,
+			"type":        incident.Type,
+			"mark_name":   incident.MarkName,
+			"status":      incident.Status,
+			"transitions": incident.Transitions,
+			"created_by":  incident.CreatedBy,
+			"created_at":  incident.CreatedAt,
+		},
+		"$set": bson.M{
+			"color":      incident.Color,
+			"note":       incident.Note,
+			"updated_at": incident.UpdatedAt,
+		},
+		"$max": bson.M{
+			"severity": incident.Severity,
+		},
+	}
+
+	_, err := r.incidentCollection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("error when upsert incident: %v", err)
+	}
+
+	return nil
+}
+
+func (r *bodyRepository) GetIncidents(ctx context.Context, studentID string, status string) ([]*Incident, error) {
+
+	filter := bson.M{}
+
+	if studentID != "" {
+		filter["student_id"] = studentID
+	}
+
+	if status != "" {
+		filter["status"] = status
+	}
+
+	findOpts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.incidentCollection.Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var incidents []*Incident
+	if err := cursor.All(ctx, &incidents); err != nil {
+		return nil, err
+	}
+
+	return incidents, nil
+}
+
+func (r *bodyRepository) GetIncident(ctx context.Context, id primitive.ObjectID) (*Incident, error) {
+
+	var incident Incident
+
+	err := r.incidentCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&incident)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("incident not found")
+		}
+		return nil, err
+	}
+
+	return &incident, nil
+}
+
+func (r *bodyRepository) TransitionIncident(ctx context.Context, id primitive.ObjectID, transition IncidentTransition) error {
+
+	// Matching on the current status keeps two concurrent transitions from both succeeding.
+	filter := bson.M{
+		"_id":    id,
+		"status": transition.From,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":     transition.To,
+			"updated_at": transition.ChangedAt,
+		},
+		"$push": bson.M{
+			"transitions": transition,
+		},
+	}
+
+	result, err := r.incidentCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("incident status has changed, please reload")
+	}
+
+	return nil
+}
+
+func (r *bodyRepository) GetCheckInsByStudents(ctx context.Context, studentIDs []string, checkInType string, from time.Time, to time.Time) ([]*CheckIn, error) {
+
+	filter := bson.M{
+		"student_id": bson.M{"$in": studentIDs},
+		"is_deleted": bson.M{"$ne": true},
+		"date": bson.M{
+			"$gte": from,
+			"$lt":  to.Add(24 * time.Hour),
+		},
+	}
+
+	if checkInType != "" {
+		filter["type"] = checkInType
+	}
+
+	findOpts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}, {Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var checkIns []*CheckIn
+	if err := cursor.All(ctx, &checkIns); err != nil {
+		return nil, err
+	}
+
+	splitRemovedMarks(checkIns...)
+
+	return checkIns, nil
+}
+
+// activeMark matches a mark by name unless staff removed it.
+func activeMark(name string) bson.M {
+	return bson.M{"$elemMatch": bson.M{"name": name, "deleted_at": bson.M{"$exists": false}}}
+}
+
+// splitRemovedMarks moves removed marks out of Marks so comparisons, timelines and
+// analytics only see the active ones.
+func splitRemovedMarks(checkIns ...*CheckIn) {
+
+	for _, checkIn := range checkIns {
+
+		active := make([]Mark, 0, len(checkIn.Marks))
+		for _, mark := range checkIn.Marks {
+			if mark.DeletedAt != nil {
+				checkIn.RemovedMarks = append(checkIn.RemovedMarks, mark)
+			} else {
+				active = append(active, mark)
+			}
+		}
+
+		checkIn.Marks = active
+	}
+}
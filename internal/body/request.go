@@ -8,4 +8,25 @@ type CreateCheckInRequest struct {
 	Type      string `json:"type" bson:"type"`
 	Marks     []Mark `json:"marks" bson:"marks"`
 	CreatedBy string `json:"created_by" bson:"created_by"`
-}
\ No newline at end of file
+}
+
+type TransitionIncidentRequest struct {
+	Status  string `json:"status" bson:"status"`
+	Comment string `json:"comment" bson:"comment"`
+}
+
+type AddMarkImageRequest struct {
+	ImageKey string `json:"image_key" bson:"image_key"`
+}
+
+type UpdateCheckInRequest struct {
+	Date    *string `json:"date" bson:"date"`
+	Context *string `json:"context" bson:"context"`
+	Gender  *string `json:"gender" bson:"gender"`
+}
+
+type UpdateMarkRequest struct {
+	Note     *string `json:"note" bson:"note"`
+	Color    *string `json:"color" bson:"color"`
+	Severity *int    `json:"severity" bson:"severity"`
+}
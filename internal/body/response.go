@@ -15,7 +15,149 @@ type CheckInReponse struct {
 	Gender    *string            `json:"gender" bson:"gender"`
 	Type      string             `json:"type" bson:"type"`
 	Marks     []Mark             `json:"marks" bson:"marks"`
+	RemovedMarks []Mark          `json:"removed_marks,omitempty" bson:"removed_marks,omitempty"`
+	AuditLogs []AuditLog         `json:"audit_logs" bson:"audit_logs"`
 	Teacher   *user.UserInfor    `json:"teacher" bson:"teacher"`
 	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
-}
\ No newline at end of file
+}
+
+type IncidentResponse struct {
+	ID          primitive.ObjectID   `json:"id" bson:"_id"`
+	Student     *user.UserInfor      `json:"student" bson:"student"`
+	Date        string               `json:"date" bson:"date"`
+	Context     string               `json:"context" bson:"context"`
+	Type        string               `json:"type" bson:"type"`
+	MarkName    string               `json:"mark_name" bson:"mark_name"`
+	Color       string               `json:"color" bson:"color"`
+	Severity    int                  `json:"severity" bson:"severity"`
+	Note        *string              `json:"note" bson:"note"`
+	Status      string               `json:"status" bson:"status"`
+	Transitions []IncidentTransition `json:"transitions" bson:"transitions"`
+	Teacher     *user.UserInfor      `json:"teacher" bson:"teacher"`
+	CreatedAt   time.Time            `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at" bson:"updated_at"`
+}
+
+type MarkTimeline struct {
+	Name         string              `json:"name"`
+	FirstSeen    string              `json:"first_seen"`
+	LastSeen     string              `json:"last_seen"`
+	ResolvedDate *string             `json:"resolved_date"`
+	Recurrences  int                 `json:"recurrences"`
+	Episodes     []MarkEpisode       `json:"episodes"`
+	Entries      []MarkTimelineEntry `json:"entries"`
+}
+
+type MarkEpisode struct {
+	StartDate    string  `json:"start_date"`
+	ResolvedDate *string `json:"resolved_date"`
+	Type         string  `json:"type"`
+	MaxSeverity  int     `json:"max_severity"`
+}
+
+type MarkTimelineEntry struct {
+	Date        string    `json:"date"`
+	Context     string    `json:"context"`
+	Type        string    `json:"type"`
+	Severity    int       `json:"severity"`
+	Color       string    `json:"color"`
+	Note        *string   `json:"note"`
+	SubmittedBy string    `json:"submitted_by"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+type MarkTimelineResponse struct {
+	Student *user.UserInfor `json:"student"`
+	From    string          `json:"from"`
+	To      string          `json:"to"`
+	Marks   []*MarkTimeline `json:"marks"`
+}
+
+type CheckInComparisonResponse struct {
+	Student *user.UserInfor   `json:"student"`
+	Date    string            `json:"date"`
+	Views   []*ViewComparison `json:"views"`
+}
+
+type ViewComparison struct {
+	Type             string           `json:"type"`
+	HomeMarks        []Mark           `json:"home_marks"`
+	ArrivalMarks     []Mark           `json:"arrival_marks"`
+	DepartureMarks   []Mark           `json:"departure_marks"`
+	NewAtSchool      []Mark           `json:"new_at_school"` // seen on arrival, not at home
+	NewAtHome        []Mark           `json:"new_at_home"`   // seen at home, not on arrival
+	InBoth           []MarkComparison `json:"in_both"`       // home against arrival
+	ArrivalRecorded  bool             `json:"arrival_recorded"`
+	NewOnPremises    []Mark           `json:"new_on_premises"`   // seen on departure, not on arrival
+	DepartureChanges []MarkComparison `json:"departure_changes"` // arrival against departure
+}
+
+// MarkComparison compares the severity of a mark between an earlier and a later check-in.
+type MarkComparison struct {
+	Name           string `json:"name"`
+	BeforeSeverity int    `json:"before_severity"`
+	AfterSeverity  int    `json:"after_severity"`
+	SeverityChange int    `json:"severity_change"`
+}
+
+type FeelingAnalyticsResponse struct {
+	Student *user.UserInfor `json:"student"`
+	From    string          `json:"from"`
+	To      string          `json:"to"`
+	*FeelingAnalytics
+}
+
+type FeelingAnalytics struct {
+	Total         int                        `json:"total"`
+	NegativeCount int                        `json:"negative_count"`
+	Frequencies   []FeelingFrequency         `json:"frequencies"`
+	TimeOfDay     []TimeOfDayPattern         `json:"time_of_day"`
+	HomeVsSchool  []FeelingContextDifference `json:"home_vs_school"`
+}
+
+type FeelingFrequency struct {
+	Feeling    string  `json:"feeling"`
+	Count      int     `json:"count"`
+	Percentage float64 `json:"percentage"`
+	Negative   bool    `json:"negative"`
+}
+
+type TimeOfDayPattern struct {
+	Period        string         `json:"period"`
+	Total         int            `json:"total"`
+	NegativeCount int            `json:"negative_count"`
+	Feelings      map[string]int `json:"feelings"`
+}
+
+type FeelingContextDifference struct {
+	Feeling     string  `json:"feeling"`
+	Home        int     `json:"home"`
+	School      int     `json:"school"`
+	HomeShare   float64 `json:"home_share"`
+	SchoolShare float64 `json:"school_share"`
+	Difference  float64 `json:"difference"` // school share minus home share, in percentage points
+}
+
+type MoodHeatmapResponse struct {
+	From     string            `json:"from"`
+	To       string            `json:"to"`
+	Students []*MoodHeatmapRow `json:"students"`
+}
+
+type MoodHeatmapRow struct {
+	StudentID             string            `json:"student_id"`
+	Student               *user.UserInfor   `json:"student"`
+	NegativeDays          int               `json:"negative_days"`
+	LongestNegativeStreak int               `json:"longest_negative_streak"`
+	Flagged               bool              `json:"flagged"`
+	Cells                 []MoodHeatmapCell `json:"cells"`
+}
+
+type MoodHeatmapCell struct {
+	Date     string   `json:"date"`
+	Mood     string   `json:"mood"` // none, positive, negative, mixed
+	Total    int      `json:"total"`
+	Negative int      `json:"negative"`
+	Feelings []string `json:"feelings"`
+}
@@ -2,6 +2,7 @@ package body
 
 import (
 	"portal/internal/middleware"
+	"portal/pkg/constants"
 
 	"github.com/gin-gonic/gin"
 )
@@ -10,9 +11,33 @@ func RegisterRoutes(r *gin.Engine, BodyHandler *BodyHandler) {
 	group := r.Group("/api/v1/body", middleware.Secured())
 	{
 		group.GET("", BodyHandler.GetCheckIns)
-		// group.GET("/:id", BodyHandler.GetCheckIn)
+		group.GET("/:id", BodyHandler.GetCheckIn)
 		group.POST("", BodyHandler.CreateCheckIn)
-		// group.PUT("/:id", BodyHandler.UpdateCheckIn)
-		// group.DELETE("/:id", BodyHandler.DeleteCheckIn)
+		group.PUT("/:id", BodyHandler.UpdateCheckIn)
+		group.DELETE("/:id", middleware.RequireRoles(constants.StaffRoles...), BodyHandler.DeleteCheckIn)
+
+		group.PUT("/:id/marks/:name", BodyHandler.UpdateMark)
+		group.DELETE("/:id/marks/:name", middleware.RequireRoles(constants.StaffRoles...), BodyHandler.RemoveMark)
+
+		group.GET("/comparison", BodyHandler.GetCheckInComparison)
+		group.GET("/timeline", BodyHandler.GetMarkTimeline)
+
+		group.GET("/feelings/analytics", BodyHandler.GetFeelingAnalytics)
+		group.GET("/feelings/heatmap", middleware.RequireRoles(constants.StaffRoles...), BodyHandler.GetMoodHeatmap)
+
+		// Incidents carry safeguarding notes, so they are for staff only.
+		incidents := group.Group("/incidents", middleware.RequireRoles(constants.StaffRoles...))
+		{
+			incidents.GET("", BodyHandler.GetIncidents)
+			incidents.GET("/:id", BodyHandler.GetIncident)
+			incidents.POST("/:id/transition", BodyHandler.TransitionIncident)
+		}
+
+		images := group.Group("/:id/marks/:name/images", middleware.RequireRoles(constants.StaffRoles...))
+		{
+			images.GET("", BodyHandler.GetMarkImages)
+			images.POST("", BodyHandler.AddMarkImage)
+			images.DELETE("", BodyHandler.RemoveMarkImage)
+		}
 	}
 }
@@ -3,7 +3,10 @@ package body
 import (
 	"context"
 	"fmt"
+	"portal/internal/middleware"
 	"portal/internal/user"
+	"portal/pkg/uploader"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -11,25 +14,42 @@ import (
 
 type BodyService interface {
 	CreateCheckIn(ctx context.Context, req *CreateCheckInRequest, userID string) error
-	GetCheckIns(ctx context.Context, student_id string, date string) ([]*CheckInReponse, error)
+	GetCheckIns(ctx context.Context, student_id string, date string, includeImages bool) ([]*CheckInReponse, error)
+	GetCheckIn(ctx context.Context, id string, includeImages bool) (*CheckInReponse, error)
+	UpdateCheckIn(ctx context.Context, id string, req *UpdateCheckInRequest, userID string) error
+	DeleteCheckIn(ctx context.Context, id string, userID string) error
+	UpdateMark(ctx context.Context, id string, markName string, req *UpdateMarkRequest, userID string) error
+	RemoveMark(ctx context.Context, id string, markName string, userID string) error
+	AddMarkImage(ctx context.Context, id string, markName string, req *AddMarkImageRequest) error
+	RemoveMarkImage(ctx context.Context, id string, markName string, imageKey string) error
+	GetMarkImages(ctx context.Context, id string, markName string) ([]MarkImage, error)
+	GetCheckInComparison(ctx context.Context, studentID string, date string, includeImages bool) (*CheckInComparisonResponse, error)
+	GetMarkTimeline(ctx context.Context, studentID string, checkInType string, from string, to string) (*MarkTimelineResponse, error)
+	GetFeelingAnalytics(ctx context.Context, studentID string, from string, to string, timezone string) (*FeelingAnalyticsResponse, error)
+	GetMoodHeatmap(ctx context.Context, studentIDs []string, from string, to string, timezone string) (*MoodHeatmapResponse, error)
+	GetIncidents(ctx context.Context, studentID string, status string) ([]*IncidentResponse, error)
+	GetIncident(ctx context.Context, id string) (*IncidentResponse, error)
+	TransitionIncident(ctx context.Context, id string, req *TransitionIncidentRequest, userID string, roles []string) error
 }
 
 type bodyService struct {
 	BodyRepository BodyRepository
 	UserService    user.UserService
+	ImageService   uploader.ImageService
 }
 
-func NewBodyService(bodyRepository BodyRepository, userService user.UserService) BodyService {
+func NewBodyService(bodyRepository BodyRepository, userService user.UserService, imageService uploader.ImageService) BodyService {
 	return &bodyService{
 		BodyRepository: bodyRepository,
 		UserService:    userService,
+		ImageService:   imageService,
 	}
 }
 
 func (s *bodyService) CreateCheckIn(ctx context.Context, req *CreateCheckInRequest, userID string) error {
 
 	if userID == "" {
-		return nil
+		return fmt.Errorf("user_id is required")
 	}
 
 	if req.Date == "" {
@@ -62,7 +82,13 @@ func (s *bodyService) CreateCheckIn(ctx context.Context, req *CreateCheckInReque
 		if req.Marks[i].Color == "" {
 			return fmt.Errorf("color is required")
 		}
+		// Photos are attached through the image routes and the audit fields are server-owned.
 		req.Marks[i].SubmittedAt = now
+		req.Marks[i].ImageKeys = nil
+		req.Marks[i].Images = nil
+		req.Marks[i].History = nil
+		req.Marks[i].DeletedAt = nil
+		req.Marks[i].DeletedBy = ""
 	}
 
 	checkIn := &CheckIn{
@@ -84,10 +110,54 @@ func (s *bodyService) CreateCheckIn(ctx context.Context, req *CreateCheckInReque
 		return err
 	}
 
+	return s.raiseIncidents(ctx, checkIn)
+}
+
+// raiseIncidents opens an incident for every mark at or above the severity threshold.
+func (s *bodyService) raiseIncidents(ctx context.Context, checkIn *CheckIn) error {
+
+	now := time.Now()
+
+	for _, mark := range checkIn.Marks {
+
+		if mark.Severity < IncidentSeverityThreshold {
+			continue
+		}
+
+		incident := &Incident{
+			ID:        primitive.NewObjectID(),
+			StudentID: checkIn.StudentID,
+			Date:      checkIn.Date,
+			Context:   checkIn.Context,
+			Type:      checkIn.Type,
+			MarkName:  mark.Name,
+			Color:     mark.Color,
+			Severity:  mark.Severity,
+			Note:      mark.Note,
+			Status:    IncidentStatusOpen,
+			Transitions: []IncidentTransition{
+				{
+					From:      "",
+					To:        IncidentStatusOpen,
+					Comment:   fmt.Sprintf("raised automatically from check-in mark with severity %d", mark.Severity),
+					ChangedBy: checkIn.CreatedBy,
+					ChangedAt: now,
+				},
+			},
+			CreatedBy: checkIn.CreatedBy,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+
+		if err := s.BodyRepository.UpsertIncident(ctx, incident); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-func (s *bodyService) GetCheckIns(ctx context.Context, student_id string, date string) ([]*CheckInReponse, error) {
+func (s *bodyService) GetCheckIns(ctx context.Context, student_id string, date string, includeImages bool) ([]*CheckInReponse, error) {
 
 	var dateRepo *time.Time
 
@@ -109,31 +179,742 @@ func (s *bodyService) GetCheckIns(ctx context.Context, student_id string, date s
 	var result []*CheckInReponse
 
 	for _, checkIn := range checkIns {
-		
-		student, err := s.UserService.GetStudentInfor(ctx, checkIn.StudentID)
+		response, err := s.toCheckInResponse(ctx, checkIn, includeImages)
 		if err != nil {
 			return nil, err
 		}
+		result = append(result, response)
+	}
+
+	return result, nil
+
+}
+
+func (s *bodyService) GetCheckIn(ctx context.Context, id string, includeImages bool) (*CheckInReponse, error) {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
 
-		teacher, err := s.UserService.GetTeacherInfor(ctx, checkIn.CreatedBy)
+	checkIn, err := s.BodyRepository.GetCheckIn(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toCheckInResponse(ctx, checkIn, includeImages)
+}
+
+func (s *bodyService) toCheckInResponse(ctx context.Context, checkIn *CheckIn, includeImages bool) (*CheckInReponse, error) {
+
+	student, err := s.UserService.GetStudentInfor(ctx, checkIn.StudentID)
+	if err != nil {
+		return nil, err
+	}
+
+	teacher, err := s.UserService.GetTeacherInfor(ctx, checkIn.CreatedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Mark photos are safeguarding evidence and are only exposed to staff.
+	for i := range checkIn.Marks {
+		if !includeImages {
+			checkIn.Marks[i].ImageKeys = nil
+			continue
+		}
+		images, err := s.resolveMarkImages(ctx, checkIn.Marks[i].ImageKeys)
 		if err != nil {
 			return nil, err
 		}
+		checkIn.Marks[i].Images = images
+	}
 
-		result = append(result, &CheckInReponse{
-			ID:        checkIn.ID,
-			Student:   student,
-			Date:      checkIn.Date.Format("2006-01-02"),
-			Context:   checkIn.Context,
-			Type:      checkIn.Type,
-			Gender:    checkIn.Gender,
-			Marks:     checkIn.Marks,
-			Teacher:   teacher,
-			CreatedAt: checkIn.CreatedAt,
-			UpdatedAt: checkIn.UpdatedAt,
-		})
+	// Removed marks stay on record for staff reviewing the check-in.
+	var removedMarks []Mark
+	if includeImages {
+		removedMarks = checkIn.RemovedMarks
+	}
+
+	return &CheckInReponse{
+		ID:        checkIn.ID,
+		Student:   student,
+		Date:      checkIn.Date.Format("2006-01-02"),
+		Context:   checkIn.Context,
+		Type:      checkIn.Type,
+		Gender:    checkIn.Gender,
+		Marks:     checkIn.Marks,
+		RemovedMarks: removedMarks,
+		AuditLogs: checkIn.AuditLogs,
+		Teacher:   teacher,
+		CreatedAt: checkIn.CreatedAt,
+		UpdatedAt: checkIn.UpdatedAt,
+	}, nil
+}
+
+func (s *bodyService) UpdateCheckIn(ctx context.Context, id string, req *UpdateCheckInRequest, userID string) error {
+
+	if userID == "" {
+		return fmt.Errorf("user_id is required")
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	checkIn, err := s.BodyRepository.GetCheckIn(ctx, objectID)
+	if err != nil {
+		return err
+	}
+
+	previous := *checkIn
+
+	changes := make([]FieldChange, 0)
+
+	if req.Date != nil {
+		parseDate, err := time.Parse("2006-01-02", *req.Date)
+		if err != nil {
+			return fmt.Errorf("invalid date format")
+		}
+		if !parseDate.Equal(checkIn.Date) {
+			changes = append(changes, FieldChange{Field: "date", From: checkIn.Date.Format("2006-01-02"), To: *req.Date})
+			checkIn.Date = parseDate
+		}
+	}
+
+	if req.Context != nil && *req.Context != checkIn.Context {
+		changes = append(changes, FieldChange{Field: "context", From: checkIn.Context, To: *req.Context})
+		checkIn.Context = *req.Context
+	}
+
+	if req.Gender != nil && stringValue(req.Gender) != stringValue(checkIn.Gender) {
+		changes = append(changes, FieldChange{Field: "gender", From: checkIn.Gender, To: req.Gender})
+		checkIn.Gender = req.Gender
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	// Check-ins are keyed by date, context and gender; moving onto another one would leave two.
+	exists, err := s.BodyRepository.CheckInExists(ctx, checkIn, objectID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrCheckInExists
+	}
+
+	audit := AuditLog{
+		Action:    "update_check_in",
+		Changes:   changes,
+		ChangedBy: userID,
+		ChangedAt: time.Now(),
+	}
+
+	if err := s.BodyRepository.UpdateCheckIn(ctx, objectID, checkIn, audit); err != nil {
+		return err
+	}
+
+	if previous.Date.Equal(checkIn.Date) && previous.Context == checkIn.Context {
+		return nil
+	}
+
+	return s.BodyRepository.MoveIncidents(ctx, &previous, checkIn)
+}
+
+func (s *bodyService) DeleteCheckIn(ctx context.Context, id string, userID string) error {
+
+	if userID == "" {
+		return fmt.Errorf("user_id is required")
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	checkIn, err := s.BodyRepository.GetCheckIn(ctx, objectID)
+	if err != nil {
+		return err
+	}
+
+	audit := AuditLog{
+		Action:    "delete_check_in",
+		Changes:   []FieldChange{{Field: "is_deleted", From: false, To: true}},
+		ChangedBy: userID,
+		ChangedAt: time.Now(),
+	}
+
+	if err := s.BodyRepository.DeleteCheckIn(ctx, objectID, audit); err != nil {
+		return err
+	}
+
+	// Removed marks may still hold photos from before removal deleted them.
+	for _, mark := range append(checkIn.Marks, checkIn.RemovedMarks...) {
+		if err := s.deleteMarkImages(ctx, mark.ImageKeys); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *bodyService) UpdateMark(ctx context.Context, id string, markName string, req *UpdateMarkRequest, userID string) error {
+
+	if userID == "" {
+		return fmt.Errorf("user_id is required")
+	}
+
+	if markName == "" {
+		return fmt.Errorf("mark name is required")
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	checkIn, err := s.BodyRepository.GetCheckIn(ctx, objectID)
+	if err != nil {
+		return err
+	}
+
+	var mark *Mark
+	for i := range checkIn.Marks {
+		if checkIn.Marks[i].Name == markName {
+			mark = &checkIn.Marks[i]
+			break
+		}
+	}
+
+	if mark == nil {
+		return fmt.Errorf("mark not found")
+	}
+
+	changes := make([]FieldChange, 0)
+
+	if req.Note != nil && stringValue(req.Note) != stringValue(mark.Note) {
+		changes = append(changes, FieldChange{Field: "note", From: mark.Note, To: req.Note})
+		mark.Note = req.Note
+	}
+
+	if req.Color != nil && *req.Color != mark.Color {
+		if *req.Color == "" {
+			return fmt.Errorf("color is required")
+		}
+		changes = append(changes, FieldChange{Field: "color", From: mark.Color, To: *req.Color})
+		mark.Color = *req.Color
+	}
+
+	if req.Severity != nil && *req.Severity != mark.Severity {
+		if *req.Severity < 0 {
+			return fmt.Errorf("severity must not be negative")
+		}
+		changes = append(changes, FieldChange{Field: "severity", From: mark.Severity, To: *req.Severity})
+		mark.Severity = *req.Severity
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+
+	revision := MarkRevision{
+		Note:        mark.Note,
+		Color:       mark.Color,
+		Severity:    mark.Severity,
+		SubmittedBy: userID,
+		SubmittedAt: now,
+		Source:      RevisionSourceStaffEdit,
+	}
+
+	audit := AuditLog{
+		Action:    "update_mark",
+		MarkName:  markName,
+		Changes:   changes,
+		ChangedBy: userID,
+		ChangedAt: now,
+	}
+
+	if err := s.BodyRepository.UpdateMark(ctx, objectID, mark, revision, audit); err != nil {
+		return err
+	}
+
+	// An edit that raises the severity past the threshold should surface like a new submission.
+	checkIn.Marks = []Mark{*mark}
+	checkIn.CreatedBy = userID
+
+	return s.raiseIncidents(ctx, checkIn)
+}
+
+func (s *bodyService) RemoveMark(ctx context.Context, id string, markName string, userID string) error {
+
+	if userID == "" {
+		return fmt.Errorf("user_id is required")
+	}
+
+	if markName == "" {
+		return fmt.Errorf("mark name is required")
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	checkIn, err := s.BodyRepository.GetCheckIn(ctx, objectID)
+	if err != nil {
+		return err
+	}
+
+	var mark *Mark
+	for i := range checkIn.Marks {
+		if checkIn.Marks[i].Name == markName {
+			mark = &checkIn.Marks[i]
+			break
+		}
+	}
+
+	if mark == nil {
+		return fmt.Errorf("mark not found")
+	}
+
+	audit := AuditLog{
+		Action:   "remove_mark",
+		MarkName: markName,
+		Changes: []FieldChange{
+			{Field: "note", From: mark.Note, To: nil},
+			{Field: "color", From: mark.Color, To: nil},
+			{Field: "severity", From: mark.Severity, To: nil},
+		},
+		ChangedBy: userID,
+		ChangedAt: time.Now(),
+	}
+
+	if err := s.BodyRepository.RemoveMark(ctx, objectID, markName, audit); err != nil {
+		return err
+	}
+
+	return s.deleteMarkImages(ctx, mark.ImageKeys)
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func (s *bodyService) deleteMarkImages(ctx context.Context, keys []string) error {
+
+	for _, key := range keys {
+		if err := s.ImageService.DeleteImageKey(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *bodyService) AddMarkImage(ctx context.Context, id string, markName string, req *AddMarkImageRequest) error {
+
+	if markName == "" {
+		return fmt.Errorf("mark name is required")
+	}
+
+	if req.ImageKey == "" {
+		return fmt.Errorf("image_key is required")
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	return s.BodyRepository.AddMarkImage(ctx, objectID, markName, req.ImageKey)
+}
+
+func (s *bodyService) RemoveMarkImage(ctx context.Context, id string, markName string, imageKey string) error {
+
+	if markName == "" {
+		return fmt.Errorf("mark name is required")
+	}
+
+	if imageKey == "" {
+		return fmt.Errorf("key is required")
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.BodyRepository.RemoveMarkImage(ctx, objectID, markName, imageKey); err != nil {
+		return err
+	}
+
+	return s.ImageService.DeleteImageKey(ctx, imageKey)
+}
+
+func (s *bodyService) GetMarkImages(ctx context.Context, id string, markName string) ([]MarkImage, error) {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	checkIn, err := s.BodyRepository.GetCheckIn(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mark := range checkIn.Marks {
+		if mark.Name == markName {
+			return s.resolveMarkImages(ctx, mark.ImageKeys)
+		}
+	}
+
+	return nil, fmt.Errorf("mark not found")
+}
+
+// resolveMarkImages turns stored keys into short-lived private URLs.
+func (s *bodyService) resolveMarkImages(ctx context.Context, keys []string) ([]MarkImage, error) {
+
+	resolved, err := s.ImageService.GetPrivateImageKeys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]MarkImage, 0, len(keys))
+
+	for _, key := range keys {
+		image, ok := resolved[key]
+		if !ok || image == nil {
+			continue
+		}
+		images = append(images, MarkImage{Key: key, Url: image.Url})
+	}
+
+	return images, nil
+}
+
+func (s *bodyService) GetCheckInComparison(ctx context.Context, studentID string, date string, includeImages bool) (*CheckInComparisonResponse, error) {
+
+	if studentID == "" {
+		return nil, fmt.Errorf("student_id is required")
+	}
+
+	if date == "" {
+		return nil, fmt.Errorf("date is required")
+	}
+
+	parseDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date format")
+	}
+
+	checkIns, err := s.BodyRepository.GetCheckIns(ctx, studentID, &parseDate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Compared marks carry the same photos and edit history as the check-ins themselves.
+	if !includeImages {
+		for _, checkIn := range checkIns {
+			for i := range checkIn.Marks {
+				checkIn.Marks[i].ImageKeys = nil
+				checkIn.Marks[i].History = nil
+			}
+		}
+	}
+
+	student, err := s.UserService.GetStudentInfor(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CheckInComparisonResponse{
+		Student: student,
+		Date:    date,
+		Views:   compareCheckIns(checkIns),
+	}, nil
+}
+
+func (s *bodyService) GetMarkTimeline(ctx context.Context, studentID string, checkInType string, from string, to string) (*MarkTimelineResponse, error) {
+
+	if studentID == "" {
+		return nil, fmt.Errorf("student_id is required")
+	}
+
+	var fromDate, toDate *time.Time
+
+	if from != "" {
+		parseDate, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from date format")
+		}
+		fromDate = &parseDate
+	}
+
+	if to != "" {
+		parseDate, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to date format")
+		}
+		toDate = &parseDate
+	}
+
+	checkIns, err := s.BodyRepository.GetCheckInsByRange(ctx, studentID, checkInType, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+
+	student, err := s.UserService.GetStudentInfor(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MarkTimelineResponse{
+		Student: student,
+		From:    from,
+		To:      to,
+		Marks:   buildMarkTimelines(checkIns),
+	}, nil
+}
+
+func (s *bodyService) GetFeelingAnalytics(ctx context.Context, studentID string, from string, to string, timezone string) (*FeelingAnalyticsResponse, error) {
+
+	if studentID == "" {
+		return nil, fmt.Errorf("student_id is required")
+	}
+
+	var fromDate, toDate *time.Time
+
+	if from != "" {
+		parseDate, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from date format")
+		}
+		fromDate = &parseDate
+	}
+
+	if to != "" {
+		parseDate, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to date format")
+		}
+		toDate = &parseDate
+	}
+
+	loc, err := loadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	checkIns, err := s.BodyRepository.GetCheckInsByRange(ctx, studentID, CheckInTypeFeeling, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+
+	student, err := s.UserService.GetStudentInfor(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FeelingAnalyticsResponse{
+		Student:          student,
+		From:             from,
+		To:               to,
+		FeelingAnalytics: buildFeelingAnalytics(feelingReports(checkIns, loc)),
+	}, nil
+}
+
+func (s *bodyService) GetMoodHeatmap(ctx context.Context, studentIDs []string, from string, to string, timezone string) (*MoodHeatmapResponse, error) {
+
+	if len(studentIDs) == 0 {
+		return nil, fmt.Errorf("students is required")
+	}
+
+	if from == "" || to == "" {
+		return nil, fmt.Errorf("from and to are required")
+	}
+
+	fromDate, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from date format")
+	}
+
+	toDate, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to date format")
+	}
+
+	if toDate.Before(fromDate) {
+		return nil, fmt.Errorf("to must not be before from")
+	}
+
+	if toDate.Sub(fromDate) > maxHeatmapDays*24*time.Hour {
+		return nil, fmt.Errorf("period must not exceed %d days", maxHeatmapDays)
+	}
+
+	loc, err := loadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	checkIns, err := s.BodyRepository.GetCheckInsByStudents(ctx, studentIDs, CheckInTypeFeeling, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := buildMoodHeatmap(studentIDs, feelingReports(checkIns, loc), fromDate, toDate)
+
+	for _, row := range rows {
+		student, err := s.UserService.GetStudentInfor(ctx, row.StudentID)
+		if err != nil {
+			return nil, err
+		}
+		row.Student = student
+	}
+
+	return &MoodHeatmapResponse{
+		From:     from,
+		To:       to,
+		Students: rows,
+	}, nil
+}
+
+func loadLocation(timezone string) (*time.Location, error) {
+
+	if timezone == "" {
+		return time.Local, nil
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone")
+	}
+
+	return loc, nil
+}
+
+func (s *bodyService) GetIncidents(ctx context.Context, studentID string, status string) ([]*IncidentResponse, error) {
+
+	if status != "" && status != IncidentStatusClosed {
+		if _, ok := incidentTransitions[status]; !ok {
+			return nil, fmt.Errorf("invalid status")
+		}
+	}
+
+	incidents, err := s.BodyRepository.GetIncidents(ctx, studentID, status)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*IncidentResponse, 0, len(incidents))
+	for _, incident := range incidents {
+		response, err := s.toIncidentResponse(ctx, incident)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, response)
 	}
 
 	return result, nil
+}
+
+func (s *bodyService) GetIncident(ctx context.Context, id string) (*IncidentResponse, error) {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	incident, err := s.BodyRepository.GetIncident(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toIncidentResponse(ctx, incident)
+}
+
+func (s *bodyService) TransitionIncident(ctx context.Context, id string, req *TransitionIncidentRequest, userID string, roles []string) error {
+
+	if userID == "" {
+		return fmt.Errorf("user_id is required")
+	}
+
+	if req.Status == "" {
+		return fmt.Errorf("status is required")
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	incident, err := s.BodyRepository.GetIncident(ctx, objectID)
+	if err != nil {
+		return err
+	}
+
+	next, ok := incidentTransitions[incident.Status]
+	if !ok {
+		return fmt.Errorf("incident is already %s", incident.Status)
+	}
+
+	if req.Status != next {
+		return fmt.Errorf("cannot move incident from %s to %s, expected %s", incident.Status, req.Status, next)
+	}
+
+	if !middleware.ContainsAnyRole(roles, incidentTransitionRoles[next]...) {
+		return fmt.Errorf("your role cannot move incident from %s to %s", incident.Status, next)
+	}
+
+	if next == IncidentStatusParentNotified && strings.TrimSpace(req.Comment) == "" {
+		return fmt.Errorf("comment is required to record how the parents were notified")
+	}
+
+	transition := IncidentTransition{
+		From:      incident.Status,
+		To:        req.Status,
+		Comment:   req.Comment,
+		ChangedBy: userID,
+		ChangedAt: time.Now(),
+	}
+
+	return s.BodyRepository.TransitionIncident(ctx, objectID, transition)
+}
+
+func (s *bodyService) toIncidentResponse(ctx context.Context, incident *Incident) (*IncidentResponse, error) {
+
+	student, err := s.UserService.GetStudentInfor(ctx, incident.StudentID)
+	if err != nil {
+		return nil, err
+	}
+
+	teacher, err := s.UserService.GetTeacherInfor(ctx, incident.CreatedBy)
+	if err != nil {
+		return nil, err
+	}
 
+	return &IncidentResponse{
+		ID:          incident.ID,
+		Student:     student,
+		Date:        incident.Date.Format("2006-01-02"),
+		Context:     incident.Context,
+		Type:        incident.Type,
+		MarkName:    incident.MarkName,
+		Color:       incident.Color,
+		Severity:    incident.Severity,
+		Note:        incident.Note,
+		Status:      incident.Status,
+		Transitions: incident.Transitions,
+		Teacher:     teacher,
+		CreatedAt:   incident.CreatedAt,
+		UpdatedAt:   incident.UpdatedAt,
+	}, nil
 }
@@ -0,0 +1,107 @@
+package body
+
+import "sort"
+
+// buildMarkTimelines groups the marks of a student's check-ins by name. Check-ins must be
+// sorted by date. A mark is resolved when it is recorded with severity 0, or when a later
+// check-in of the same view type no longer contains it; a mark seen again after that
+// starts a new episode.
+func buildMarkTimelines(checkIns []*CheckIn) []*MarkTimeline {
+
+	timelines := map[string]*MarkTimeline{}
+	order := make([]string, 0)
+
+	for _, checkIn := range checkIns {
+
+		date := checkIn.Date.Format("2006-01-02")
+		present := map[string]bool{}
+
+		for _, mark := range checkIn.Marks {
+
+			present[mark.Name] = true
+
+			timeline, ok := timelines[mark.Name]
+			if !ok {
+				timeline = &MarkTimeline{
+					Name:      mark.Name,
+					FirstSeen: date,
+					Entries:   []MarkTimelineEntry{},
+					Episodes:  []MarkEpisode{},
+				}
+				timelines[mark.Name] = timeline
+				order = append(order, mark.Name)
+			}
+
+			timeline.LastSeen = date
+
+			revisions := mark.History
+			if len(revisions) == 0 {
+				revisions = []MarkRevision{{Note: mark.Note, Color: mark.Color, Severity: mark.Severity, SubmittedAt: mark.SubmittedAt}}
+			}
+			for _, revision := range revisions {
+				timeline.Entries = append(timeline.Entries, MarkTimelineEntry{
+					Date:        date,
+					Context:     checkIn.Context,
+					Type:        checkIn.Type,
+					Severity:    revision.Severity,
+					Color:       revision.Color,
+					Note:        revision.Note,
+					SubmittedBy: revision.SubmittedBy,
+					SubmittedAt: revision.SubmittedAt,
+				})
+			}
+
+			episode := timeline.currentEpisode()
+			if mark.Severity == 0 {
+				if episode != nil && episode.ResolvedDate == nil {
+					episode.ResolvedDate = &date
+				}
+				continue
+			}
+
+			if episode == nil || episode.ResolvedDate != nil {
+				timeline.Episodes = append(timeline.Episodes, MarkEpisode{StartDate: date, Type: checkIn.Type})
+				episode = timeline.currentEpisode()
+			}
+			if mark.Severity > episode.MaxSeverity {
+				episode.MaxSeverity = mark.Severity
+			}
+		}
+
+		for _, name := range order {
+			if present[name] {
+				continue
+			}
+			episode := timelines[name].currentEpisode()
+			if episode != nil && episode.ResolvedDate == nil && episode.Type == checkIn.Type && timelines[name].LastSeen != date {
+				resolved := date
+				episode.ResolvedDate = &resolved
+			}
+		}
+	}
+
+	result := make([]*MarkTimeline, 0, len(order))
+	for _, name := range order {
+		timeline := timelines[name]
+		if episode := timeline.currentEpisode(); episode != nil {
+			timeline.ResolvedDate = episode.ResolvedDate
+		}
+		if len(timeline.Episodes) > 1 {
+			timeline.Recurrences = len(timeline.Episodes) - 1
+		}
+		result = append(result, timeline)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].FirstSeen < result[j].FirstSeen
+	})
+
+	return result
+}
+
+func (t *MarkTimeline) currentEpisode() *MarkEpisode {
+	if len(t.Episodes) == 0 {
+		return nil
+	}
+	return &t.Episodes[len(t.Episodes)-1]
+}
@@ -0,0 +1,126 @@
+package ieb
+
+import "strings"
+
+const (
+	DiffAdded     = "added"
+	DiffRemoved   = "removed"
+	DiffChanged   = "changed"
+	DiffUnchanged = "unchanged"
+)
+
+// diffInformation aligns sections by title and their contents by label, and reports what
+// was added, removed or changed going from before to after.
+func diffInformation(before []Information, after []Information) *InformationDiff {
+	return diffInformationBy(before, after, func(title string) string { return title })
+}
+
+// diffInformationBy aligns sections and contents on the given key of their title or label.
+func diffInformationBy(before []Information, after []Information, key func(string) string) *InformationDiff {
+
+	diff := &InformationDiff{Sections: []SectionDiff{}}
+
+	beforeByTitle := map[string]Information{}
+	for _, section := range before {
+		beforeByTitle[key(section.Tilte)] = section
+	}
+
+	afterTitles := map[string]bool{}
+
+	for _, section := range after {
+
+		afterTitles[key(section.Tilte)] = true
+
+		previous, ok := beforeByTitle[key(section.Tilte)]
+		if !ok {
+			diff.Sections = append(diff.Sections, SectionDiff{
+				Title:    section.Tilte,
+				Status:   DiffAdded,
+				Contents: diffContents(nil, section.Contents, key),
+			})
+			diff.Added++
+			continue
+		}
+
+		contents := diffContents(previous.Contents, section.Contents, key)
+		status := DiffUnchanged
+		for _, content := range contents {
+			if content.Status != DiffUnchanged {
+				status = DiffChanged
+				break
+			}
+		}
+
+		diff.Sections = append(diff.Sections, SectionDiff{
+			Title:    section.Tilte,
+			Status:   status,
+			Contents: contents,
+		})
+		if status == DiffChanged {
+			diff.Changed++
+		} else {
+			diff.Unchanged++
+		}
+	}
+
+	for _, section := range before {
+		if afterTitles[key(section.Tilte)] {
+			continue
+		}
+		diff.Sections = append(diff.Sections, SectionDiff{
+			Title:    section.Tilte,
+			Status:   DiffRemoved,
+			Contents: diffContents(section.Contents, nil, key),
+		})
+		diff.Removed++
+	}
+
+	return diff
+}
+
+func diffContents(before []Content, after []Content, key func(string) string) []ContentDiff {
+
+	result := make([]ContentDiff, 0, len(after))
+
+	beforeByLabel := map[string]Content{}
+	for _, content := range before {
+		beforeByLabel[key(content.Label)] = content
+	}
+
+	afterLabels := map[string]bool{}
+
+	for _, content := range after {
+
+		afterLabels[key(content.Label)] = true
+		text := content.Content
+
+		previous, ok := beforeByLabel[key(content.Label)]
+		if !ok {
+			result = append(result, ContentDiff{Label: content.Label, Status: DiffAdded, After: &text})
+			continue
+		}
+
+		previousText := previous.Content
+		status := DiffUnchanged
+		if previousText != text {
+			status = DiffChanged
+		}
+		result = append(result, ContentDiff{Label: content.Label, Status: status, Before: &previousText, After: &text})
+	}
+
+	for _, content := range before {
+		if afterLabels[key(content.Label)] {
+			continue
+		}
+		text := content.Content
+		result = append(result, ContentDiff{Label: content.Label, Status: DiffRemoved, Before: &text})
+	}
+
+	return result
+}
+
+// looseKey matches titles and labels retyped from one term to the next, ignoring case and
+// extra spaces.
+func looseKey(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
@@ -0,0 +1,168 @@
+package ieb
+
+import "testing"
+
+func section(title string, contents ...Content) Information {
+	return Information{Tilte: title, Contents: contents}
+}
+
+func content(label string, text string) Content {
+	return Content{Label: label, Content: text}
+}
+
+func TestDiffInformation(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		before    []Information
+		after     []Information
+		key       func(string) string
+		added     int
+		removed   int
+		changed   int
+		unchanged int
+		statuses  map[string]string
+	}{
+		{
+			name:      "identical",
+			before:    []Information{section("Goals", content("Reading", "Read aloud"))},
+			after:     []Information{section("Goals", content("Reading", "Read aloud"))},
+			unchanged: 1,
+			statuses:  map[string]string{"Goals": DiffUnchanged},
+		},
+		{
+			name:     "section added",
+			before:   []Information{},
+			after:    []Information{section("Goals", content("Reading", "Read aloud"))},
+			added:    1,
+			statuses: map[string]string{"Goals": DiffAdded},
+		},
+		{
+			name:     "section removed",
+			before:   []Information{section("Goals", content("Reading", "Read aloud"))},
+			after:    []Information{},
+			removed:  1,
+			statuses: map[string]string{"Goals": DiffRemoved},
+		},
+		{
+			name:     "content text changed",
+			before:   []Information{section("Goals", content("Reading", "Read aloud"))},
+			after:    []Information{section("Goals", content("Reading", "Read silently"))},
+			changed:  1,
+			statuses: map[string]string{"Goals": DiffChanged},
+		},
+		{
+			name:     "content added to existing section",
+			before:   []Information{section("Goals", content("Reading", "Read aloud"))},
+			after:    []Information{section("Goals", content("Reading", "Read aloud"), content("Writing", "Copy a sentence"))},
+			changed:  1,
+			statuses: map[string]string{"Goals": DiffChanged},
+		},
+		{
+			name:      "mixed",
+			before:    []Information{section("Goals"), section("Support", content("Aide", "Mornings"))},
+			after:     []Information{section("Goals"), section("Strengths", content("Art", "Drawing"))},
+			added:     1,
+			removed:   1,
+			unchanged: 1,
+			statuses:  map[string]string{"Goals": DiffUnchanged, "Strengths": DiffAdded, "Support": DiffRemoved},
+		},
+		{
+			name:     "retyped title is a different section by default",
+			before:   []Information{section("Goals")},
+			after:    []Information{section(" goals ")},
+			added:    1,
+			removed:  1,
+			statuses: map[string]string{" goals ": DiffAdded, "Goals": DiffRemoved},
+		},
+		{
+			name:      "retyped title matches with the loose key",
+			before:    []Information{section("Learning  Goals", content("Reading", "Read aloud"))},
+			after:     []Information{section("learning goals", content(" reading", "Read aloud"))},
+			key:       looseKey,
+			unchanged: 1,
+			statuses:  map[string]string{"learning goals": DiffUnchanged},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var diff *InformationDiff
+			if tt.key != nil {
+				diff = diffInformationBy(tt.before, tt.after, tt.key)
+			} else {
+				diff = diffInformation(tt.before, tt.after)
+			}
+
+			if diff.Added != tt.added || diff.Removed != tt.removed || diff.Changed != tt.changed || diff.Unchanged != tt.unchanged {
+				t.Fatalf("counts = added %d removed %d changed %d unchanged %d, want %d %d %d %d",
+					diff.Added, diff.Removed, diff.Changed, diff.Unchanged, tt.added, tt.removed, tt.changed, tt.unchanged)
+			}
+
+			if len(diff.Sections) != len(tt.statuses) {
+				t.Fatalf("got %d sections, want %d", len(diff.Sections), len(tt.statuses))
+			}
+
+			for _, s := range diff.Sections {
+				if want := tt.statuses[s.Title]; s.Status != want {
+					t.Errorf("section %q status = %q, want %q", s.Title, s.Status, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDiffContents(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		before []Content
+		after  []Content
+		want   map[string]string
+	}{
+		{
+			name:   "added and removed",
+			before: []Content{content("Reading", "Read aloud")},
+			after:  []Content{content("Writing", "Copy a sentence")},
+			want:   map[string]string{"Reading": DiffRemoved, "Writing": DiffAdded},
+		},
+		{
+			name:   "changed and unchanged",
+			before: []Content{content("Reading", "Read aloud"), content("Maths", "Count to 10")},
+			after:  []Content{content("Reading", "Read aloud"), content("Maths", "Count to 20")},
+			want:   map[string]string{"Reading": DiffUnchanged, "Maths": DiffChanged},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			diffs := diffContents(tt.before, tt.after, func(label string) string { return label })
+
+			if len(diffs) != len(tt.want) {
+				t.Fatalf("got %d contents, want %d", len(diffs), len(tt.want))
+			}
+
+			for _, d := range diffs {
+				if d.Status != tt.want[d.Label] {
+					t.Errorf("content %q status = %q, want %q", d.Label, d.Status, tt.want[d.Label])
+				}
+				switch d.Status {
+				case DiffAdded:
+					if d.Before != nil || d.After == nil {
+						t.Errorf("added content %q should only have after text", d.Label)
+					}
+				case DiffRemoved:
+					if d.Before == nil || d.After != nil {
+						t.Errorf("removed content %q should only have before text", d.Label)
+					}
+				default:
+					if d.Before == nil || d.After == nil {
+						t.Errorf("content %q should have before and after text", d.Label)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package ieb
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+)
+
+const (
+	emuPerPoint = 12700
+
+	docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Default Extension="png" ContentType="image/png"/>` +
+		`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+		`<Override PartName="/word/footer1.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.footer+xml"/>` +
+		`</Types>`
+
+	docxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>` +
+		`</Relationships>`
+
+	docxNamespaces = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" ` +
+		`xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing" ` +
+		`xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" ` +
+		`xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture"`
+)
+
+type docxRun struct {
+	text  string
+	bold  bool
+	size  int // half-points
+	color string
+	field string // complex field instruction, e.g. PAGE
+	image *docxImage
+}
+
+type docxImage struct {
+	relID  string
+	id     int
+	width  float64 // points
+	height float64
+}
+
+type docxWriter struct {
+	color    string
+	body     strings.Builder
+	rels     []string
+	media    map[string][]byte
+	language string
+}
+
+func (w *docxWriter) addImage(img image.Image, name string, height float64) (*docxImage, error) {
+
+	if img == nil {
+		return nil, nil
+	}
+
+	var data bytes.Buffer
+	if err := png.Encode(&data, img); err != nil {
+		return nil, err
+	}
+
+	id := len(w.media) + 1
+	relID := fmt.Sprintf("rIdImage%d", id)
+	w.media["media/"+name+".png"] = data.Bytes()
+	w.rels = append(w.rels, fmt.Sprintf(`<Relationship Id="%s" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="media/%s.png"/>`, relID, name))
+
+	bounds := img.Bounds()
+	return &docxImage{
+		relID:  relID,
+		id:     id,
+		width:  height * float64(bounds.Dx()) / float64(bounds.Dy()),
+		height: height,
+	}, nil
+}
+
+func (w *docxWriter) runXML(run docxRun) string {
+
+	var b strings.Builder
+
+	props := fmt.Sprintf(`<w:rFonts w:ascii="Arial" w:hAnsi="Arial" w:cs="Arial"/><w:lang w:val="%s"/>`, xmlEscape(w.language))
+	if run.bold {
+		props += `<w:b/>`
+	}
+	if run.color != "" {
+		props += fmt.Sprintf(`<w:color w:val="%s"/>`, run.color)
+	}
+	if run.size > 0 {
+		props += fmt.Sprintf(`<w:sz w:val="%d"/>`, run.size)
+	}
+
+	switch {
+	case run.image != nil:
+		img := run.image
+		cx, cy := int(img.width*emuPerPoint), int(img.height*emuPerPoint)
+		fmt.Fprintf(&b, `<w:r><w:drawing><wp:inline distT="0" distB="0" distL="0" distR="114300"><wp:extent cx="%d" cy="%d"/>`+
+			`<wp:docPr id="%d" name="Picture %d"/><a:graphic><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture">`+
+			`<pic:pic><pic:nvPicPr><pic:cNvPr id="%d" name="Picture %d"/><pic:cNvPicPr/></pic:nvPicPr>`+
+			`<pic:blipFill><a:blip r:embed="%s"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill>`+
+			`<pic:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></pic:spPr>`+
+			`</pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing></w:r>`,
+			cx, cy, img.id, img.id, img.id, img.id, img.relID, cx, cy)
+
+	case run.field != "":
+		fmt.Fprintf(&b, `<w:r><w:rPr>%s</w:rPr><w:fldChar w:fldCharType="begin"/></w:r>`, props)
+		fmt.Fprintf(&b, `<w:r><w:rPr>%s</w:rPr><w:instrText xml:space="preserve"> %s </w:instrText></w:r>`, props, run.field)
+		fmt.Fprintf(&b, `<w:r><w:rPr>%s</w:rPr><w:fldChar w:fldCharType="end"/></w:r>`, props)
+
+	default:
+		fmt.Fprintf(&b, `<w:r><w:rPr>%s</w:rPr>`, props)
+		for i, line := range strings.Split(strings.ReplaceAll(run.text, "\r\n", "\n"), "\n") {
+			if i > 0 {
+				b.WriteString(`<w:br/>`)
+			}
+			for j, part := range strings.Split(line, "\t") {
+				if j > 0 {
+					b.WriteString(`<w:tab/>`)
+				}
+				fmt.Fprintf(&b, `<w:t xml:space="preserve">%s</w:t>`, xmlEscape(part))
+			}
+		}
+		b.WriteString(`</w:r>`)
+	}
+
+	return b.String()
+}
+
+func (w *docxWriter) paragraph(props string, runs ...docxRun) string {
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<w:p><w:pPr>%s</w:pPr>`, props)
+	for _, run := range runs {
+		b.WriteString(w.runXML(run))
+	}
+	b.WriteString(`</w:p>`)
+
+	return b.String()
+}
+
+func (w *docxWriter) write(props string, runs ...docxRun) {
+	w.body.WriteString(w.paragraph(props, runs...))
+}
+
+func renderDOCX(doc *exportDocument) ([]byte, error) {
+
+	w := &docxWriter{
+		color:    fmt.Sprintf("%02X%02X%02X", doc.Color[0], doc.Color[1], doc.Color[2]),
+		media:    map[string][]byte{},
+		language: doc.Language,
+	}
+	if w.language == "" {
+		w.language = "en"
+	}
+
+	logo, err := w.addImage(doc.Logo, "logo", 36)
+	if err != nil {
+		return nil, err
+	}
+
+	avatar, err := w.addImage(doc.Avatar, "avatar", 80)
+	if err != nil {
+		return nil, err
+	}
+
+	// Branding band.
+	band := []docxRun{}
+	if logo != nil {
+		band = append(band, docxRun{image: logo})
+	}
+	band = append(band, docxRun{text: doc.SchoolName, bold: true, size: 36, color: "FFFFFF"})
+	w.write(fmt.Sprintf(`<w:shd w:val="clear" w:color="auto" w:fill="%s"/><w:spacing w:after="240"/>`, w.color), band...)
+
+	w.write(`<w:spacing w:after="120"/>`, docxRun{text: doc.Title, bold: true, size: 40, color: w.color})
+
+	if avatar != nil {
+		w.write(`<w:spacing w:after="60"/>`, docxRun{image: avatar})
+	}
+	w.write(`<w:spacing w:after="60"/>`, docxRun{text: doc.StudentName, bold: true, size: 30})
+
+	for _, detail := range doc.Details {
+		w.write(`<w:spacing w:after="0"/>`,
+			docxRun{text: detail.Label + ": ", bold: true, size: 21, color: "666666"},
+			docxRun{text: detail.Value, size: 21})
+	}
+
+	for _, section := range doc.Sections {
+
+		w.write(fmt.Sprintf(`<w:keepNext/><w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="%s"/></w:pBdr><w:spacing w:before="360" w:after="120"/>`, w.color),
+			docxRun{text: section.Title, bold: true, size: 26, color: w.color})
+
+		for _, item := range section.Items {
+			if item.Label != "" {
+				w.write(`<w:keepNext/><w:spacing w:before="120" w:after="0"/>`, docxRun{text: item.Label, bold: true, size: 21})
+			}
+			w.write(`<w:spacing w:after="60"/>`, docxRun{text: item.Value, size: 21})
+		}
+	}
+
+	w.rels = append(w.rels, `<Relationship Id="rIdFooter" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/footer" Target="footer1.xml"/>`)
+
+	document := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<w:document ` + docxNamespaces + `><w:body>` + w.body.String() +
+		`<w:sectPr><w:footerReference w:type="default" r:id="rIdFooter"/><w:pgSz w:w="11906" w:h="16838"/>` +
+		`<w:pgMar w:top="1000" w:right="1000" w:bottom="1000" w:left="1000" w:header="500" w:footer="500" w:gutter="0"/></w:sectPr>` +
+		`</w:body></w:document>`
+
+	footer := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<w:ftr ` + docxNamespaces + `>` +
+		w.paragraph(`<w:tabs><w:tab w:val="right" w:pos="9900"/></w:tabs>`,
+			docxRun{text: doc.SchoolName, size: 18, color: "666666"},
+			docxRun{text: "\t" + doc.PageLabel + " ", size: 18, color: "666666"},
+			docxRun{field: "PAGE", size: 18, color: "666666"},
+			docxRun{text: " / ", size: 18, color: "666666"},
+			docxRun{field: "NUMPAGES", size: 18, color: "666666"}) +
+		`</w:ftr>`
+
+	rels := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		strings.Join(w.rels, "") + `</Relationships>`
+
+	var out bytes.Buffer
+	archive := zip.NewWriter(&out)
+
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"[Content_Types].xml", []byte(docxContentTypes)},
+		{"_rels/.rels", []byte(docxRootRels)},
+		{"word/document.xml", []byte(document)},
+		{"word/footer1.xml", []byte(footer)},
+		{"word/_rels/document.xml.rels", []byte(rels)},
+	}
+	for name, data := range w.media {
+		files = append(files, struct {
+			name string
+			data []byte
+		}{"word/" + name, data})
+	}
+
+	for _, file := range files {
+		writer, err := archive.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := writer.Write(file.data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+func xmlEscape(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
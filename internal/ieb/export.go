@@ -0,0 +1,304 @@
+package ieb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"portal/internal/term"
+	"portal/internal/user"
+)
+
+const (
+	ExportFormatPDF  = "pdf"
+	ExportFormatDOCX = "docx"
+
+	// maxExportImageSide caps avatar and logo resolution; they are printed a few
+	// centimetres wide, so anything larger only bloats the file.
+	maxExportImageSide = 300
+
+	maxExportImageBytes = 5 << 20
+
+	// maxExportImagePixels guards against small files that decode to huge bitmaps.
+	maxExportImagePixels = 4000 * 4000
+)
+
+// Branding is the school identity printed on exported documents, taken from the
+// organization the book belongs to.
+type Branding struct {
+	SchoolName string
+	Color      string // hex, e.g. #1F4E79
+	LogoURL    string
+}
+
+type ExportFile struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
+// exportDocument is the renderer-neutral layout of an exported IEB.
+type exportDocument struct {
+	Language    string
+	SchoolName  string
+	Color       [3]uint8
+	Logo        image.Image
+	Title       string
+	StudentName string
+	Avatar      image.Image
+	Details     []exportDetail
+	Sections    []exportSection
+	PageLabel   string
+}
+
+type exportDetail struct {
+	Label string
+	Value string
+}
+
+type exportSection struct {
+	Title string
+	Items []exportDetail
+}
+
+type exportLabels struct {
+	Title    string
+	Term     string
+	Version  string
+	Status   string
+	Updated  string
+	Page     string
+	Empty    string
+	Date     string // time layout for dates
+	Statuses map[string]string
+}
+
+var exportLabelsByLanguage = map[string]exportLabels{
+	"en": {
+		Title:   "Individual Education Book",
+		Term:    "Term",
+		Version: "Version",
+		Status:  "Status",
+		Updated: "Last updated",
+		Page:    "Page",
+		Empty:   "No content yet.",
+		Date:    "02 Jan 2006",
+		Statuses: map[string]string{
+			StatusDraft:              "Draft",
+			StatusInReview:           "In review",
+			StatusApproved:           "Approved",
+			StatusSharedWithParent:   "Shared with family",
+			StatusParentAcknowledged: "Acknowledged by family",
+		},
+	},
+	"vi": {
+		Title:   "Sổ Giáo Dục Cá Nhân",
+		Term:    "Học kỳ",
+		Version: "Phiên bản",
+		Status:  "Trạng thái",
+		Updated: "Cập nhật lần cuối",
+		Page:    "Trang",
+		Empty:   "Chưa có nội dung.",
+		Date:    "02/01/2006",
+		Statuses: map[string]string{
+			StatusDraft:              "Bản nháp",
+			StatusInReview:           "Đang duyệt",
+			StatusApproved:           "Đã duyệt",
+			StatusSharedWithParent:   "Đã chia sẻ với gia đình",
+			StatusParentAcknowledged: "Gia đình đã xác nhận",
+		},
+	},
+}
+
+// labelsFor picks the labels of the language part of keys like "vi" or "en-US", falling
+// back to English.
+func labelsFor(languageKey string) exportLabels {
+	language := strings.ToLower(languageKey)
+	if i := strings.IndexAny(language, "-_"); i >= 0 {
+		language = language[:i]
+	}
+	if labels, ok := exportLabelsByLanguage[language]; ok {
+		return labels
+	}
+	return exportLabelsByLanguage["en"]
+}
+
+func buildExportDocument(ieb *IEB, owner *user.UserInfor, termInfo *term.TermInfor, branding Branding, images exportImages) *exportDocument {
+
+	labels := labelsFor(ieb.LanguageKey)
+
+	doc := &exportDocument{
+		Language:    ieb.LanguageKey,
+		SchoolName:  branding.SchoolName,
+		Color:       parseHexColor(branding.Color),
+		Logo:        images.Logo,
+		Title:       labels.Title,
+		StudentName: owner.UserName,
+		Avatar:      images.Avatar,
+		PageLabel:   labels.Page,
+		Sections:    make([]exportSection, 0, len(ieb.Information)),
+	}
+
+	status := labels.Statuses[statusOf(ieb)]
+	if status == "" {
+		status = statusOf(ieb)
+	}
+
+	doc.Details = []exportDetail{
+		{Label: labels.Term, Value: formatTermDates(termInfo, labels.Date)},
+		{Label: labels.Version, Value: fmt.Sprintf("%d", ieb.Version)},
+		{Label: labels.Status, Value: status},
+		{Label: labels.Updated, Value: ieb.UpdatedAt.Format(labels.Date)},
+	}
+
+	for _, information := range ieb.Information {
+		section := exportSection{Title: information.Tilte, Items: make([]exportDetail, 0, len(information.Contents))}
+		for _, content := range information.Contents {
+			section.Items = append(section.Items, exportDetail{Label: content.Label, Value: content.Content})
+		}
+		if len(section.Items) == 0 {
+			section.Items = append(section.Items, exportDetail{Value: labels.Empty})
+		}
+		doc.Sections = append(doc.Sections, section)
+	}
+
+	return doc
+}
+
+func formatTermDates(termInfo *term.TermInfor, layout string) string {
+
+	start, startErr := time.Parse("2006-01-02", termInfo.StartDate)
+	end, endErr := time.Parse("2006-01-02", termInfo.EndDate)
+	if startErr != nil || endErr != nil {
+		return termInfo.StartDate + " - " + termInfo.EndDate
+	}
+
+	return start.Format(layout) + " - " + end.Format(layout)
+}
+
+func exportFileName(ieb *IEB, name string, format string) string {
+
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '_':
+			b.WriteRune('-')
+		}
+	}
+
+	base := strings.Trim(b.String(), "-")
+	if base == "" {
+		base = ieb.ID.Hex()
+	}
+
+	return fmt.Sprintf("ieb-%s-%s.%s", base, ieb.LanguageKey, format)
+}
+
+func parseHexColor(hex string) [3]uint8 {
+
+	color := [3]uint8{0x1F, 0x4E, 0x79}
+
+	hex = strings.TrimPrefix(strings.TrimSpace(hex), "#")
+	if len(hex) != 6 {
+		return color
+	}
+
+	var r, g, b uint8
+	if _, err := fmt.Sscanf(hex, "%02x%02x%02x", &r, &g, &b); err != nil {
+		return color
+	}
+
+	return [3]uint8{r, g, b}
+}
+
+type exportImages struct {
+	Avatar image.Image
+	Logo   image.Image
+}
+
+var exportHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// fetchImage downloads and decodes an image for the export. A missing or broken image
+// only leaves a gap in the document, so errors are swallowed.
+func fetchImage(ctx context.Context, url string) image.Image {
+
+	if url == "" {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil
+	}
+
+	resp, err := exportHTTPClient.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxExportImageBytes))
+	if err != nil {
+		return nil
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxExportImagePixels {
+		return nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+
+	return downscale(img, maxExportImageSide)
+}
+
+// downscale shrinks the image by nearest-neighbour sampling so its longest side is at
+// most max pixels.
+func downscale(img image.Image, max int) image.Image {
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= max && height <= max {
+		return img
+	}
+
+	scale := float64(max) / float64(width)
+	if height > width {
+		scale = float64(max) / float64(height)
+	}
+
+	newWidth := int(float64(width) * scale)
+	newHeight := int(float64(height) * scale)
+	if newWidth < 1 {
+		newWidth = 1
+	}
+	if newHeight < 1 {
+		newHeight = 1
+	}
+
+	scaled := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
+	for y := 0; y < newHeight; y++ {
+		for x := 0; x < newWidth; x++ {
+			scaled.Set(x, y, img.At(bounds.Min.X+int(float64(x)/scale), bounds.Min.Y+int(float64(y)/scale)))
+		}
+	}
+
+	return scaled
+}
@@ -2,8 +2,10 @@ package ieb
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"portal/helper"
+	"portal/internal/middleware"
 	"portal/pkg/constants"
 
 	"github.com/gin-gonic/gin"
@@ -44,7 +46,7 @@ func (handler *IEBHandler) CreateIEB(c *gin.Context) {
 
 	iebID, err := handler.IEBService.CreateIEB(ctx, &req, userID.(string))
 	if err != nil {
-		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		sendIEBError(c, err)
 		return
 	}
 
@@ -66,12 +68,561 @@ func (handler *IEBHandler) GetIEB(c *gin.Context) {
 
 	ctx := context.WithValue(c, constants.TokenKey, token)
 
-	ieb, err := handler.IEBService.GetIEB(ctx, userID, termID, languageKey, regionKey)
+	// Parents only ever see the version that was shared with them.
+	// Anyone without a staff role only gets to see what was shared with the family.
+	sharedOnly := !middleware.HasAnyRole(c, constants.StaffRoles...)
+
+	ieb, err := handler.IEBService.GetIEB(ctx, userID, termID, languageKey, regionKey, sharedOnly)
 	if err != nil {
-		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		sendIEBError(c, err)
 		return
 	}
 
 	helper.SendSuccess(c, 200, "Get ieb successfully", ieb)
 
 }
+
+func (handler *IEBHandler) GetRevisions(c *gin.Context) {
+
+	id := c.Param("id")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	revisions, err := handler.IEBService.GetRevisions(ctx, id)
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Get ieb revisions successfully", revisions)
+
+}
+
+func (handler *IEBHandler) GetRevision(c *gin.Context) {
+
+	id := c.Param("id")
+	version := c.Param("version")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	revision, err := handler.IEBService.GetRevision(ctx, id, version)
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Get ieb revision successfully", revision)
+
+}
+
+func (handler *IEBHandler) DiffRevisions(c *gin.Context) {
+
+	id := c.Param("id")
+	from := c.Query("from")
+	to := c.Query("to")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	diff, err := handler.IEBService.DiffRevisions(ctx, id, from, to)
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Diff ieb revisions successfully", diff)
+
+}
+
+func (handler *IEBHandler) RestoreRevision(c *gin.Context) {
+
+	id := c.Param("id")
+	version := c.Param("version")
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	ieb, err := handler.IEBService.RestoreRevision(ctx, id, version, userID.(string))
+	if err != nil {
+		sendIEBError(c, err)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Restore ieb revision successfully", ieb)
+
+}
+
+func (handler *IEBHandler) AddSection(c *gin.Context) {
+
+	id := c.Param("id")
+
+	var req AddSectionRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	ieb, err := handler.IEBService.AddSection(ctx, id, &req, userID.(string))
+	if err != nil {
+		sendIEBError(c, err)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Add ieb section successfully", ieb)
+
+}
+
+func (handler *IEBHandler) UpdateSection(c *gin.Context) {
+
+	id := c.Param("id")
+	sectionID := c.Param("section_id")
+
+	var req UpdateSectionRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	ieb, err := handler.IEBService.UpdateSection(ctx, id, sectionID, &req, userID.(string))
+	if err != nil {
+		sendIEBError(c, err)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Update ieb section successfully", ieb)
+
+}
+
+func (handler *IEBHandler) RemoveSection(c *gin.Context) {
+
+	id := c.Param("id")
+	sectionID := c.Param("section_id")
+	version := c.Query("version")
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	ieb, err := handler.IEBService.RemoveSection(ctx, id, sectionID, version, userID.(string))
+	if err != nil {
+		sendIEBError(c, err)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Remove ieb section successfully", ieb)
+
+}
+
+func (handler *IEBHandler) ReorderSections(c *gin.Context) {
+
+	id := c.Param("id")
+
+	var req ReorderSectionsRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	ieb, err := handler.IEBService.ReorderSections(ctx, id, &req, userID.(string))
+	if err != nil {
+		sendIEBError(c, err)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Reorder ieb sections successfully", ieb)
+
+}
+
+func (handler *IEBHandler) AddContent(c *gin.Context) {
+
+	id := c.Param("id")
+	sectionID := c.Param("section_id")
+
+	var req AddContentRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	ieb, err := handler.IEBService.AddContent(ctx, id, sectionID, &req, userID.(string))
+	if err != nil {
+		sendIEBError(c, err)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Add ieb content successfully", ieb)
+
+}
+
+func (handler *IEBHandler) UpdateContent(c *gin.Context) {
+
+	id := c.Param("id")
+	sectionID := c.Param("section_id")
+	contentID := c.Param("content_id")
+
+	var req UpdateContentRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	ieb, err := handler.IEBService.UpdateContent(ctx, id, sectionID, contentID, &req, userID.(string))
+	if err != nil {
+		sendIEBError(c, err)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Update ieb content successfully", ieb)
+
+}
+
+func (handler *IEBHandler) RemoveContent(c *gin.Context) {
+
+	id := c.Param("id")
+	sectionID := c.Param("section_id")
+	contentID := c.Param("content_id")
+	version := c.Query("version")
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	ieb, err := handler.IEBService.RemoveContent(ctx, id, sectionID, contentID, version, userID.(string))
+	if err != nil {
+		sendIEBError(c, err)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Remove ieb content successfully", ieb)
+
+}
+
+func (handler *IEBHandler) ReorderContents(c *gin.Context) {
+
+	id := c.Param("id")
+	sectionID := c.Param("section_id")
+
+	var req ReorderContentsRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	ieb, err := handler.IEBService.ReorderContents(ctx, id, sectionID, &req, userID.(string))
+	if err != nil {
+		sendIEBError(c, err)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Reorder ieb contents successfully", ieb)
+
+}
+
+func (handler *IEBHandler) GetIEBs(c *gin.Context) {
+
+	req := GetIEBsRequest{
+		TermID:      c.Query("term_id"),
+		OwnerID:     c.Query("owner_id"),
+		OwnerRole:   c.Query("owner_role"),
+		LanguageKey: c.Query("language_key"),
+		RegionKey:   c.Query("region_key"),
+		Status:      c.Query("status"),
+		UpdatedFrom: c.Query("updated_from"),
+		UpdatedTo:   c.Query("updated_to"),
+		Search:      c.Query("search"),
+		Page:        c.Query("page"),
+		Size:        c.Query("size"),
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	iebs, err := handler.IEBService.GetIEBs(ctx, &req)
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Get iebs successfully", iebs)
+
+}
+
+func (handler *IEBHandler) ExportIEB(c *gin.Context) {
+
+	req := ExportIEBRequest{
+		OwnerID:     c.Query("user_id"),
+		TermID:      c.Query("term_id"),
+		LanguageKey: c.Query("language_key"),
+		RegionKey:   c.Query("region_key"),
+		Format:      c.Query("format"),
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	sharedOnly := !middleware.HasAnyRole(c, constants.StaffRoles...)
+
+	file, err := handler.IEBService.ExportIEB(ctx, &req, sharedOnly)
+	if err != nil {
+		sendIEBError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.FileName))
+	c.Data(200, file.ContentType, file.Data)
+
+}
+
+func (handler *IEBHandler) CompareTerms(c *gin.Context) {
+
+	userID := c.Query("user_id")
+	fromTermID := c.Query("from_term_id")
+	toTermID := c.Query("to_term_id")
+	languageKey := c.Query("language_key")
+	regionKey := c.Query("region_key")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	sharedOnly := !middleware.HasAnyRole(c, constants.StaffRoles...)
+
+	comparison, err := handler.IEBService.CompareTerms(ctx, userID, fromTermID, toTermID, languageKey, regionKey, sharedOnly)
+	if err != nil {
+		sendIEBError(c, err)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Compare ieb terms successfully", comparison)
+
+}
+
+func (handler *IEBHandler) GetTranslations(c *gin.Context) {
+
+	userID := c.Query("user_id")
+	termID := c.Query("term_id")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	sharedOnly := !middleware.HasAnyRole(c, constants.StaffRoles...)
+
+	translations, err := handler.IEBService.GetTranslations(ctx, userID, termID, sharedOnly)
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Get ieb translations successfully", translations)
+
+}
+
+func (handler *IEBHandler) TransitionIEB(c *gin.Context) {
+
+	id := c.Param("id")
+
+	var req TransitionIEBRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	ieb, err := handler.IEBService.TransitionIEB(ctx, id, &req, userID.(string), middleware.GetRoles(c))
+	if err != nil {
+		sendIEBError(c, err)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Update ieb status successfully", ieb)
+
+}
+
+// sendIEBError answers a stale version with 409 so clients know to reload and retry, and
+// a missing IEB with 404 rather than a generic failure.
+func sendIEBError(c *gin.Context, err error) {
+
+	if errors.Is(err, ErrIEBNotFound) || errors.Is(err, ErrRevisionNotFound) {
+		helper.SendError(c, 404, err, helper.ErrNotFound)
+		return
+	}
+
+	if errors.Is(err, ErrVersionConflict) {
+		helper.SendError(c, 409, err, helper.ErrVersionConflict)
+		return
+	}
+
+	helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+
+}
@@ -13,9 +13,79 @@ type IEB struct {
 	LanguageKey string             `json:"language_key" bson:"language_key"`
 	RegionKey   string             `json:"region_key" bson:"region_key"`
 	Information []Information      `json:"information" bson:"information"`
+	Version     int                `json:"version" bson:"version"`
 	CreatedBy   string             `json:"created_by" bson:"created_by"`
+	UpdatedBy   string             `json:"updated_by" bson:"updated_by"`
 	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+
+	Status        string             `json:"status" bson:"status"`
+	StatusHistory []StatusTransition `json:"status_history" bson:"status_history"`
+	SharedVersion *int               `json:"shared_version,omitempty" bson:"shared_version,omitempty"` // version parents see
+	SharedAt      *time.Time         `json:"shared_at,omitempty" bson:"shared_at,omitempty"`
+
+	// AcknowledgedVersion is the shared version the parent confirmed reading.
+	AcknowledgedVersion *int              `json:"acknowledged_version,omitempty" bson:"acknowledged_version,omitempty"`
+	AcknowledgedAt      *time.Time        `json:"acknowledged_at,omitempty" bson:"acknowledged_at,omitempty"`
+	Acknowledgements    []Acknowledgement `json:"acknowledgements,omitempty" bson:"acknowledgements,omitempty"`
+
+	// OrganizationID is the school the book belongs to, whose branding is printed on exports.
+	OrganizationID string `json:"organization_id,omitempty" bson:"organization_id,omitempty"`
+
+	// SourceID links a translation to the source-language IEB of the same owner and term.
+	SourceID *primitive.ObjectID `json:"source_id,omitempty" bson:"source_id,omitempty"`
+
+	// Score is the text search relevance, only set on search results.
+	Score float64 `json:"-" bson:"score,omitempty"`
+}
+
+// IEBFilter narrows the IEB list. Zero values are ignored.
+type IEBFilter struct {
+	TermID      string
+	OwnerID     string
+	OwnerRole   string
+	LanguageKey string
+	RegionKey   string
+	Status      string
+	UpdatedFrom *time.Time
+	UpdatedTo   *time.Time // exclusive
+	Search      string
+}
+
+type StatusTransition struct {
+	From      string    `json:"from" bson:"from"`
+	To        string    `json:"to" bson:"to"`
+	Comment   string    `json:"comment" bson:"comment"`
+	ChangedBy string    `json:"changed_by" bson:"changed_by"`
+	ChangedAt time.Time `json:"changed_at" bson:"changed_at"`
+}
+
+// Acknowledgement is a parent confirming they read a shared version. It is kept apart
+// from the status history, which only records the staff workflow.
+type Acknowledgement struct {
+	Version        int       `json:"version" bson:"version"`
+	Comment        string    `json:"comment" bson:"comment"`
+	AcknowledgedBy string    `json:"acknowledged_by" bson:"acknowledged_by"`
+	AcknowledgedAt time.Time `json:"acknowledged_at" bson:"acknowledged_at"`
+}
+
+const (
+	RevisionActionSave    = "save"
+	RevisionActionEdit    = "edit"
+	RevisionActionRestore = "restore"
+	RevisionActionShare   = "share" // snapshot of a book saved before revisions, taken when sharing
+)
+
+// IEBRevision is a snapshot of the IEB content taken on every save.
+type IEBRevision struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id"`
+	IEBID        primitive.ObjectID `json:"ieb_id" bson:"ieb_id"`
+	Version      int                `json:"version" bson:"version"`
+	Action       string             `json:"action" bson:"action"` // save, edit, restore
+	RestoredFrom *int               `json:"restored_from,omitempty" bson:"restored_from,omitempty"`
+	Information  []Information      `json:"information" bson:"information"`
+	CreatedBy    string             `json:"created_by" bson:"created_by"`
+	CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
 }
 
 type Owner struct {
@@ -24,11 +94,13 @@ type Owner struct {
 }
 
 type Information struct {
+	ID       string    `json:"id" bson:"id"`
 	Tilte    string    `json:"title" bson:"title"`
 	Contents []Content `json:"contents" bson:"contents"`
 }
 
 type Content struct {
+	ID        string    `json:"id" bson:"id"`
 	Label     string    `json:"label" bson:"label"`
 	Content   string    `json:"content" bson:"content"`
 	CreatedAt time.Time `json:"created_at" bson:"created_at"`
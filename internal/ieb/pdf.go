@@ -0,0 +1,263 @@
+package ieb
+
+import (
+	_ "embed"
+	"fmt"
+	"image"
+	"strings"
+	"time"
+
+	"github.com/signintech/gopdf"
+)
+
+// The PDF embeds DejaVu Sans (see fonts/LICENSE), which covers Vietnamese and the other
+// languages books are written in. Only the glyphs a document uses end up in the file.
+
+//go:embed fonts/DejaVuSans.ttf
+var fontRegularData []byte
+
+//go:embed fonts/DejaVuSans-Bold.ttf
+var fontBoldData []byte
+
+const (
+	pdfPageWidth  = 595.0 // A4
+	pdfPageHeight = 842.0
+	pdfMargin     = 50.0
+	pdfFooter     = 40.0
+	pdfBandHeight = 72.0
+	pdfAvatarSize = 80.0
+	pdfBodySize   = 10.5
+	pdfLeading    = 1.35
+)
+
+const (
+	fontRegular = "regular"
+	fontBold    = "bold"
+)
+
+var (
+	pdfWhite = [3]uint8{0xFF, 0xFF, 0xFF}
+	pdfGray  = [3]uint8{0x66, 0x66, 0x66}
+	pdfBlack = [3]uint8{0x1A, 0x1A, 0x1A}
+)
+
+// pdfLayout places the document top-down on A4 pages. The first error is kept and every
+// later call is skipped, so drawing code does not have to check each step.
+type pdfLayout struct {
+	pdf *gopdf.GoPdf
+	doc *exportDocument
+	y   float64
+	err error
+}
+
+func (l *pdfLayout) newPage() {
+	l.pdf.AddPage()
+	l.y = pdfMargin
+}
+
+// ensure starts a new page unless height still fits above the footer.
+func (l *pdfLayout) ensure(height float64) {
+	if l.y+height > pdfPageHeight-pdfMargin-pdfFooter {
+		l.newPage()
+	}
+}
+
+func (l *pdfLayout) setFont(font string, size float64) {
+	if l.err == nil {
+		l.err = l.pdf.SetFont(font, "", size)
+	}
+}
+
+func (l *pdfLayout) width(text string, font string, size float64) float64 {
+
+	l.setFont(font, size)
+	if l.err != nil {
+		return 0
+	}
+
+	width, err := l.pdf.MeasureTextWidth(text)
+	if err != nil {
+		l.err = err
+	}
+
+	return width
+}
+
+// text writes a single line with its top-left corner at x, y.
+func (l *pdfLayout) text(x float64, y float64, font string, size float64, color [3]uint8, text string) {
+
+	l.setFont(font, size)
+	if l.err != nil || text == "" {
+		return
+	}
+
+	l.pdf.SetTextColor(color[0], color[1], color[2])
+	l.pdf.SetXY(x, y)
+	l.err = l.pdf.Cell(nil, text)
+}
+
+// wrap breaks text into lines that fit the width, keeping the author's line breaks.
+func (l *pdfLayout) wrap(text string, font string, size float64, width float64) []string {
+
+	lines := make([]string, 0)
+
+	l.setFont(font, size)
+	if l.err != nil {
+		return lines
+	}
+
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+
+		paragraph = strings.Join(strings.Fields(paragraph), " ")
+		if paragraph == "" {
+			lines = append(lines, "")
+			continue
+		}
+
+		wrapped, err := l.pdf.SplitTextWithWordWrap(paragraph, width)
+		if err != nil {
+			l.err = err
+			return lines
+		}
+		lines = append(lines, wrapped...)
+	}
+
+	return lines
+}
+
+func (l *pdfLayout) paragraph(text string, font string, size float64, color [3]uint8) {
+	for _, line := range l.wrap(text, font, size, pdfPageWidth-2*pdfMargin) {
+		l.ensure(size * pdfLeading)
+		l.text(pdfMargin, l.y, font, size, color, line)
+		l.y += size * pdfLeading
+	}
+}
+
+func (l *pdfLayout) image(img image.Image, x float64, y float64, width float64, height float64) {
+	if l.err == nil {
+		l.err = l.pdf.ImageFrom(img, x, y, &gopdf.Rect{W: width, H: height})
+	}
+}
+
+func (l *pdfLayout) rule(width float64) {
+	color := l.doc.Color
+	l.pdf.SetStrokeColor(color[0], color[1], color[2])
+	l.pdf.SetLineWidth(width)
+	l.pdf.Line(pdfMargin, l.y, pdfPageWidth-pdfMargin, l.y)
+}
+
+func (l *pdfLayout) header() {
+
+	doc := l.doc
+
+	l.pdf.SetFillColor(doc.Color[0], doc.Color[1], doc.Color[2])
+	l.pdf.RectFromUpperLeftWithStyle(0, 0, pdfPageWidth, pdfBandHeight, "F")
+
+	textX := pdfMargin
+	if doc.Logo != nil {
+		height := 48.0
+		width := height * float64(doc.Logo.Bounds().Dx()) / float64(doc.Logo.Bounds().Dy())
+		l.image(doc.Logo, pdfMargin, 12, width, height)
+		textX += width + 12
+	}
+	l.text(textX, pdfBandHeight/2-10, fontBold, 18, pdfWhite, doc.SchoolName)
+
+	l.y = pdfBandHeight + 24
+	l.text(pdfMargin, l.y, fontBold, 20, doc.Color, doc.Title)
+
+	top := l.y + 24
+	if doc.Avatar != nil {
+		l.image(doc.Avatar, pdfPageWidth-pdfMargin-pdfAvatarSize, top, pdfAvatarSize, pdfAvatarSize)
+	}
+
+	l.y += 32
+	l.text(pdfMargin, l.y, fontBold, 15, pdfBlack, doc.StudentName)
+	l.y += 22
+
+	for _, detail := range doc.Details {
+		label := detail.Label + ": "
+		l.text(pdfMargin, l.y, fontBold, pdfBodySize, pdfGray, label)
+		l.text(pdfMargin+l.width(label, fontBold, pdfBodySize), l.y, fontRegular, pdfBodySize, pdfBlack, detail.Value)
+		l.y += pdfBodySize * pdfLeading
+	}
+
+	if doc.Avatar != nil && l.y < top+pdfAvatarSize {
+		l.y = top + pdfAvatarSize
+	}
+
+	l.y += 12
+	l.rule(1)
+	l.y += 10
+}
+
+func (l *pdfLayout) sections() {
+
+	for _, section := range l.doc.Sections {
+
+		// Keep a heading together with at least its first lines.
+		l.ensure(60)
+		l.y += 10
+		l.text(pdfMargin, l.y, fontBold, 13, l.doc.Color, section.Title)
+		l.y += 18
+		l.rule(0.5)
+		l.y += 4
+
+		for _, item := range section.Items {
+			l.y += 6
+			if item.Label != "" {
+				l.paragraph(item.Label, fontBold, pdfBodySize, pdfBlack)
+			}
+			l.paragraph(item.Value, fontRegular, pdfBodySize, pdfBlack)
+		}
+	}
+}
+
+func (l *pdfLayout) footers() {
+
+	size := 9.0
+	pages := l.pdf.GetNumberOfPages()
+
+	for i := 1; i <= pages && l.err == nil; i++ {
+
+		if l.err = l.pdf.SetPage(i); l.err != nil {
+			return
+		}
+
+		label := fmt.Sprintf("%s %d / %d", l.doc.PageLabel, i, pages)
+		y := pdfPageHeight - pdfMargin + 8
+		l.text(pdfPageWidth-pdfMargin-l.width(label, fontRegular, size), y, fontRegular, size, pdfGray, label)
+		l.text(pdfMargin, y, fontRegular, size, pdfGray, l.doc.SchoolName)
+	}
+}
+
+func renderPDF(doc *exportDocument) ([]byte, error) {
+
+	pdf := &gopdf.GoPdf{}
+	pdf.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4})
+
+	if err := pdf.AddTTFFontData(fontRegular, fontRegularData); err != nil {
+		return nil, err
+	}
+	if err := pdf.AddTTFFontData(fontBold, fontBoldData); err != nil {
+		return nil, err
+	}
+
+	pdf.SetInfo(gopdf.PdfInfo{
+		Title:        doc.Title + " - " + doc.StudentName,
+		Author:       doc.SchoolName,
+		CreationDate: time.Now(),
+	})
+
+	layout := &pdfLayout{pdf: pdf, doc: doc}
+
+	layout.newPage()
+	layout.header()
+	layout.sections()
+	layout.footers()
+
+	if layout.err != nil {
+		return nil, fmt.Errorf("error when render pdf: %v", layout.err)
+	}
+
+	return pdf.GetBytesPdfReturnErr()
+}
@@ -0,0 +1,36 @@
+package ieb
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRenderPDF(t *testing.T) {
+
+	doc := &exportDocument{
+		Language:    "vi",
+		SchoolName:  "Trường Mầm Non",
+		Color:       parseHexColor("#1F4E79"),
+		Title:       labelsFor("vi").Title,
+		StudentName: "Nguyễn Thị Hồng Ngọc",
+		PageLabel:   labelsFor("vi").Page,
+		Details:     []exportDetail{{Label: "Học kỳ", Value: "01/09/2025 - 31/12/2025"}},
+		Sections: []exportSection{
+			{Title: "Mục tiêu", Items: []exportDetail{{Label: "Đọc", Value: "Đọc to một đoạn văn ngắn.\n\nKể lại câu chuyện bằng lời của mình."}}},
+			{Title: "Empty", Items: []exportDetail{{Value: labelsFor("vi").Empty}}},
+		},
+	}
+
+	data, err := renderPDF(doc)
+	if err != nil {
+		t.Fatalf("renderPDF: %v", err)
+	}
+
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		t.Fatalf("output is not a PDF")
+	}
+
+	if !bytes.Contains(data, []byte("FontFile2")) {
+		t.Errorf("expected the TrueType font to be embedded")
+	}
+}
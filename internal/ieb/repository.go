@@ -3,27 +3,132 @@ package ieb
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type IEBRepository interface {
-	CreateIEB(ctx context.Context, data *IEB) error
+	EnsureIndexes(ctx context.Context) error
+	GetIEBs(ctx context.Context, filter IEBFilter, page int, size int) ([]*IEB, int64, error)
+	CreateIEB(ctx context.Context, data *IEB) (*IEB, error)
 	GetIEB(ctx context.Context, userID string, termID string, languageKey, regionKey string) (*IEB, error)
+	GetIEBByID(ctx context.Context, id primitive.ObjectID) (*IEB, error)
+	GetSiblings(ctx context.Context, ownerID string, termID string) ([]*IEB, error)
+	UpdateIEBInformation(ctx context.Context, id primitive.ObjectID, version int, information []Information, userID string, reopen *StatusTransition) (*IEB, error)
+	TransitionIEB(ctx context.Context, id primitive.ObjectID, from string, transition *StatusTransition, sharedVersion *int) (*IEB, error)
+	BackfillIDs(ctx context.Context, id primitive.ObjectID, version int, information []Information) error
+	AcknowledgeIEB(ctx context.Context, id primitive.ObjectID, acknowledgement *Acknowledgement) (*IEB, error)
+	GetIEBsMissingIDs(ctx context.Context) ([]*IEB, error)
+	CreateRevision(ctx context.Context, revision *IEBRevision) error
+	GetRevisions(ctx context.Context, iebID primitive.ObjectID) ([]*IEBRevision, error)
+	GetRevision(ctx context.Context, iebID primitive.ObjectID, version int) (*IEBRevision, error)
 }
 
 type iebRepository struct {
-	IEBCollection *mongo.Collection
+	IEBCollection      *mongo.Collection
+	RevisionCollection *mongo.Collection
 }
 
-func NewIEBRepository(collection *mongo.Collection) IEBRepository {
+func NewIEBRepository(collection *mongo.Collection, revisionCollection *mongo.Collection) IEBRepository {
 	return &iebRepository{
-		IEBCollection: collection,
+		IEBCollection:      collection,
+		RevisionCollection: revisionCollection,
 	}
 }
 
-func (repository *iebRepository) CreateIEB(ctx context.Context, data *IEB) error {
+// EnsureIndexes creates the text index used by list search. Books are written in several
+// languages, so no language-specific stemming is applied.
+func (repository *iebRepository) EnsureIndexes(ctx context.Context) error {
+
+	_, err := repository.IEBCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "information.title", Value: "text"},
+			{Key: "information.contents.label", Value: "text"},
+			{Key: "information.contents.content", Value: "text"},
+		},
+		Options: options.Index().SetName("ieb_text").SetDefaultLanguage("none"),
+	})
+
+	return err
+
+}
+
+func (repository *iebRepository) GetIEBs(ctx context.Context, filter IEBFilter, page int, size int) ([]*IEB, int64, error) {
+
+	query := bson.M{}
+
+	if filter.TermID != "" {
+		query["term_id"] = filter.TermID
+	}
+	if filter.OwnerID != "" {
+		query["owner.owner_id"] = filter.OwnerID
+	}
+	if filter.OwnerRole != "" {
+		query["owner.owner_role"] = filter.OwnerRole
+	}
+	if filter.LanguageKey != "" {
+		query["language_key"] = filter.LanguageKey
+	}
+	if filter.RegionKey != "" {
+		query["region_key"] = filter.RegionKey
+	}
+	if filter.Status == StatusDraft {
+		query["status"] = bson.M{"$in": bson.A{StatusDraft, "", nil}}
+	} else if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	updatedAt := bson.M{}
+	if filter.UpdatedFrom != nil {
+		updatedAt["$gte"] = *filter.UpdatedFrom
+	}
+	if filter.UpdatedTo != nil {
+		updatedAt["$lt"] = *filter.UpdatedTo
+	}
+	if len(updatedAt) > 0 {
+		query["updated_at"] = updatedAt
+	}
+
+	findOpts := options.Find().
+		SetSkip(int64((page - 1) * size)).
+		SetLimit(int64(size)).
+		SetProjection(bson.M{"status_history": 0})
+
+	if filter.Search != "" {
+		query["$text"] = bson.M{"$search": filter.Search}
+		score := bson.M{"$meta": "textScore"}
+		findOpts.SetProjection(bson.M{"status_history": 0, "score": score})
+		findOpts.SetSort(bson.D{{Key: "score", Value: score}, {Key: "updated_at", Value: -1}})
+	} else {
+		findOpts.SetSort(bson.D{{Key: "updated_at", Value: -1}, {Key: "_id", Value: -1}})
+	}
+
+	total, err := repository.IEBCollection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cursor, err := repository.IEBCollection.Find(ctx, query, findOpts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var iebs []*IEB
+	if err := cursor.All(ctx, &iebs); err != nil {
+		return nil, 0, err
+	}
+
+	return iebs, total, nil
+
+}
+
+// CreateIEB saves the IEB for owner, term, language and region. The existing document is
+// updated in place and its version bumped, so its ID and revisions survive the save.
+func (repository *iebRepository) CreateIEB(ctx context.Context, data *IEB) (*IEB, error) {
 
 	filter := bson.M{
 		"owner.owner_id": data.Owner.OwnerID,
@@ -32,13 +137,32 @@ func (repository *iebRepository) CreateIEB(ctx context.Context, data *IEB) error
 		"region_key":     data.RegionKey,
 	}
 
-	_, err := repository.IEBCollection.DeleteOne(ctx, filter)
-	if err != nil {
-		return err
+	update := bson.M{
+		"$set": bson.M{
+			"owner":       data.Owner,
+			"information": data.Information,
+			"updated_by":  data.UpdatedBy,
+			"updated_at":  data.UpdatedAt,
+		},
+		"$setOnInsert": bson.M{
+			"_id":             data.ID,
+			"created_by":      data.CreatedBy,
+			"created_at":      data.CreatedAt,
+			"status":          StatusDraft,
+			"source_id":       data.SourceID,
+			"organization_id": data.OrganizationID,
+		},
+		"$inc": bson.M{"version": 1},
 	}
 
-	_, err = repository.IEBCollection.InsertOne(ctx, data)
-	return err
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var ieb IEB
+	if err := repository.IEBCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&ieb); err != nil {
+		return nil, err
+	}
+
+	return &ieb, nil
 
 }
 
@@ -55,8 +179,255 @@ func (repository *iebRepository) GetIEB(ctx context.Context, userID string, term
 
 	err := repository.IEBCollection.FindOne(ctx, filter).Decode(&ieb)
 	if err == mongo.ErrNoDocuments {
-		return nil, fmt.Errorf("ieb not found")
+		return nil, ErrIEBNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &ieb, nil
+
+}
+
+func (repository *iebRepository) GetIEBByID(ctx context.Context, id primitive.ObjectID) (*IEB, error) {
+
+	var ieb IEB
+
+	err := repository.IEBCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&ieb)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrIEBNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &ieb, nil
+
+}
+
+// GetSiblings returns every language version of the owner's IEB for the term, oldest first.
+func (repository *iebRepository) GetSiblings(ctx context.Context, ownerID string, termID string) ([]*IEB, error) {
+
+	filter := bson.M{
+		"owner.owner_id": ownerID,
+		"term_id":        termID,
+	}
+
+	findOpts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := repository.IEBCollection.Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var siblings []*IEB
+	if err := cursor.All(ctx, &siblings); err != nil {
+		return nil, err
+	}
+
+	return siblings, nil
+
+}
+
+// UpdateIEBInformation replaces the content if the IEB is still at the given version.
+// A reviewed IEB is put back to draft when reopen is set.
+func (repository *iebRepository) UpdateIEBInformation(ctx context.Context, id primitive.ObjectID, version int, information []Information, userID string, reopen *StatusTransition) (*IEB, error) {
+
+	filter := bson.M{
+		"_id":     id,
+		"version": versionFilter(version),
+	}
+
+	set := bson.M{
+		"information": information,
+		"updated_by":  userID,
+		"updated_at":  time.Now(),
+	}
+
+	update := bson.M{
+		"$set": set,
+		"$inc": bson.M{"version": 1},
+	}
+
+	if reopen != nil {
+		set["status"] = reopen.To
+		update["$push"] = bson.M{"status_history": reopen}
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var ieb IEB
+	err := repository.IEBCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&ieb)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrVersionConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &ieb, nil
+
+}
+
+// TransitionIEB moves the IEB to a new status if it is still in the from status. Sharing
+// also records which version parents get to see.
+func (repository *iebRepository) TransitionIEB(ctx context.Context, id primitive.ObjectID, from string, transition *StatusTransition, sharedVersion *int) (*IEB, error) {
+
+	filter := bson.M{"_id": id, "status": from}
+	if from == StatusDraft {
+		// IEBs saved before the workflow have no status and count as drafts.
+		filter["status"] = bson.M{"$in": bson.A{StatusDraft, "", nil}}
+	}
+
+	set := bson.M{"status": transition.To}
+	if sharedVersion != nil {
+		set["shared_version"] = *sharedVersion
+		set["shared_at"] = transition.ChangedAt
+	}
+
+	update := bson.M{
+		"$set":  set,
+		"$push": bson.M{"status_history": transition},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var ieb IEB
+	err := repository.IEBCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&ieb)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrVersionConflict
+	}
+	if err != nil {
+		return nil, err
 	}
-	return &ieb, err
+
+	return &ieb, nil
+
+}
+
+// AcknowledgeIEB records that the parent read the shared version. The staff workflow may
+// have moved on since sharing, so neither the status nor its history is touched.
+func (repository *iebRepository) AcknowledgeIEB(ctx context.Context, id primitive.ObjectID, acknowledgement *Acknowledgement) (*IEB, error) {
+
+	filter := bson.M{
+		"_id":                  id,
+		"shared_version":       acknowledgement.Version,
+		"acknowledged_version": bson.M{"$ne": acknowledgement.Version},
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"acknowledged_version": acknowledgement.Version,
+			"acknowledged_at":      acknowledgement.AcknowledgedAt,
+		},
+		"$push": bson.M{"acknowledgements": acknowledgement},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var ieb IEB
+	err := repository.IEBCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&ieb)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrVersionConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &ieb, nil
+
+}
+
+// BackfillIDs stores IDs generated for sections and contents saved before they had any.
+// The content itself is unchanged, so the version is left alone.
+func (repository *iebRepository) BackfillIDs(ctx context.Context, id primitive.ObjectID, version int, information []Information) error {
+
+	filter := bson.M{
+		"_id":     id,
+		"version": versionFilter(version),
+	}
+
+	_, err := repository.IEBCollection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"information": information}})
+	return err
+
+}
+
+// GetIEBsMissingIDs returns the IEBs with a section or content item saved without an ID.
+func (repository *iebRepository) GetIEBsMissingIDs(ctx context.Context) ([]*IEB, error) {
+
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{"information": bson.M{"$elemMatch": bson.M{"id": bson.M{"$in": bson.A{"", nil}}}}},
+			bson.M{"information": bson.M{"$elemMatch": bson.M{"contents": bson.M{"$elemMatch": bson.M{"id": bson.M{"$in": bson.A{"", nil}}}}}}},
+		},
+	}
+
+	cursor, err := repository.IEBCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var iebs []*IEB
+	if err := cursor.All(ctx, &iebs); err != nil {
+		return nil, err
+	}
+
+	return iebs, nil
+
+}
+
+// versionFilter matches the given version. IEBs saved before versioning have no version
+// field and are read as version 0, so 0 also matches a missing field.
+func versionFilter(version int) interface{} {
+
+	if version == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+
+	return version
+
+}
+
+func (repository *iebRepository) CreateRevision(ctx context.Context, revision *IEBRevision) error {
+
+	_, err := repository.RevisionCollection.InsertOne(ctx, revision)
+	return err
+
+}
+
+func (repository *iebRepository) GetRevisions(ctx context.Context, iebID primitive.ObjectID) ([]*IEBRevision, error) {
+
+	findOpts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}})
+
+	cursor, err := repository.RevisionCollection.Find(ctx, bson.M{"ieb_id": iebID}, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var revisions []*IEBRevision
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+
+}
+
+func (repository *iebRepository) GetRevision(ctx context.Context, iebID primitive.ObjectID, version int) (*IEBRevision, error) {
+
+	var revision IEBRevision
+
+	err := repository.RevisionCollection.FindOne(ctx, bson.M{"ieb_id": iebID, "version": version}).Decode(&revision)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("%w: version %d", ErrRevisionNotFound, version)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
 
 }
@@ -6,4 +6,70 @@ type CreateIEBRequest struct {
 	LanguageKey string        `json:"language_key" bson:"language_key"`
 	RegionKey   string        `json:"region_key" bson:"region_key"`
 	Information []Information `json:"information" bson:"information"`
+	Version     *int          `json:"version" bson:"version"` // version read by the client, checked when set
+
+	OrganizationID string `json:"organization_id" bson:"organization_id"` // stored when the book is created
+}
+
+type AddSectionRequest struct {
+	Version  *int   `json:"version" bson:"version"`
+	Title    string `json:"title" bson:"title"`
+	Position *int   `json:"position" bson:"position"` // zero-based, appended when empty
+}
+
+type UpdateSectionRequest struct {
+	Version *int    `json:"version" bson:"version"`
+	Title   *string `json:"title" bson:"title"`
+}
+
+type ReorderSectionsRequest struct {
+	Version    *int     `json:"version" bson:"version"`
+	SectionIDs []string `json:"section_ids" bson:"section_ids"`
+}
+
+type AddContentRequest struct {
+	Version  *int   `json:"version" bson:"version"`
+	Label    string `json:"label" bson:"label"`
+	Content  string `json:"content" bson:"content"`
+	Position *int   `json:"position" bson:"position"`
+}
+
+type UpdateContentRequest struct {
+	Version *int    `json:"version" bson:"version"`
+	Label   *string `json:"label" bson:"label"`
+	Content *string `json:"content" bson:"content"`
+}
+
+type ReorderContentsRequest struct {
+	Version    *int     `json:"version" bson:"version"`
+	ContentIDs []string `json:"content_ids" bson:"content_ids"`
+}
+
+type TransitionIEBRequest struct {
+	Status  string `json:"status" bson:"status"`
+	Comment string `json:"comment" bson:"comment"`
+}
+
+// GetIEBsRequest holds the list query parameters, all optional. Dates are 2006-01-02 and
+// inclusive.
+type GetIEBsRequest struct {
+	TermID      string
+	OwnerID     string
+	OwnerRole   string
+	LanguageKey string
+	RegionKey   string
+	Status      string
+	UpdatedFrom string
+	UpdatedTo   string
+	Search      string
+	Page        string
+	Size        string
+}
+
+type ExportIEBRequest struct {
+	OwnerID     string
+	TermID      string
+	LanguageKey string
+	RegionKey   string
+	Format      string // pdf (default) or docx
 }
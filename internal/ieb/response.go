@@ -0,0 +1,108 @@
+package ieb
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RevisionSummary struct {
+	ID           primitive.ObjectID `json:"id"`
+	Version      int                `json:"version"`
+	Action       string             `json:"action"`
+	RestoredFrom *int               `json:"restored_from,omitempty"`
+	Sections     int                `json:"sections"`
+	CreatedBy    string             `json:"created_by"`
+	CreatedAt    time.Time          `json:"created_at"`
+}
+
+type RevisionDiffResponse struct {
+	IEBID       primitive.ObjectID `json:"ieb_id"`
+	FromVersion int                `json:"from_version"`
+	ToVersion   int                `json:"to_version"`
+	*InformationDiff
+}
+
+type InformationDiff struct {
+	Added     int           `json:"added"`
+	Removed   int           `json:"removed"`
+	Changed   int           `json:"changed"`
+	Unchanged int           `json:"unchanged"`
+	Sections  []SectionDiff `json:"sections"`
+}
+
+type SectionDiff struct {
+	Title    string        `json:"title"`
+	Status   string        `json:"status"` // added, removed, changed, unchanged
+	Contents []ContentDiff `json:"contents"`
+}
+
+type ContentDiff struct {
+	Label  string  `json:"label"`
+	Status string  `json:"status"`
+	Before *string `json:"before"`
+	After  *string `json:"after"`
+}
+
+type TranslationsResponse struct {
+	OwnerID           string                `json:"owner_id"`
+	TermID            string                `json:"term_id"`
+	SourceLanguageKey string                `json:"source_language_key"`
+	SourceRegionKey   string                `json:"source_region_key"`
+	Translations      []*TranslationSummary `json:"translations"`
+}
+
+type TranslationSummary struct {
+	ID          primitive.ObjectID  `json:"id"`
+	LanguageKey string              `json:"language_key"`
+	RegionKey   string              `json:"region_key"`
+	IsSource    bool                `json:"is_source"`
+	SourceID    *primitive.ObjectID `json:"source_id,omitempty"`
+	Stale       bool                `json:"stale"`
+	Status      string              `json:"status"`
+	Version     int                 `json:"version"`
+	UpdatedBy   string              `json:"updated_by"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+type IEBListResponse struct {
+	Items      []*IEBSummary `json:"items"`
+	Page       int           `json:"page"`
+	Size       int           `json:"size"`
+	Total      int64         `json:"total"`
+	TotalPages int64         `json:"total_pages"`
+}
+
+type IEBSummary struct {
+	ID          primitive.ObjectID `json:"id"`
+	Owner       *Owner             `json:"owner"`
+	TermID      string             `json:"term_id"`
+	LanguageKey string             `json:"language_key"`
+	RegionKey   string             `json:"region_key"`
+	Status      string             `json:"status"`
+	Version     int                `json:"version"`
+	Sections    []string           `json:"sections"` // section titles
+	Score       float64            `json:"score,omitempty"`
+	UpdatedBy   string             `json:"updated_by"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+type TermComparisonResponse struct {
+	OwnerID     string          `json:"owner_id"`
+	LanguageKey string          `json:"language_key"`
+	RegionKey   string          `json:"region_key"`
+	From        *TermComparison `json:"from"`
+	To          *TermComparison `json:"to"`
+	*InformationDiff
+}
+
+// TermComparison is one side of the comparison. IEBID is empty when the child has no IEB
+// for that term, in which case everything on the other side shows as added or removed.
+type TermComparison struct {
+	TermID    string              `json:"term_id"`
+	StartDate string              `json:"start_date"`
+	EndDate   string              `json:"end_date"`
+	IEBID     *primitive.ObjectID `json:"ieb_id,omitempty"`
+	Version   int                 `json:"version"`
+	UpdatedAt *time.Time          `json:"updated_at,omitempty"`
+}
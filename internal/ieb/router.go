@@ -2,6 +2,7 @@ package ieb
 
 import (
 	"portal/internal/middleware"
+	"portal/pkg/constants"
 
 	"github.com/gin-gonic/gin"
 )
@@ -9,11 +10,39 @@ import (
 func RegisterRouters(r *gin.Engine, IEBHandler *IEBHandler) {
 	group := r.Group("/api/v1/ieb", middleware.Secured())
 	{
-		// group.GET("", IEBHandler.GetIEBs)
 		group.GET("", IEBHandler.GetIEB)
-		group.POST("", IEBHandler.CreateIEB)
+		// The list is built from live content and searches it, so it is for staff only.
+		group.GET("/list", middleware.RequireRoles(constants.StaffRoles...), IEBHandler.GetIEBs)
+		group.POST("", middleware.RequireRoles(constants.StaffRoles...), IEBHandler.CreateIEB)
+		group.GET("/translations", IEBHandler.GetTranslations)
+		group.GET("/comparison", IEBHandler.CompareTerms)
+		group.GET("/export", IEBHandler.ExportIEB)
 		// group.PUT("/:id", IEBHandler.UpdateIEB)
 		// group.DELETE("/:id", IEBHandler.DeleteIEB)
+
+		group.POST("/:id/transition", IEBHandler.TransitionIEB)
+
+		// Revisions hold unreviewed content, so parents go through GetIEB instead.
+		revisions := group.Group("/:id/revisions", middleware.RequireRoles(constants.StaffRoles...))
+		{
+			revisions.GET("", IEBHandler.GetRevisions)
+			revisions.GET("/diff", IEBHandler.DiffRevisions)
+			revisions.GET("/:version", IEBHandler.GetRevision)
+			revisions.POST("/:version/restore", IEBHandler.RestoreRevision)
+		}
+
+		// Only staff write the book; parents read the shared version.
+		sections := group.Group("/:id/sections", middleware.RequireRoles(constants.StaffRoles...))
+		{
+			sections.POST("", IEBHandler.AddSection)
+			sections.PUT("/order", IEBHandler.ReorderSections)
+			sections.PUT("/:section_id", IEBHandler.UpdateSection)
+			sections.DELETE("/:section_id", IEBHandler.RemoveSection)
+			sections.POST("/:section_id/contents", IEBHandler.AddContent)
+			sections.PUT("/:section_id/contents/order", IEBHandler.ReorderContents)
+			sections.PUT("/:section_id/contents/:content_id", IEBHandler.UpdateContent)
+			sections.DELETE("/:section_id/contents/:content_id", IEBHandler.RemoveContent)
+		}
 	}
 
 }
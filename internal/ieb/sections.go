@@ -0,0 +1,182 @@
+package ieb
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrIEBNotFound      = errors.New("ieb not found")
+	ErrRevisionNotFound = errors.New("revision not found")
+
+	// ErrVersionConflict is returned when the IEB changed since the caller read it.
+	ErrVersionConflict = errors.New("ieb was modified by someone else, please reload")
+)
+
+// assignIDs gives every section and content item without an ID a new one. It reports
+// whether anything was assigned.
+func assignIDs(information []Information) bool {
+
+	assigned := false
+
+	for i := range information {
+		if information[i].ID == "" {
+			information[i].ID = primitive.NewObjectID().Hex()
+			assigned = true
+		}
+		for j := range information[i].Contents {
+			if information[i].Contents[j].ID == "" {
+				information[i].Contents[j].ID = primitive.NewObjectID().Hex()
+				assigned = true
+			}
+		}
+	}
+
+	return assigned
+}
+
+// stampContents sets the content timestamps on the server side. Items kept from the
+// previous save keep their creation time and are only touched when their text changed;
+// whatever timestamps the client sent are ignored.
+func stampContents(information []Information, previous []Information, now time.Time) {
+
+	existing := map[string]Content{}
+	for _, section := range previous {
+		for _, content := range section.Contents {
+			existing[content.ID] = content
+		}
+	}
+
+	for i := range information {
+		for j := range information[i].Contents {
+			content := &information[i].Contents[j]
+			old, ok := existing[content.ID]
+			if !ok || content.ID == "" {
+				content.CreatedAt = now
+				content.UpdatedAt = now
+				continue
+			}
+			content.CreatedAt = old.CreatedAt
+			if old.Label != content.Label || old.Content != content.Content {
+				content.UpdatedAt = now
+			} else {
+				content.UpdatedAt = old.UpdatedAt
+			}
+		}
+	}
+}
+
+func findSection(information []Information, sectionID string) (int, error) {
+	for i, section := range information {
+		if section.ID == sectionID {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("section not found")
+}
+
+func findContent(section *Information, contentID string) (int, error) {
+	for i, content := range section.Contents {
+		if content.ID == contentID {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("content not found")
+}
+
+// insertAt returns the index to insert at: the position when it is inside the list,
+// otherwise the end.
+func insertAt(position *int, length int) (int, error) {
+	if position == nil || *position >= length {
+		return length, nil
+	}
+	if *position < 0 {
+		return 0, fmt.Errorf("position must not be negative")
+	}
+	return *position, nil
+}
+
+func addSection(information []Information, title string, position *int) ([]Information, *Information, error) {
+
+	index, err := insertAt(position, len(information))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	section := Information{ID: primitive.NewObjectID().Hex(), Tilte: title, Contents: []Content{}}
+
+	information = append(information, Information{})
+	copy(information[index+1:], information[index:])
+	information[index] = section
+
+	return information, &information[index], nil
+}
+
+func addContent(section *Information, label string, text string, position *int, now time.Time) (*Content, error) {
+
+	index, err := insertAt(position, len(section.Contents))
+	if err != nil {
+		return nil, err
+	}
+
+	content := Content{
+		ID:        primitive.NewObjectID().Hex(),
+		Label:     label,
+		Content:   text,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	section.Contents = append(section.Contents, Content{})
+	copy(section.Contents[index+1:], section.Contents[index:])
+	section.Contents[index] = content
+
+	return &section.Contents[index], nil
+}
+
+func reorderSections(information []Information, order []string) ([]Information, error) {
+
+	if len(order) != len(information) {
+		return nil, fmt.Errorf("section_ids must list every section")
+	}
+
+	result := make([]Information, 0, len(order))
+	seen := map[string]bool{}
+
+	for _, id := range order {
+		index, err := findSection(information, id)
+		if err != nil || seen[id] {
+			return nil, fmt.Errorf("section_ids must list every section")
+		}
+		seen[id] = true
+		result = append(result, information[index])
+	}
+
+	return result, nil
+}
+
+func reorderContents(section *Information, order []string) error {
+
+	if len(order) != len(section.Contents) {
+		return fmt.Errorf("content_ids must list every content of the section")
+	}
+
+	result := make([]Content, 0, len(order))
+	seen := map[string]bool{}
+
+	for _, id := range order {
+		index, err := findContent(section, id)
+		if err != nil || seen[id] {
+			return fmt.Errorf("content_ids must list every content of the section")
+		}
+		seen[id] = true
+		result = append(result, section.Contents[index])
+	}
+
+	section.Contents = result
+
+	return nil
+}
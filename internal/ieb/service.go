@@ -2,24 +2,61 @@ package ieb
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"portal/internal/organization"
+	"portal/internal/term"
+	"portal/internal/user"
+	"portal/pkg/constants"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type IEBService interface {
 	CreateIEB(ctx context.Context, req *CreateIEBRequest, userID string) (string, error)
-	GetIEB(ctx context.Context, userID string, termID string, languageKey string, regionKey string) (*IEB, error)
+	GetIEB(ctx context.Context, userID string, termID string, languageKey string, regionKey string, sharedOnly bool) (*IEB, error)
+	GetIEBs(ctx context.Context, req *GetIEBsRequest) (*IEBListResponse, error)
+	ExportIEB(ctx context.Context, req *ExportIEBRequest, sharedOnly bool) (*ExportFile, error)
+	CompareTerms(ctx context.Context, ownerID string, fromTermID string, toTermID string, languageKey string, regionKey string, sharedOnly bool) (*TermComparisonResponse, error)
+	GetTranslations(ctx context.Context, ownerID string, termID string, sharedOnly bool) (*TranslationsResponse, error)
+	TransitionIEB(ctx context.Context, id string, req *TransitionIEBRequest, userID string, roles []string) (*IEB, error)
+	GetRevisions(ctx context.Context, id string) ([]*RevisionSummary, error)
+	GetRevision(ctx context.Context, id string, version string) (*IEBRevision, error)
+	DiffRevisions(ctx context.Context, id string, from string, to string) (*RevisionDiffResponse, error)
+	RestoreRevision(ctx context.Context, id string, version string, userID string) (*IEB, error)
+	AddSection(ctx context.Context, id string, req *AddSectionRequest, userID string) (*IEB, error)
+	UpdateSection(ctx context.Context, id string, sectionID string, req *UpdateSectionRequest, userID string) (*IEB, error)
+	RemoveSection(ctx context.Context, id string, sectionID string, version string, userID string) (*IEB, error)
+	ReorderSections(ctx context.Context, id string, req *ReorderSectionsRequest, userID string) (*IEB, error)
+	AddContent(ctx context.Context, id string, sectionID string, req *AddContentRequest, userID string) (*IEB, error)
+	UpdateContent(ctx context.Context, id string, sectionID string, contentID string, req *UpdateContentRequest, userID string) (*IEB, error)
+	RemoveContent(ctx context.Context, id string, sectionID string, contentID string, version string, userID string) (*IEB, error)
+	ReorderContents(ctx context.Context, id string, sectionID string, req *ReorderContentsRequest, userID string) (*IEB, error)
+	BackfillIDs(ctx context.Context) error
 }
 
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
 type iebService struct {
-	iebRepository IEBRepository
+	iebRepository       IEBRepository
+	termService         term.TermService
+	userService         user.UserService
+	organizationService organization.OrganizationService
 }
 
-func NewIEBService(iebRepository IEBRepository) IEBService {
+func NewIEBService(iebRepository IEBRepository, termService term.TermService, userService user.UserService, organizationService organization.OrganizationService) IEBService {
 	return &iebService{
-		iebRepository: iebRepository,
+		iebRepository:       iebRepository,
+		termService:         termService,
+		userService:         userService,
+		organizationService: organizationService,
 	}
 }
 
@@ -41,25 +78,199 @@ func (service *iebService) CreateIEB(ctx context.Context, req *CreateIEBRequest,
 		return "", fmt.Errorf("region_id is required")
 	}
 
-	ID := primitive.NewObjectID()
+	now := time.Now()
+	information := req.Information
+	assignIDs(information)
 
-	data := &IEB{
-		ID:          ID,
-		Owner:       req.Owner,
-		TermID:      req.TermID,
-		LanguageKey: req.LanguageKey,
-		RegionKey:   req.RegionKey,
-		Information: req.Information,
-		CreatedBy:   userID,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+	existing, err := service.iebRepository.GetIEB(ctx, req.Owner.OwnerID, req.TermID, req.LanguageKey, req.RegionKey)
+	if err != nil && !errors.Is(err, ErrIEBNotFound) {
+		return "", err
+	}
+
+	var ieb *IEB
+
+	if existing != nil {
+
+		// Saving over an existing book must say which version it was based on, or it
+		// would silently overwrite edits made since the client loaded it.
+		if req.Version == nil {
+			return "", fmt.Errorf("version is required")
+		}
+
+		if *req.Version != existing.Version {
+			return "", ErrVersionConflict
+		}
+
+		stampContents(information, existing.Information, now)
+
+		ieb, err = service.iebRepository.UpdateIEBInformation(ctx, existing.ID, existing.Version, information, userID, reopenTransition(existing, userID, now))
+		if err != nil {
+			return "", err
+		}
+
+	} else {
+
+		stampContents(information, nil, now)
+
+		// A new language of an existing book becomes a translation of its source.
+		siblings, err := service.iebRepository.GetSiblings(ctx, req.Owner.OwnerID, req.TermID)
+		if err != nil {
+			return "", err
+		}
+
+		var sourceID *primitive.ObjectID
+		organizationID := req.OrganizationID
+		if source := findSource(siblings); source != nil {
+			sourceID = &source.ID
+			if organizationID == "" {
+				organizationID = source.OrganizationID
+			}
+		}
+
+		ieb, err = service.iebRepository.CreateIEB(ctx, &IEB{
+			ID:          primitive.NewObjectID(),
+			Owner:       req.Owner,
+			TermID:      req.TermID,
+			LanguageKey: req.LanguageKey,
+			RegionKey:   req.RegionKey,
+			Information: information,
+			CreatedBy:   userID,
+			UpdatedBy:   userID,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			SourceID:    sourceID,
+
+			OrganizationID: organizationID,
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if err := service.createRevision(ctx, ieb, RevisionActionSave, nil, userID); err != nil {
+		return "", err
 	}
 
-	return ID.Hex(), service.iebRepository.CreateIEB(ctx, data)
+	return ieb.ID.Hex(), nil
 
 }
 
-func (service *iebService) GetIEB(ctx context.Context, userID string, termID string, languageKey string, regionKey string) (*IEB, error) {
+func (service *iebService) GetRevisions(ctx context.Context, id string) ([]*RevisionSummary, error) {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := service.iebRepository.GetRevisions(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*RevisionSummary, 0, len(revisions))
+	for _, revision := range revisions {
+		result = append(result, &RevisionSummary{
+			ID:           revision.ID,
+			Version:      revision.Version,
+			Action:       revision.Action,
+			RestoredFrom: revision.RestoredFrom,
+			Sections:     len(revision.Information),
+			CreatedBy:    revision.CreatedBy,
+			CreatedAt:    revision.CreatedAt,
+		})
+	}
+
+	return result, nil
+
+}
+
+func (service *iebService) GetRevision(ctx context.Context, id string, version string) (*IEBRevision, error) {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	versionNumber, err := strconv.Atoi(version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version")
+	}
+
+	return service.iebRepository.GetRevision(ctx, objectID, versionNumber)
+
+}
+
+func (service *iebService) DiffRevisions(ctx context.Context, id string, from string, to string) (*RevisionDiffResponse, error) {
+
+	if from == "" || to == "" {
+		return nil, fmt.Errorf("from and to are required")
+	}
+
+	before, err := service.GetRevision(ctx, id, from)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := service.GetRevision(ctx, id, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RevisionDiffResponse{
+		IEBID:           before.IEBID,
+		FromVersion:     before.Version,
+		ToVersion:       after.Version,
+		InformationDiff: diffInformation(before.Information, after.Information),
+	}, nil
+
+}
+
+// RestoreRevision makes the content of an old revision current again. The restore is
+// recorded as a new revision, so nothing in the history is lost.
+func (service *iebService) RestoreRevision(ctx context.Context, id string, version string, userID string) (*IEB, error) {
+
+	revision, err := service.GetRevision(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := service.iebRepository.GetIEBByID(ctx, revision.IEBID)
+	if err != nil {
+		return nil, err
+	}
+
+	ieb, err := service.iebRepository.UpdateIEBInformation(ctx, current.ID, current.Version, revision.Information, userID, reopenTransition(current, userID, time.Now()))
+	if err != nil {
+		return nil, err
+	}
+
+	restoredFrom := revision.Version
+	if err := service.createRevision(ctx, ieb, RevisionActionRestore, &restoredFrom, userID); err != nil {
+		return nil, err
+	}
+
+	return ieb, nil
+
+}
+
+func (service *iebService) createRevision(ctx context.Context, ieb *IEB, action string, restoredFrom *int, userID string) error {
+
+	return service.iebRepository.CreateRevision(ctx, &IEBRevision{
+		ID:           primitive.NewObjectID(),
+		IEBID:        ieb.ID,
+		Version:      ieb.Version,
+		Action:       action,
+		RestoredFrom: restoredFrom,
+		Information:  ieb.Information,
+		CreatedBy:    userID,
+		CreatedAt:    ieb.UpdatedAt,
+	})
+
+}
+
+// GetIEB returns the current IEB. With sharedOnly, as for parents, it returns the version
+// last shared with the family, or nothing if the book was never shared.
+func (service *iebService) GetIEB(ctx context.Context, userID string, termID string, languageKey string, regionKey string, sharedOnly bool) (*IEB, error) {
 
 	if termID == "" {
 		return nil, fmt.Errorf("term_id is required")
@@ -79,9 +290,626 @@ func (service *iebService) GetIEB(ctx context.Context, userID string, termID str
 
 	ieb, err := service.iebRepository.GetIEB(ctx, userID, termID, languageKey, regionKey)
 	if err != nil {
-		return nil, nil
+		return nil, err
+	}
+
+	if sharedOnly {
+
+		if ieb.SharedVersion == nil {
+			return nil, ErrIEBNotFound
+		}
+
+		revision, err := service.iebRepository.GetRevision(ctx, ieb.ID, *ieb.SharedVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		return sharedView(ieb, revision), nil
 	}
 
 	return ieb, nil
 	
 }
+
+func (service *iebService) GetIEBs(ctx context.Context, req *GetIEBsRequest) (*IEBListResponse, error) {
+
+	page, size, err := parsePage(req.Page, req.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := IEBFilter{
+		TermID:      req.TermID,
+		OwnerID:     req.OwnerID,
+		OwnerRole:   req.OwnerRole,
+		LanguageKey: req.LanguageKey,
+		RegionKey:   req.RegionKey,
+		Status:      req.Status,
+		Search:      strings.TrimSpace(req.Search),
+	}
+
+	if req.UpdatedFrom != "" {
+		from, err := time.Parse("2006-01-02", req.UpdatedFrom)
+		if err != nil {
+			return nil, fmt.Errorf("invalid updated_from")
+		}
+		filter.UpdatedFrom = &from
+	}
+
+	if req.UpdatedTo != "" {
+		to, err := time.Parse("2006-01-02", req.UpdatedTo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid updated_to")
+		}
+		to = to.AddDate(0, 0, 1)
+		filter.UpdatedTo = &to
+	}
+
+	iebs, total, err := service.iebRepository.GetIEBs(ctx, filter, page, size)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*IEBSummary, 0, len(iebs))
+	for _, ieb := range iebs {
+		titles := make([]string, 0, len(ieb.Information))
+		for _, section := range ieb.Information {
+			titles = append(titles, section.Tilte)
+		}
+		items = append(items, &IEBSummary{
+			ID:          ieb.ID,
+			Owner:       ieb.Owner,
+			TermID:      ieb.TermID,
+			LanguageKey: ieb.LanguageKey,
+			RegionKey:   ieb.RegionKey,
+			Status:      statusOf(ieb),
+			Version:     ieb.Version,
+			Sections:    titles,
+			Score:       ieb.Score,
+			UpdatedBy:   ieb.UpdatedBy,
+			UpdatedAt:   ieb.UpdatedAt,
+		})
+	}
+
+	return &IEBListResponse{
+		Items:      items,
+		Page:       page,
+		Size:       size,
+		Total:      total,
+		TotalPages: (total + int64(size) - 1) / int64(size),
+	}, nil
+
+}
+
+// ExportIEB renders the IEB in the requested language as a printable document.
+func (service *iebService) ExportIEB(ctx context.Context, req *ExportIEBRequest, sharedOnly bool) (*ExportFile, error) {
+
+	format := strings.ToLower(req.Format)
+	if format == "" {
+		format = ExportFormatPDF
+	}
+	if format != ExportFormatPDF && format != ExportFormatDOCX {
+		return nil, fmt.Errorf("format must be pdf or docx")
+	}
+
+	ieb, err := service.GetIEB(ctx, req.OwnerID, req.TermID, req.LanguageKey, req.RegionKey, sharedOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	termInfo, err := service.termService.GetTermByID(ctx, ieb.TermID)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := service.getOwner(ctx, ieb.Owner)
+	if err != nil {
+		return nil, err
+	}
+
+	// Books created before they recorded their school are exported without branding.
+	var branding Branding
+	if ieb.OrganizationID != "" {
+		org, err := service.organizationService.GetOrganization(ctx, ieb.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+		branding = Branding{
+			SchoolName: org.Name,
+			Color:      org.Color,
+			LogoURL:    org.LogoURL,
+		}
+	}
+
+	images := exportImages{
+		Avatar: fetchImage(ctx, owner.Avartar.ImageUrl),
+		Logo:   fetchImage(ctx, branding.LogoURL),
+	}
+
+	doc := buildExportDocument(ieb, owner, termInfo, branding, images)
+
+	file := &ExportFile{FileName: exportFileName(ieb, owner.UserName, format)}
+
+	switch format {
+	case ExportFormatDOCX:
+		file.ContentType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+		file.Data, err = renderDOCX(doc)
+	default:
+		file.ContentType = "application/pdf"
+		file.Data, err = renderPDF(doc)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+
+}
+
+func (service *iebService) getOwner(ctx context.Context, owner *Owner) (*user.UserInfor, error) {
+
+	switch owner.OwnerRole {
+	case constants.RoleStudent:
+		return service.userService.GetStudentInfor(ctx, owner.OwnerID)
+	case constants.RoleTeacher:
+		return service.userService.GetTeacherInfor(ctx, owner.OwnerID)
+	case constants.RoleStaff:
+		return service.userService.GetStaffInfor(ctx, owner.OwnerID)
+	default:
+		return service.userService.GetUserInfor(ctx, owner.OwnerID)
+	}
+
+}
+
+// CompareTerms lines up the child's IEBs of two terms, earlier term first whatever the
+// order the terms were given in.
+func (service *iebService) CompareTerms(ctx context.Context, ownerID string, fromTermID string, toTermID string, languageKey string, regionKey string, sharedOnly bool) (*TermComparisonResponse, error) {
+
+	if ownerID == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+
+	if fromTermID == "" || toTermID == "" {
+		return nil, fmt.Errorf("from_term_id and to_term_id are required")
+	}
+
+	fromTerm, err := service.termService.GetTermByID(ctx, fromTermID)
+	if err != nil {
+		return nil, err
+	}
+
+	toTerm, err := service.termService.GetTermByID(ctx, toTermID)
+	if err != nil {
+		return nil, err
+	}
+
+	if termStartsAfter(fromTerm, toTerm) {
+		fromTerm, toTerm = toTerm, fromTerm
+	}
+
+	from, fromIEB, err := service.termSide(ctx, ownerID, fromTerm, languageKey, regionKey, sharedOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	to, toIEB, err := service.termSide(ctx, ownerID, toTerm, languageKey, regionKey, sharedOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	if fromIEB == nil && toIEB == nil {
+		return nil, ErrIEBNotFound
+	}
+
+	var before, after []Information
+	if fromIEB != nil {
+		before = fromIEB.Information
+	}
+	if toIEB != nil {
+		after = toIEB.Information
+	}
+
+	return &TermComparisonResponse{
+		OwnerID:         ownerID,
+		LanguageKey:     languageKey,
+		RegionKey:       regionKey,
+		From:            from,
+		To:              to,
+		InformationDiff: diffInformationBy(before, after, looseKey),
+	}, nil
+
+}
+
+func (service *iebService) termSide(ctx context.Context, ownerID string, termInfo *term.TermInfor, languageKey string, regionKey string, sharedOnly bool) (*TermComparison, *IEB, error) {
+
+	side := &TermComparison{
+		TermID:    termInfo.ID,
+		StartDate: termInfo.StartDate,
+		EndDate:   termInfo.EndDate,
+	}
+
+	ieb, err := service.GetIEB(ctx, ownerID, termInfo.ID, languageKey, regionKey, sharedOnly)
+	if errors.Is(err, ErrIEBNotFound) {
+		return side, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	side.IEBID = &ieb.ID
+	side.Version = ieb.Version
+	side.UpdatedAt = &ieb.UpdatedAt
+
+	return side, ieb, nil
+
+}
+
+// termStartsAfter reports whether a starts after b. Terms with unreadable dates keep the
+// order they were given in.
+func termStartsAfter(a *term.TermInfor, b *term.TermInfor) bool {
+
+	aStart, err := time.Parse("2006-01-02", a.StartDate)
+	if err != nil {
+		return false
+	}
+
+	bStart, err := time.Parse("2006-01-02", b.StartDate)
+	if err != nil {
+		return false
+	}
+
+	return aStart.After(bStart)
+
+}
+
+func (service *iebService) GetTranslations(ctx context.Context, ownerID string, termID string, sharedOnly bool) (*TranslationsResponse, error) {
+
+	if ownerID == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+
+	if termID == "" {
+		return nil, fmt.Errorf("term_id is required")
+	}
+
+	siblings, err := service.iebRepository.GetSiblings(ctx, ownerID, termID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &TranslationsResponse{
+		OwnerID:      ownerID,
+		TermID:       termID,
+		Translations: buildTranslations(siblings, sharedOnly),
+	}
+
+	if source := findSource(siblings); source != nil {
+		response.SourceLanguageKey = source.LanguageKey
+		response.SourceRegionKey = source.RegionKey
+	}
+
+	return response, nil
+
+}
+
+func (service *iebService) TransitionIEB(ctx context.Context, id string, req *TransitionIEBRequest, userID string, roles []string) (*IEB, error) {
+
+	if req.Status == "" {
+		return nil, fmt.Errorf("status is required")
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := service.iebRepository.GetIEBByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := statusOf(current)
+	comment := strings.TrimSpace(req.Comment)
+
+	if req.Status == StatusParentAcknowledged {
+
+		if err := checkAcknowledgement(current, roles); err != nil {
+			return nil, err
+		}
+
+		acknowledgement := &Acknowledgement{
+			Version:        *current.SharedVersion,
+			Comment:        comment,
+			AcknowledgedBy: userID,
+			AcknowledgedAt: time.Now(),
+		}
+
+		return service.iebRepository.AcknowledgeIEB(ctx, objectID, acknowledgement)
+	}
+
+	if err := checkTransition(from, req.Status, roles, comment); err != nil {
+		return nil, err
+	}
+
+	transition := &StatusTransition{
+		From:      from,
+		To:        req.Status,
+		Comment:   comment,
+		ChangedBy: userID,
+		ChangedAt: time.Now(),
+	}
+
+	var sharedVersion *int
+	if req.Status == StatusSharedWithParent {
+
+		// Parents are served the shared version from its revision, which books saved
+		// before revisions were kept do not have yet.
+		if _, err := service.iebRepository.GetRevision(ctx, objectID, current.Version); err != nil {
+			if !errors.Is(err, ErrRevisionNotFound) {
+				return nil, err
+			}
+			if err := service.createRevision(ctx, current, RevisionActionShare, nil, userID); err != nil {
+				return nil, err
+			}
+		}
+
+		sharedVersion = &current.Version
+	}
+
+	return service.iebRepository.TransitionIEB(ctx, objectID, from, transition, sharedVersion)
+
+}
+
+func (service *iebService) AddSection(ctx context.Context, id string, req *AddSectionRequest, userID string) (*IEB, error) {
+
+	if strings.TrimSpace(req.Title) == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+
+	return service.editIEB(ctx, id, req.Version, userID, func(information []Information, now time.Time) ([]Information, error) {
+		information, _, err := addSection(information, req.Title, req.Position)
+		return information, err
+	})
+
+}
+
+func (service *iebService) UpdateSection(ctx context.Context, id string, sectionID string, req *UpdateSectionRequest, userID string) (*IEB, error) {
+
+	return service.editIEB(ctx, id, req.Version, userID, func(information []Information, now time.Time) ([]Information, error) {
+		index, err := findSection(information, sectionID)
+		if err != nil {
+			return nil, err
+		}
+		if req.Title != nil {
+			if strings.TrimSpace(*req.Title) == "" {
+				return nil, fmt.Errorf("title is required")
+			}
+			information[index].Tilte = *req.Title
+		}
+		return information, nil
+	})
+
+}
+
+func (service *iebService) RemoveSection(ctx context.Context, id string, sectionID string, version string, userID string) (*IEB, error) {
+
+	versionNumber, err := parseVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.editIEB(ctx, id, versionNumber, userID, func(information []Information, now time.Time) ([]Information, error) {
+		index, err := findSection(information, sectionID)
+		if err != nil {
+			return nil, err
+		}
+		return append(information[:index], information[index+1:]...), nil
+	})
+
+}
+
+func (service *iebService) ReorderSections(ctx context.Context, id string, req *ReorderSectionsRequest, userID string) (*IEB, error) {
+
+	return service.editIEB(ctx, id, req.Version, userID, func(information []Information, now time.Time) ([]Information, error) {
+		return reorderSections(information, req.SectionIDs)
+	})
+
+}
+
+func (service *iebService) AddContent(ctx context.Context, id string, sectionID string, req *AddContentRequest, userID string) (*IEB, error) {
+
+	if strings.TrimSpace(req.Label) == "" {
+		return nil, fmt.Errorf("label is required")
+	}
+
+	return service.editIEB(ctx, id, req.Version, userID, func(information []Information, now time.Time) ([]Information, error) {
+		index, err := findSection(information, sectionID)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := addContent(&information[index], req.Label, req.Content, req.Position, now); err != nil {
+			return nil, err
+		}
+		return information, nil
+	})
+
+}
+
+func (service *iebService) UpdateContent(ctx context.Context, id string, sectionID string, contentID string, req *UpdateContentRequest, userID string) (*IEB, error) {
+
+	return service.editIEB(ctx, id, req.Version, userID, func(information []Information, now time.Time) ([]Information, error) {
+		sectionIndex, err := findSection(information, sectionID)
+		if err != nil {
+			return nil, err
+		}
+		section := &information[sectionIndex]
+		contentIndex, err := findContent(section, contentID)
+		if err != nil {
+			return nil, err
+		}
+		content := &section.Contents[contentIndex]
+		changed := false
+		if req.Label != nil && *req.Label != content.Label {
+			if strings.TrimSpace(*req.Label) == "" {
+				return nil, fmt.Errorf("label is required")
+			}
+			content.Label = *req.Label
+			changed = true
+		}
+		if req.Content != nil && *req.Content != content.Content {
+			content.Content = *req.Content
+			changed = true
+		}
+		if changed {
+			content.UpdatedAt = now
+		}
+		return information, nil
+	})
+
+}
+
+func (service *iebService) RemoveContent(ctx context.Context, id string, sectionID string, contentID string, version string, userID string) (*IEB, error) {
+
+	versionNumber, err := parseVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.editIEB(ctx, id, versionNumber, userID, func(information []Information, now time.Time) ([]Information, error) {
+		sectionIndex, err := findSection(information, sectionID)
+		if err != nil {
+			return nil, err
+		}
+		section := &information[sectionIndex]
+		contentIndex, err := findContent(section, contentID)
+		if err != nil {
+			return nil, err
+		}
+		section.Contents = append(section.Contents[:contentIndex], section.Contents[contentIndex+1:]...)
+		return information, nil
+	})
+
+}
+
+func (service *iebService) ReorderContents(ctx context.Context, id string, sectionID string, req *ReorderContentsRequest, userID string) (*IEB, error) {
+
+	return service.editIEB(ctx, id, req.Version, userID, func(information []Information, now time.Time) ([]Information, error) {
+		index, err := findSection(information, sectionID)
+		if err != nil {
+			return nil, err
+		}
+		if err := reorderContents(&information[index], req.ContentIDs); err != nil {
+			return nil, err
+		}
+		return information, nil
+	})
+
+}
+
+// editIEB applies a change to the IEB content at the version the client read. The save
+// fails with ErrVersionConflict if another edit landed in between.
+func (service *iebService) editIEB(ctx context.Context, id string, version *int, userID string, change func(information []Information, now time.Time) ([]Information, error)) (*IEB, error) {
+
+	if version == nil {
+		return nil, fmt.Errorf("version is required")
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := service.iebRepository.GetIEBByID(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if current.Version != *version {
+		return nil, ErrVersionConflict
+	}
+
+	assignIDs(current.Information)
+
+	now := time.Now()
+
+	information, err := change(current.Information, now)
+	if err != nil {
+		return nil, err
+	}
+
+	ieb, err := service.iebRepository.UpdateIEBInformation(ctx, objectID, current.Version, information, userID, reopenTransition(current, userID, now))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := service.createRevision(ctx, ieb, RevisionActionEdit, nil, userID); err != nil {
+		return nil, err
+	}
+
+	return ieb, nil
+
+}
+
+// BackfillIDs gives IEBs saved before sections had IDs stable ones, so clients can
+// address them with the section endpoints. It runs once at startup rather than on read.
+// A book edited while it runs keeps the IDs its edit assigned.
+func (service *iebService) BackfillIDs(ctx context.Context) error {
+
+	iebs, err := service.iebRepository.GetIEBsMissingIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, ieb := range iebs {
+
+		if !assignIDs(ieb.Information) {
+			continue
+		}
+
+		if err := service.iebRepository.BackfillIDs(ctx, ieb.ID, ieb.Version, ieb.Information); err != nil {
+			return err
+		}
+	}
+
+	return nil
+
+}
+
+func parseVersion(version string) (*int, error) {
+
+	if version == "" {
+		return nil, nil
+	}
+
+	versionNumber, err := strconv.Atoi(version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version")
+	}
+
+	return &versionNumber, nil
+
+}
+
+func parsePage(page string, size string) (int, int, error) {
+
+	pageNumber, pageSize := 1, defaultPageSize
+
+	if page != "" {
+		value, err := strconv.Atoi(page)
+		if err != nil || value < 1 {
+			return 0, 0, fmt.Errorf("invalid page")
+		}
+		pageNumber = value
+	}
+
+	if size != "" {
+		value, err := strconv.Atoi(size)
+		if err != nil || value < 1 {
+			return 0, 0, fmt.Errorf("invalid size")
+		}
+		pageSize = min(value, maxPageSize)
+	}
+
+	return pageNumber, pageSize, nil
+
+}
@@ -0,0 +1,91 @@
+package ieb
+
+import "time"
+
+// findSource returns the source-language IEB among the siblings of one book: the one not
+// linked to another, earliest created first. Siblings must be sorted by creation time.
+func findSource(siblings []*IEB) *IEB {
+	for _, sibling := range siblings {
+		if sibling.SourceID == nil {
+			return sibling
+		}
+	}
+	if len(siblings) > 0 {
+		return siblings[0]
+	}
+	return nil
+}
+
+// buildTranslations lists the languages of one book. A translation is stale when the
+// source content was saved after the translation was last updated. With sharedOnly the
+// summaries describe the shared versions, so parents never see the state of a draft.
+func buildTranslations(siblings []*IEB, sharedOnly bool) []*TranslationSummary {
+
+	source := findSource(siblings)
+	translations := make([]*TranslationSummary, 0, len(siblings))
+
+	for _, sibling := range siblings {
+
+		if sharedOnly && sibling.SharedVersion == nil {
+			continue
+		}
+
+		summary := &TranslationSummary{
+			ID:          sibling.ID,
+			LanguageKey: sibling.LanguageKey,
+			RegionKey:   sibling.RegionKey,
+			IsSource:    sibling == source,
+			Status:      statusOf(sibling),
+			Version:     sibling.Version,
+			UpdatedBy:   sibling.UpdatedBy,
+			UpdatedAt:   sibling.UpdatedAt,
+		}
+
+		if sharedOnly {
+			sharedSummary(summary, sibling)
+		}
+
+		if !summary.IsSource {
+			if sharedOnly {
+				if source.SharedVersion != nil {
+					summary.SourceID = &source.ID
+					summary.Stale = sharedDate(source).After(sharedDate(sibling))
+				}
+			} else {
+				summary.SourceID = &source.ID
+				summary.Stale = source.UpdatedAt.After(sibling.UpdatedAt)
+			}
+		}
+
+		translations = append(translations, summary)
+	}
+
+	return translations
+}
+
+// sharedSummary replaces the live state of the summary with the version shared with
+// parents: who shared it and when.
+func sharedSummary(summary *TranslationSummary, ieb *IEB) {
+
+	summary.Status = StatusSharedWithParent
+	if isAcknowledged(ieb) {
+		summary.Status = StatusParentAcknowledged
+	}
+
+	summary.Version = *ieb.SharedVersion
+	summary.UpdatedAt = sharedDate(ieb)
+	summary.UpdatedBy = ""
+
+	for _, transition := range ieb.StatusHistory {
+		if transition.To == StatusSharedWithParent {
+			summary.UpdatedBy = transition.ChangedBy
+		}
+	}
+}
+
+func sharedDate(ieb *IEB) time.Time {
+	if ieb.SharedAt == nil {
+		return time.Time{}
+	}
+	return *ieb.SharedAt
+}
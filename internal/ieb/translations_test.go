@@ -0,0 +1,56 @@
+package ieb
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestBuildTranslationsSharedOnly(t *testing.T) {
+
+	sharedAt := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	editedAt := sharedAt.AddDate(0, 1, 0)
+
+	source := &IEB{
+		ID:            primitive.NewObjectID(),
+		LanguageKey:   "en",
+		Status:        StatusDraft,
+		Version:       5,
+		UpdatedBy:     "teacher",
+		UpdatedAt:     editedAt,
+		SharedVersion: version(3),
+		SharedAt:      &sharedAt,
+		StatusHistory: []StatusTransition{{From: StatusApproved, To: StatusSharedWithParent, ChangedBy: "coordinator", ChangedAt: sharedAt}},
+	}
+	translation := &IEB{
+		ID:                  primitive.NewObjectID(),
+		LanguageKey:         "vi",
+		SourceID:            &source.ID,
+		Status:              StatusInReview,
+		Version:             4,
+		UpdatedAt:           sharedAt,
+		SharedVersion:       version(2),
+		SharedAt:            &sharedAt,
+		AcknowledgedVersion: version(2),
+	}
+	unshared := &IEB{ID: primitive.NewObjectID(), LanguageKey: "fr", SourceID: &source.ID, Status: StatusDraft}
+
+	translations := buildTranslations([]*IEB{source, translation, unshared}, true)
+
+	if len(translations) != 2 {
+		t.Fatalf("got %d translations, want the 2 shared ones", len(translations))
+	}
+
+	if got := translations[0]; got.Status != StatusSharedWithParent || got.Version != 3 || got.UpdatedBy != "coordinator" || !got.UpdatedAt.Equal(sharedAt) {
+		t.Errorf("source summary = %+v, want the shared version", got)
+	}
+
+	if got := translations[1]; got.Status != StatusParentAcknowledged || got.Version != 2 || got.Stale {
+		t.Errorf("translation summary = %+v, want the acknowledged shared version, not stale", got)
+	}
+
+	if live := buildTranslations([]*IEB{source, translation, unshared}, false); len(live) != 3 || !live[1].Stale || live[0].Version != 5 {
+		t.Errorf("staff summaries should show the live versions")
+	}
+}
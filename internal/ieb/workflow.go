@@ -0,0 +1,136 @@
+package ieb
+
+import (
+	"fmt"
+	"time"
+
+	"portal/internal/middleware"
+	"portal/pkg/constants"
+)
+
+const (
+	StatusDraft              = "draft"
+	StatusInReview           = "in_review"
+	StatusApproved           = "approved"
+	StatusSharedWithParent   = "shared_with_parent"
+	StatusParentAcknowledged = "parent_acknowledged"
+)
+
+// transitions lists, for each status, the statuses it can move to and the roles allowed
+// to make that move.
+var transitions = map[string]map[string][]string{
+	StatusDraft: {
+		StatusInReview: {constants.RoleTeacher, constants.RoleCoordinator, constants.RoleAdmin},
+	},
+	StatusInReview: {
+		StatusApproved: {constants.RoleCoordinator, constants.RoleAdmin},
+		StatusDraft:    {constants.RoleCoordinator, constants.RoleAdmin},
+	},
+	StatusApproved: {
+		StatusSharedWithParent: {constants.RoleCoordinator, constants.RoleAdmin},
+		StatusDraft:            {constants.RoleCoordinator, constants.RoleAdmin},
+	},
+}
+
+// Parents acknowledge the version shared with them, which stays visible to them while
+// staff edit and review the next one, so acknowledging does not go through transitions.
+var acknowledgeRoles = []string{constants.RoleParent}
+
+// statusOf treats IEBs saved before the workflow existed as drafts.
+func statusOf(ieb *IEB) string {
+	if ieb.Status == "" {
+		return StatusDraft
+	}
+	return ieb.Status
+}
+
+// checkAcknowledgement reports whether the parent can acknowledge the shared version.
+func checkAcknowledgement(ieb *IEB, roles []string) error {
+
+	if !middleware.ContainsAnyRole(roles, acknowledgeRoles...) {
+		return fmt.Errorf("only parents can acknowledge an ieb")
+	}
+
+	if ieb.SharedVersion == nil {
+		return fmt.Errorf("ieb has not been shared with parents")
+	}
+
+	if isAcknowledged(ieb) {
+		return fmt.Errorf("shared version %d is already acknowledged", *ieb.SharedVersion)
+	}
+
+	return nil
+}
+
+// isAcknowledged reports whether the parent acknowledged the version now shared.
+func isAcknowledged(ieb *IEB) bool {
+
+	if ieb.SharedVersion == nil || ieb.AcknowledgedVersion == nil {
+		return false
+	}
+
+	return *ieb.AcknowledgedVersion == *ieb.SharedVersion
+}
+
+func checkTransition(from string, to string, roles []string, comment string) error {
+
+	allowed, ok := transitions[from][to]
+	if !ok {
+		return fmt.Errorf("cannot move ieb from %s to %s", from, to)
+	}
+
+	if !middleware.ContainsAnyRole(roles, allowed...) {
+		return fmt.Errorf("your role cannot move ieb from %s to %s", from, to)
+	}
+
+	// Sending a book back must say what needs changing.
+	if to == StatusDraft && comment == "" {
+		return fmt.Errorf("comment is required when returning ieb to draft")
+	}
+
+	return nil
+}
+
+// reopenTransition returns the transition recorded when the content of a reviewed IEB
+// is edited. The edit needs a new review, so the book goes back to draft; parents keep
+// seeing the version that was shared with them.
+func reopenTransition(ieb *IEB, userID string, now time.Time) *StatusTransition {
+
+	from := statusOf(ieb)
+	if from == StatusDraft {
+		return nil
+	}
+
+	return &StatusTransition{
+		From:      from,
+		To:        StatusDraft,
+		Comment:   "content edited",
+		ChangedBy: userID,
+		ChangedAt: now,
+	}
+}
+
+// sharedView turns the IEB into what a parent may see: the content of the shared
+// version and the sharing steps of the history only.
+func sharedView(ieb *IEB, revision *IEBRevision) *IEB {
+
+	view := *ieb
+	view.Information = revision.Information
+	view.Version = revision.Version
+	view.UpdatedBy = revision.CreatedBy
+	view.UpdatedAt = revision.CreatedAt
+
+	view.Status = StatusSharedWithParent
+	if isAcknowledged(ieb) {
+		view.Status = StatusParentAcknowledged
+	}
+
+	view.StatusHistory = make([]StatusTransition, 0)
+	for _, transition := range ieb.StatusHistory {
+		if transition.To == StatusSharedWithParent || transition.To == StatusParentAcknowledged {
+			view.StatusHistory = append(view.StatusHistory, transition)
+		}
+	}
+
+	return &view
+}
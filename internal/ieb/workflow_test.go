@@ -0,0 +1,130 @@
+package ieb
+
+import (
+	"testing"
+
+	"portal/pkg/constants"
+)
+
+func version(v int) *int {
+	return &v
+}
+
+func TestCheckTransition(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		roles   []string
+		comment string
+		wantErr bool
+	}{
+		{name: "teacher submits for review", from: StatusDraft, to: StatusInReview, roles: []string{constants.RoleTeacher}},
+		{name: "coordinator approves", from: StatusInReview, to: StatusApproved, roles: []string{constants.RoleCoordinator}},
+		{name: "teacher cannot approve", from: StatusInReview, to: StatusApproved, roles: []string{constants.RoleTeacher}, wantErr: true},
+		{name: "return to draft needs a comment", from: StatusInReview, to: StatusDraft, roles: []string{constants.RoleAdmin}, wantErr: true},
+		{name: "return to draft with comment", from: StatusInReview, to: StatusDraft, roles: []string{constants.RoleAdmin}, comment: "add goals"},
+		{name: "admin shares approved book", from: StatusApproved, to: StatusSharedWithParent, roles: []string{constants.RoleAdmin}},
+		{name: "draft cannot be shared", from: StatusDraft, to: StatusSharedWithParent, roles: []string{constants.RoleAdmin}, wantErr: true},
+		{name: "acknowledging is not a transition", from: StatusSharedWithParent, to: StatusParentAcknowledged, roles: []string{constants.RoleParent}, wantErr: true},
+		{name: "parent cannot submit", from: StatusDraft, to: StatusInReview, roles: []string{constants.RoleParent}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkTransition(tt.from, tt.to, tt.roles, tt.comment)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkTransition error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckAcknowledgement(t *testing.T) {
+
+	parent := []string{constants.RoleParent}
+
+	tests := []struct {
+		name    string
+		ieb     *IEB
+		roles   []string
+		wantErr bool
+	}{
+		{name: "parent acknowledges shared version", ieb: &IEB{Status: StatusSharedWithParent, SharedVersion: version(2)}, roles: parent},
+		{name: "edited after sharing", ieb: &IEB{Status: StatusDraft, SharedVersion: version(2)}, roles: parent},
+		{name: "new version shared after acknowledgement", ieb: &IEB{Status: StatusSharedWithParent, SharedVersion: version(3), AcknowledgedVersion: version(2)}, roles: parent},
+		{name: "already acknowledged", ieb: &IEB{Status: StatusDraft, SharedVersion: version(2), AcknowledgedVersion: version(2)}, roles: parent, wantErr: true},
+		{name: "never shared", ieb: &IEB{Status: StatusApproved}, roles: parent, wantErr: true},
+		{name: "staff cannot acknowledge", ieb: &IEB{Status: StatusSharedWithParent, SharedVersion: version(2)}, roles: []string{constants.RoleAdmin}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAcknowledgement(tt.ieb, tt.roles)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkAcknowledgement error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSharedView(t *testing.T) {
+
+	history := []StatusTransition{
+		{From: StatusDraft, To: StatusInReview},
+		{From: StatusInReview, To: StatusApproved},
+		{From: StatusApproved, To: StatusSharedWithParent},
+		{From: StatusSharedWithParent, To: StatusDraft, Comment: "content edited"},
+	}
+
+	revision := &IEBRevision{
+		Version:     2,
+		Information: []Information{{Tilte: "Goals"}},
+	}
+
+	tests := []struct {
+		name       string
+		ieb        *IEB
+		wantStatus string
+	}{
+		{
+			name:       "edited after sharing still shows shared",
+			ieb:        &IEB{Status: StatusDraft, Version: 3, SharedVersion: version(2), StatusHistory: history},
+			wantStatus: StatusSharedWithParent,
+		},
+		{
+			name:       "acknowledged version",
+			ieb:        &IEB{Status: StatusDraft, Version: 3, SharedVersion: version(2), AcknowledgedVersion: version(2), StatusHistory: history},
+			wantStatus: StatusParentAcknowledged,
+		},
+		{
+			name:       "older acknowledgement does not count",
+			ieb:        &IEB{Status: StatusSharedWithParent, Version: 2, SharedVersion: version(2), AcknowledgedVersion: version(1), StatusHistory: history},
+			wantStatus: StatusSharedWithParent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			status := tt.ieb.Status
+			view := sharedView(tt.ieb, revision)
+
+			if view.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", view.Status, tt.wantStatus)
+			}
+			if view.Version != revision.Version || len(view.Information) != 1 || view.Information[0].Tilte != "Goals" {
+				t.Errorf("view does not show the shared revision")
+			}
+			for _, transition := range view.StatusHistory {
+				if transition.To != StatusSharedWithParent && transition.To != StatusParentAcknowledged {
+					t.Errorf("view exposes the %s step", transition.To)
+				}
+			}
+			if tt.ieb.Status != status || len(tt.ieb.StatusHistory) != len(history) {
+				t.Errorf("sharedView changed the stored book")
+			}
+		})
+	}
+}
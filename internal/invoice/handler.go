@@ -0,0 +1,188 @@
+package invoice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"portal/helper"
+	"portal/internal/middleware"
+	"portal/pkg/constants"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InvoiceHandler struct {
+	InvoiceService InvoiceService
+}
+
+func NewInvoiceHandler(invoiceService InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{
+		InvoiceService: invoiceService,
+	}
+}
+
+func (handler *InvoiceHandler) GenerateInvoice(c *gin.Context) {
+
+	var req GenerateInvoiceRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	invoice, err := handler.InvoiceService.GenerateInvoice(ctx, &req, userID.(string))
+	if err != nil {
+		sendInvoiceError(c, err)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Generate invoice successfully", invoice)
+
+}
+
+func (handler *InvoiceHandler) GetInvoices(c *gin.Context) {
+
+	req := GetInvoicesRequest{
+		OrganizationID: c.Query("organization_id"),
+		StudentID:      c.Query("student_id"),
+		ParentID:       c.Query("parent_id"),
+		Month:          c.Query("month"),
+		Year:           c.Query("year"),
+		Status:         c.Query("status"),
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	invoices, err := handler.InvoiceService.GetInvoices(ctx, &req, parentScope(c, userID.(string)))
+	if err != nil {
+		sendInvoiceError(c, err)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Get invoices successfully", invoices)
+
+}
+
+func (handler *InvoiceHandler) GetInvoice(c *gin.Context) {
+
+	id := c.Param("id")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	invoice, err := handler.InvoiceService.GetInvoice(ctx, id, parentScope(c, userID.(string)))
+	if err != nil {
+		sendInvoiceError(c, err)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Get invoice successfully", invoice)
+
+}
+
+func (handler *InvoiceHandler) TransitionInvoice(c *gin.Context) {
+
+	id := c.Param("id")
+
+	var req TransitionInvoiceRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	invoice, err := handler.InvoiceService.TransitionInvoice(ctx, id, &req, userID.(string))
+	if err != nil {
+		sendInvoiceError(c, err)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Update invoice status successfully", invoice)
+
+}
+
+// parentView is true for parents, who only see their own invoices and never drafts.
+func parentView(c *gin.Context) bool {
+	return middleware.HasAnyRole(c, constants.RoleParent) && !middleware.HasAnyRole(c, constants.StaffRoles...)
+}
+
+// parentScope is the parent the caller may see the invoices of, or empty for staff.
+func parentScope(c *gin.Context, userID string) string {
+	if parentView(c) {
+		return userID
+	}
+	return ""
+}
+
+func sendInvoiceError(c *gin.Context, err error) {
+
+	if errors.Is(err, ErrInvoiceNotFound) {
+		helper.SendError(c, 404, err, helper.ErrNotFound)
+		return
+	}
+
+	if errors.Is(err, ErrInvoiceExists) {
+		helper.SendError(c, 409, err, helper.ErrAlreadyExists)
+		return
+	}
+
+	if errors.Is(err, ErrStatusConflict) {
+		helper.SendError(c, 409, err, helper.ErrVersionConflict)
+		return
+	}
+
+	helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+
+}
@@ -0,0 +1,68 @@
+package invoice
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Invoice struct {
+	ID               primitive.ObjectID `json:"id" bson:"_id"`
+	Number           string             `json:"number,omitempty" bson:"number,omitempty"` // assigned when issued
+	Sequence         int64              `json:"-" bson:"sequence,omitempty"`
+	OrganizationID   string             `json:"organization_id" bson:"organization_id"`
+	StudentID        string             `json:"student_id" bson:"student_id"`
+	ParentID         string             `json:"parent_id" bson:"parent_id"`
+	Month            int                `json:"month" bson:"month"`
+	Year             int                `json:"year" bson:"year"`
+	Status           string             `json:"status" bson:"status"`
+	LineItems        []LineItem         `json:"line_items" bson:"line_items"`
+	Subtotal         float64            `json:"subtotal" bson:"subtotal"`
+	Credits          float64            `json:"credits" bson:"credits"`
+	Total            float64            `json:"total" bson:"total"`
+	Sources          Sources            `json:"sources" bson:"sources"`
+	StatusHistory    []StatusChange     `json:"status_history" bson:"status_history"`
+	PaymentReference string             `json:"payment_reference,omitempty" bson:"payment_reference,omitempty"`
+	IssuedAt         *time.Time         `json:"issued_at,omitempty" bson:"issued_at,omitempty"`
+	PaidAt           *time.Time         `json:"paid_at,omitempty" bson:"paid_at,omitempty"`
+	VoidedAt         *time.Time         `json:"voided_at,omitempty" bson:"voided_at,omitempty"`
+	CreatedBy        string             `json:"created_by" bson:"created_by"`
+	CreatedAt        time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedBy        string             `json:"updated_by" bson:"updated_by"`
+	UpdatedAt        time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// LineItem is one charge or credit on the statement. Credits have a negative amount.
+type LineItem struct {
+	Type        string     `json:"type" bson:"type"`
+	Description string     `json:"description" bson:"description"`
+	SourceID    string     `json:"source_id,omitempty" bson:"source_id,omitempty"`
+	WeekNumber  int        `json:"week_number,omitempty" bson:"week_number,omitempty"`
+	Date        *time.Time `json:"date,omitempty" bson:"date,omitempty"`
+	Amount      float64    `json:"amount" bson:"amount"`
+}
+
+// Sources are the records the statement was built from.
+type Sources struct {
+	ProgramPlanerID     string `json:"program_planer_id,omitempty" bson:"program_planer_id,omitempty"`
+	TeacherAssignmentID string `json:"teacher_assignment_id,omitempty" bson:"teacher_assignment_id,omitempty"`
+	StudyProgramID      string `json:"study_program_id,omitempty" bson:"study_program_id,omitempty"`
+}
+
+type StatusChange struct {
+	From      string    `json:"from" bson:"from"`
+	To        string    `json:"to" bson:"to"`
+	Comment   string    `json:"comment,omitempty" bson:"comment,omitempty"`
+	ChangedBy string    `json:"changed_by" bson:"changed_by"`
+	ChangedAt time.Time `json:"changed_at" bson:"changed_at"`
+}
+
+type InvoiceFilter struct {
+	OrganizationID string
+	StudentID      string
+	ParentID       string
+	Month          int
+	Year           int
+	Status         string
+	ExcludeDrafts  bool
+}
@@ -0,0 +1,290 @@
+package invoice
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type InvoiceRepository interface {
+	EnsureIndexes(ctx context.Context) error
+	CreateInvoice(ctx context.Context, invoice *Invoice) error
+	UpdateDraft(ctx context.Context, invoice *Invoice) error
+	GetInvoice(ctx context.Context, id primitive.ObjectID) (*Invoice, error)
+	GetOpenInvoice(ctx context.Context, organizationID string, studentID string, month int, year int) (*Invoice, error)
+	GetInvoices(ctx context.Context, filter InvoiceFilter) ([]*Invoice, error)
+	NextSequence(ctx context.Context, organizationID string) (int64, error)
+	TransitionInvoice(ctx context.Context, id primitive.ObjectID, from string, change *StatusChange, set bson.M) (*Invoice, error)
+	ReleaseSequence(ctx context.Context, organizationID string, sequence int64) error
+}
+
+type invoiceRepository struct {
+	invoiceCollection *mongo.Collection
+	counterCollection *mongo.Collection
+}
+
+func NewInvoiceRepository(collection *mongo.Collection, counterCollection *mongo.Collection) InvoiceRepository {
+	return &invoiceRepository{
+		invoiceCollection: collection,
+		counterCollection: counterCollection,
+	}
+}
+
+// EnsureIndexes keeps one open invoice per student and month, so concurrent generation
+// cannot leave two drafts, and bills each teacher assignment and study program on one open
+// invoice only. Voided invoices do not count; the month can be invoiced again.
+func (repository *invoiceRepository) EnsureIndexes(ctx context.Context) error {
+
+	open := bson.M{"$in": []string{StatusDraft, StatusIssued, StatusPaid}}
+
+	_, err := repository.invoiceCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "organization_id", Value: 1},
+				{Key: "student_id", Value: 1},
+				{Key: "year", Value: 1},
+				{Key: "month", Value: 1},
+			},
+			Options: options.Index().
+				SetName("invoice_open_month").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": open}),
+		},
+		{
+			Keys: bson.D{{Key: "sources.teacher_assignment_id", Value: 1}},
+			Options: options.Index().
+				SetName("invoice_open_teacher_assignment").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": open, "sources.teacher_assignment_id": bson.M{"$exists": true}}),
+		},
+		{
+			Keys: bson.D{{Key: "sources.study_program_id", Value: 1}},
+			Options: options.Index().
+				SetName("invoice_open_study_program").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": open, "sources.study_program_id": bson.M{"$exists": true}}),
+		},
+	})
+
+	return err
+
+}
+
+func (repository *invoiceRepository) CreateInvoice(ctx context.Context, invoice *Invoice) error {
+
+	_, err := repository.invoiceCollection.InsertOne(ctx, invoice)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrInvoiceExists
+	}
+	return err
+
+}
+
+// UpdateDraft replaces the statement of an invoice that has not been issued yet.
+func (repository *invoiceRepository) UpdateDraft(ctx context.Context, invoice *Invoice) error {
+
+	filter := bson.M{
+		"_id":    invoice.ID,
+		"status": StatusDraft,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"parent_id":  invoice.ParentID,
+			"line_items": invoice.LineItems,
+			"subtotal":   invoice.Subtotal,
+			"credits":    invoice.Credits,
+			"total":      invoice.Total,
+			"sources":    invoice.Sources,
+			"updated_by": invoice.UpdatedBy,
+			"updated_at": invoice.UpdatedAt,
+		},
+	}
+
+	result, err := repository.invoiceCollection.UpdateOne(ctx, filter, update)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrInvoiceExists
+	}
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrStatusConflict
+	}
+
+	return nil
+
+}
+
+func (repository *invoiceRepository) GetInvoice(ctx context.Context, id primitive.ObjectID) (*Invoice, error) {
+
+	var invoice Invoice
+
+	err := repository.invoiceCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&invoice)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrInvoiceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &invoice, nil
+
+}
+
+// GetOpenInvoice returns the student's invoice for the month that has not been voided, or nil.
+func (repository *invoiceRepository) GetOpenInvoice(ctx context.Context, organizationID string, studentID string, month int, year int) (*Invoice, error) {
+
+	filter := bson.M{
+		"organization_id": organizationID,
+		"student_id":      studentID,
+		"month":           month,
+		"year":            year,
+		"status":          bson.M{"$ne": StatusVoid},
+	}
+
+	var invoice Invoice
+
+	err := repository.invoiceCollection.FindOne(ctx, filter).Decode(&invoice)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &invoice, nil
+
+}
+
+func (repository *invoiceRepository) GetInvoices(ctx context.Context, filter InvoiceFilter) ([]*Invoice, error) {
+
+	query := bson.M{}
+
+	if filter.OrganizationID != "" {
+		query["organization_id"] = filter.OrganizationID
+	}
+	if filter.StudentID != "" {
+		query["student_id"] = filter.StudentID
+	}
+	if filter.ParentID != "" {
+		query["parent_id"] = filter.ParentID
+	}
+	if filter.Month != 0 {
+		query["month"] = filter.Month
+	}
+	if filter.Year != 0 {
+		query["year"] = filter.Year
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if filter.ExcludeDrafts {
+		if filter.Status == StatusDraft {
+			return []*Invoice{}, nil
+		}
+		if filter.Status == "" {
+			query["status"] = bson.M{"$ne": StatusDraft}
+		}
+	}
+
+	findOpts := options.Find().SetSort(bson.D{{Key: "year", Value: -1}, {Key: "month", Value: -1}, {Key: "created_at", Value: -1}})
+
+	cursor, err := repository.invoiceCollection.Find(ctx, query, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	invoices := []*Invoice{}
+	if err := cursor.All(ctx, &invoices); err != nil {
+		return nil, err
+	}
+
+	return invoices, nil
+
+}
+
+// NextSequence hands out the organization's next invoice number. Numbers given back by
+// ReleaseSequence are handed out again first, lowest first, so the numbering has no gaps.
+func (repository *invoiceRepository) NextSequence(ctx context.Context, organizationID string) (int64, error) {
+
+	var counter struct {
+		Sequence int64   `bson:"sequence"`
+		Released []int64 `bson:"released"`
+	}
+
+	filter := bson.M{"_id": organizationID, "released.0": bson.M{"$exists": true}}
+	reuse := bson.M{
+		"$pop": bson.M{"released": -1},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+
+	err := repository.counterCollection.FindOneAndUpdate(ctx, filter, reuse).Decode(&counter)
+	if err == nil {
+		return counter.Released[0], nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return 0, err
+	}
+
+	update := bson.M{
+		"$inc": bson.M{"sequence": int64(1)},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	if err := repository.counterCollection.FindOneAndUpdate(ctx, bson.M{"_id": organizationID}, update, opts).Decode(&counter); err != nil {
+		return 0, err
+	}
+
+	return counter.Sequence, nil
+
+}
+
+// ReleaseSequence gives back a number that did not end up on an invoice.
+func (repository *invoiceRepository) ReleaseSequence(ctx context.Context, organizationID string, sequence int64) error {
+
+	update := bson.M{
+		"$push": bson.M{"released": bson.M{"$each": bson.A{sequence}, "$sort": 1}},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+
+	_, err := repository.counterCollection.UpdateOne(ctx, bson.M{"_id": organizationID}, update)
+	return err
+
+}
+
+// TransitionInvoice moves the invoice to a new status if it is still in the from status.
+func (repository *invoiceRepository) TransitionInvoice(ctx context.Context, id primitive.ObjectID, from string, change *StatusChange, set bson.M) (*Invoice, error) {
+
+	filter := bson.M{"_id": id, "status": from}
+
+	set["status"] = change.To
+	set["updated_by"] = change.ChangedBy
+	set["updated_at"] = change.ChangedAt
+
+	update := bson.M{
+		"$set":  set,
+		"$push": bson.M{"status_history": change},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var invoice Invoice
+	err := repository.invoiceCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&invoice)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrStatusConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &invoice, nil
+
+}
@@ -0,0 +1,23 @@
+package invoice
+
+type GenerateInvoiceRequest struct {
+	OrganizationID string `json:"organization_id" bson:"organization_id"`
+	StudentID      string `json:"student_id" bson:"student_id"`
+	Month          int    `json:"month" bson:"month"`
+	Year           int    `json:"year" bson:"year"`
+}
+
+type TransitionInvoiceRequest struct {
+	Status           string `json:"status" binding:"required"`
+	Comment          string `json:"comment"`
+	PaymentReference string `json:"payment_reference"`
+}
+
+type GetInvoicesRequest struct {
+	OrganizationID string
+	StudentID      string
+	ParentID       string
+	Month          string
+	Year           string
+	Status         string
+}
@@ -0,0 +1,20 @@
+package invoice
+
+import (
+	"portal/internal/middleware"
+	"portal/pkg/constants"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, handler *InvoiceHandler) {
+	group := r.Group("/api/v1/invoices", middleware.Secured())
+	{
+		// Parents read their own invoices; other roles have no business with billing.
+		readers := append([]string{constants.RoleParent}, constants.StaffRoles...)
+		group.GET("", middleware.RequireRoles(readers...), handler.GetInvoices)
+		group.GET("/:id", middleware.RequireRoles(readers...), handler.GetInvoice)
+		group.POST("", middleware.RequireRoles(constants.StaffRoles...), handler.GenerateInvoice)
+		group.POST("/:id/transition", middleware.RequireRoles(constants.StaffRoles...), handler.TransitionInvoice)
+	}
+}
@@ -0,0 +1,294 @@
+package invoice
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"portal/internal/program_planner"
+	studyprogram "portal/internal/study_program"
+	teacherassign "portal/internal/teacher_assign"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type InvoiceService interface {
+	GenerateInvoice(ctx context.Context, req *GenerateInvoiceRequest, userID string) (*Invoice, error)
+	GetInvoices(ctx context.Context, req *GetInvoicesRequest, parentID string) ([]*Invoice, error)
+	GetInvoice(ctx context.Context, id string, parentID string) (*Invoice, error)
+	TransitionInvoice(ctx context.Context, id string, req *TransitionInvoiceRequest, userID string) (*Invoice, error)
+}
+
+type invoiceService struct {
+	InvoiceRepository        InvoiceRepository
+	ProgramPlanerService     program_planner.ProgramPlanerService
+	TeacherAssignmentService teacherassign.TeacherAssignmentService
+	StudyProgramService      studyprogram.StudyProgramService
+}
+
+func NewInvoiceService(
+	repository InvoiceRepository,
+	programPlanerService program_planner.ProgramPlanerService,
+	teacherAssignmentService teacherassign.TeacherAssignmentService,
+	studyProgramService studyprogram.StudyProgramService,
+) InvoiceService {
+	return &invoiceService{
+		InvoiceRepository:        repository,
+		ProgramPlanerService:     programPlanerService,
+		TeacherAssignmentService: teacherAssignmentService,
+		StudyProgramService:      studyProgramService,
+	}
+}
+
+// GenerateInvoice builds the student's statement for the month. A draft for the month is
+// rebuilt in place; an issued or paid invoice has to be voided first.
+func (service *invoiceService) GenerateInvoice(ctx context.Context, req *GenerateInvoiceRequest, userID string) (*Invoice, error) {
+
+	if req.OrganizationID == "" {
+		return nil, fmt.Errorf("organization_id is required")
+	}
+	if req.StudentID == "" {
+		return nil, fmt.Errorf("student_id is required")
+	}
+	if req.Month < 1 || req.Month > 12 {
+		return nil, fmt.Errorf("month must be between 1 and 12")
+	}
+	if req.Year == 0 {
+		return nil, fmt.Errorf("year is required")
+	}
+
+	existing, err := service.InvoiceRepository.GetOpenInvoice(ctx, req.OrganizationID, req.StudentID, req.Month, req.Year)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.Status != StatusDraft {
+		return nil, fmt.Errorf("invoice %s is already %s for this month, void it first", existing.Number, existing.Status)
+	}
+
+	invoice := existing
+	if invoice == nil {
+		invoice = &Invoice{
+			ID:             primitive.NewObjectID(),
+			OrganizationID: req.OrganizationID,
+			StudentID:      req.StudentID,
+			Month:          req.Month,
+			Year:           req.Year,
+			Status:         StatusDraft,
+			StatusHistory:  []StatusChange{},
+			CreatedBy:      userID,
+			CreatedAt:      time.Now(),
+		}
+	}
+
+	if err := service.buildStatement(ctx, invoice); err != nil {
+		return nil, err
+	}
+
+	invoice.UpdatedBy = userID
+	invoice.UpdatedAt = time.Now()
+
+	if existing != nil {
+		if err := service.InvoiceRepository.UpdateDraft(ctx, invoice); err != nil {
+			return nil, err
+		}
+		return invoice, nil
+	}
+
+	if err := service.InvoiceRepository.CreateInvoice(ctx, invoice); err != nil {
+		return nil, err
+	}
+
+	return invoice, nil
+}
+
+// buildStatement collects the month's planner, teacher assignment and study program of the
+// student into line items and totals. The teacher assignment and study program are left out
+// when another organization's invoice already bills them.
+func (service *invoiceService) buildStatement(ctx context.Context, invoice *Invoice) error {
+
+	planner, err := service.ProgramPlanerService.GetProgramPlanerForMonth(ctx, invoice.OrganizationID, invoice.StudentID, invoice.Month, invoice.Year)
+	if err != nil {
+		return err
+	}
+
+	assignment, err := service.TeacherAssignmentService.GetTeacherAssignmentForMonth(ctx, invoice.StudentID, invoice.Month, invoice.Year)
+	if err != nil {
+		return err
+	}
+
+	program, err := service.StudyProgramService.GetStudyProgramForMonth(ctx, invoice.StudentID, invoice.Month, invoice.Year)
+	if err != nil {
+		return err
+	}
+
+	others, err := service.InvoiceRepository.GetInvoices(ctx, InvoiceFilter{StudentID: invoice.StudentID, Month: invoice.Month, Year: invoice.Year})
+	if err != nil {
+		return err
+	}
+
+	claimed := claimedSources(others, invoice)
+	if assignment != nil && claimed[assignment.ID.Hex()] {
+		assignment = nil
+	}
+	if program != nil && claimed[program.ID.Hex()] {
+		program = nil
+	}
+
+	if planner == nil && assignment == nil && program == nil {
+		return fmt.Errorf("nothing to invoice for student %s in %d-%02d", invoice.StudentID, invoice.Year, invoice.Month)
+	}
+
+	items := []LineItem{}
+	invoice.Sources = Sources{}
+
+	if planner != nil {
+		items = append(items, plannerItems(planner)...)
+		invoice.Sources.ProgramPlanerID = planner.ID.Hex()
+	}
+
+	if assignment != nil {
+		items = append(items, teacherAssignmentItem(assignment))
+		invoice.Sources.TeacherAssignmentID = assignment.ID.Hex()
+		invoice.ParentID = assignment.ParentID
+	}
+
+	if program != nil {
+		items = append(items, studyProgramItem(program))
+		invoice.Sources.StudyProgramID = program.ID.Hex()
+		if invoice.ParentID == "" {
+			invoice.ParentID = program.ParentID
+		}
+	}
+
+	invoice.LineItems = items
+	invoice.Subtotal, invoice.Credits, invoice.Total = totals(items)
+
+	return nil
+}
+
+// GetInvoices lists invoices. Parents, passed as parentID, only get their own issued ones.
+func (service *invoiceService) GetInvoices(ctx context.Context, req *GetInvoicesRequest, parentID string) ([]*Invoice, error) {
+
+	filter := InvoiceFilter{
+		OrganizationID: req.OrganizationID,
+		StudentID:      req.StudentID,
+		ParentID:       req.ParentID,
+		Status:         req.Status,
+	}
+
+	if parentID != "" {
+		filter.ParentID = parentID
+		filter.ExcludeDrafts = true
+	}
+
+	if req.Month != "" {
+		month, err := strconv.Atoi(req.Month)
+		if err != nil || month < 1 || month > 12 {
+			return nil, fmt.Errorf("month must be between 1 and 12")
+		}
+		filter.Month = month
+	}
+
+	if req.Year != "" {
+		year, err := strconv.Atoi(req.Year)
+		if err != nil {
+			return nil, fmt.Errorf("invalid year")
+		}
+		filter.Year = year
+	}
+
+	switch req.Status {
+	case "", StatusDraft, StatusIssued, StatusPaid, StatusVoid:
+	default:
+		return nil, fmt.Errorf("invalid status %q", req.Status)
+	}
+
+	return service.InvoiceRepository.GetInvoices(ctx, filter)
+}
+
+// GetInvoice hides drafts and other families' invoices from parents, passed as parentID,
+// who only see their own invoices once they are issued.
+func (service *invoiceService) GetInvoice(ctx context.Context, id string, parentID string) (*Invoice, error) {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice, err := service.InvoiceRepository.GetInvoice(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if parentID != "" && (invoice.ParentID != parentID || invoice.Status == StatusDraft) {
+		return nil, ErrInvoiceNotFound
+	}
+
+	return invoice, nil
+}
+
+// TransitionInvoice moves the invoice along draft, issued, paid or void. Issuing assigns the
+// organization's next invoice number in the same write, so an issued invoice always has
+// one; a number drawn for a write that fails is given back.
+func (service *invoiceService) TransitionInvoice(ctx context.Context, id string, req *TransitionInvoiceRequest, userID string) (*Invoice, error) {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice, err := service.InvoiceRepository.GetInvoice(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkTransition(invoice.Status, req); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	set := bson.M{}
+
+	var sequence int64
+
+	switch req.Status {
+	case StatusIssued:
+		sequence, err = service.InvoiceRepository.NextSequence(ctx, invoice.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+		set["issued_at"] = now
+		set["sequence"] = sequence
+		set["number"] = formatNumber(sequence)
+	case StatusPaid:
+		set["paid_at"] = now
+		if req.PaymentReference != "" {
+			set["payment_reference"] = req.PaymentReference
+		}
+	case StatusVoid:
+		set["voided_at"] = now
+	}
+
+	change := &StatusChange{
+		From:      invoice.Status,
+		To:        req.Status,
+		Comment:   req.Comment,
+		ChangedBy: userID,
+		ChangedAt: now,
+	}
+
+	updated, err := service.InvoiceRepository.TransitionInvoice(ctx, objectID, invoice.Status, change, set)
+	if err != nil {
+		if sequence != 0 {
+			if releaseErr := service.InvoiceRepository.ReleaseSequence(ctx, invoice.OrganizationID, sequence); releaseErr != nil {
+				log.Printf("[invoiceService] could not give back invoice number %s: %v", formatNumber(sequence), releaseErr)
+			}
+		}
+		return nil, err
+	}
+
+	return updated, nil
+}
@@ -0,0 +1,212 @@
+package invoice
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"portal/internal/program_planner"
+	studyprogram "portal/internal/study_program"
+	teacherassign "portal/internal/teacher_assign"
+)
+
+const (
+	StatusDraft  = "draft"
+	StatusIssued = "issued"
+	StatusPaid   = "paid"
+	StatusVoid   = "void"
+)
+
+const (
+	ItemProgramPlaner     = "program_planner"
+	ItemExtraSlot         = "extra_slot"
+	ItemRemovedSlot       = "removed_slot"
+	ItemWaitlistedSlot    = "waitlisted_slot"
+	ItemClosure           = "closure"
+	ItemTeacherAssignment = "teacher_assignment"
+	ItemStudyProgram      = "study_program"
+)
+
+var (
+	ErrInvoiceNotFound = errors.New("invoice not found")
+
+	// ErrInvoiceExists is returned when another invoice for the month, or one billing the same
+	// teacher assignment or study program, was created meanwhile.
+	ErrInvoiceExists = errors.New("an invoice for this month already exists, please reload")
+
+	// ErrStatusConflict is returned when the invoice changed status while it was being updated.
+	ErrStatusConflict = errors.New("invoice was modified by someone else, please reload")
+)
+
+// claimedSources lists the teacher assignments and study programs billed on the student's
+// other open invoices of the month. They are not tied to an organization, so only the
+// first organization to invoice them bills them.
+func claimedSources(invoices []*Invoice, invoice *Invoice) map[string]bool {
+
+	claimed := map[string]bool{}
+
+	for _, other := range invoices {
+		if other.ID == invoice.ID || other.Status == StatusVoid {
+			continue
+		}
+		if other.Sources.TeacherAssignmentID != "" {
+			claimed[other.Sources.TeacherAssignmentID] = true
+		}
+		if other.Sources.StudyProgramID != "" {
+			claimed[other.Sources.StudyProgramID] = true
+		}
+	}
+
+	return claimed
+}
+
+// transitions lists the statuses each status can move to. Paid and void invoices are final;
+// a voided month can be invoiced again.
+var transitions = map[string][]string{
+	StatusDraft:  {StatusIssued, StatusVoid},
+	StatusIssued: {StatusPaid, StatusVoid},
+}
+
+func checkTransition(from string, req *TransitionInvoiceRequest) error {
+
+	allowed := false
+	for _, to := range transitions[from] {
+		if to == req.Status {
+			allowed = true
+		}
+	}
+
+	if !allowed {
+		return fmt.Errorf("cannot move invoice from %s to %s", from, req.Status)
+	}
+
+	if from == StatusIssued && req.Status == StatusVoid && strings.TrimSpace(req.Comment) == "" {
+		return fmt.Errorf("comment is required when voiding an issued invoice")
+	}
+
+	return nil
+}
+
+func formatNumber(sequence int64) string {
+	return fmt.Sprintf("INV-%06d", sequence)
+}
+
+// plannerItems turns the planner into line items: the monthly selections, then every
+// week-level change made by UpdateProgramPlanerWeek or a closure.
+func plannerItems(program *program_planner.ProgramPlaner) []LineItem {
+
+	items := []LineItem{}
+	sourceID := program.ID.Hex()
+
+	for _, selection := range program.SelectedSlots {
+		if !selection.Selected {
+			continue
+		}
+		items = append(items, LineItem{
+			Type:        ItemProgramPlaner,
+			Description: fmt.Sprintf("%s (%s)", selection.TimeRange, strings.Join(selection.Days, ", ")),
+			SourceID:    sourceID,
+			Amount:      roundAmount(selection.Fee),
+		})
+	}
+
+	for _, week := range program.Weeks {
+
+		for _, slot := range week.Slots {
+
+			if slot.Fee == 0 {
+				continue
+			}
+
+			item := LineItem{
+				SourceID:   sourceID,
+				WeekNumber: week.WeekNumber,
+				Amount:     roundAmount(slot.Fee),
+			}
+
+			if slot.Waitlisted {
+				item.Type = ItemWaitlistedSlot
+				item.Description = fmt.Sprintf("Waitlisted slot %s %s, week %d", slot.DayOfWeek, slot.Time, week.WeekNumber)
+			} else if slot.IsOriginal {
+				item.Type = ItemRemovedSlot
+				item.Description = fmt.Sprintf("Removed slot %s %s, week %d", slot.DayOfWeek, slot.Time, week.WeekNumber)
+			} else {
+				item.Type = ItemExtraSlot
+				item.Description = fmt.Sprintf("Extra slot %s %s, week %d", slot.DayOfWeek, slot.Time, week.WeekNumber)
+			}
+
+			items = append(items, item)
+		}
+
+		for _, closedDay := range week.ClosedDays {
+
+			if closedDay.Credit == 0 {
+				continue
+			}
+
+			date := closedDay.Date
+			items = append(items, LineItem{
+				Type:        ItemClosure,
+				Description: fmt.Sprintf("Closed: %s (%s)", closedDay.Name, date.Format("2006-01-02")),
+				SourceID:    sourceID,
+				WeekNumber:  week.WeekNumber,
+				Date:        &date,
+				Amount:      roundAmount(-closedDay.Credit),
+			})
+		}
+	}
+
+	return items
+}
+
+func teacherAssignmentItem(assignment *teacherassign.TeacherAssignment) LineItem {
+
+	description := "Teacher assignment"
+	if assignment.Language != nil && assignment.Language.Label != "" {
+		description += " (" + assignment.Language.Label + ")"
+	}
+
+	return LineItem{
+		Type:        ItemTeacherAssignment,
+		Description: description,
+		SourceID:    assignment.ID.Hex(),
+		Amount:      roundAmount(assignment.MonthlyFee),
+	}
+}
+
+func studyProgramItem(program *studyprogram.StudyProgram) LineItem {
+
+	description := "Study program"
+	if program.TimeSlot != nil && program.TimeSlot.Label != "" {
+		description += " (" + program.TimeSlot.Label + ")"
+	}
+
+	return LineItem{
+		Type:        ItemStudyProgram,
+		Description: description,
+		SourceID:    program.ID.Hex(),
+		Amount:      roundAmount(program.MonthlyTotal),
+	}
+}
+
+// totals splits the line items into charges and credits.
+func totals(items []LineItem) (subtotal float64, credits float64, total float64) {
+
+	for _, item := range items {
+		if item.Amount >= 0 {
+			subtotal += item.Amount
+		} else {
+			credits -= item.Amount
+		}
+	}
+
+	subtotal = roundAmount(subtotal)
+	credits = roundAmount(credits)
+
+	return subtotal, credits, roundAmount(subtotal - credits)
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,220 @@
+package invoice
+
+import (
+	"testing"
+	"time"
+
+	"portal/internal/program_planner"
+	studyprogram "portal/internal/study_program"
+	teacherassign "portal/internal/teacher_assign"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPlannerItems(t *testing.T) {
+
+	closed := time.Date(2026, 6, 8, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		planner *program_planner.ProgramPlaner
+		types   []string
+		total   float64
+	}{
+		{
+			name: "monthly selections only",
+			planner: &program_planner.ProgramPlaner{
+				SelectedSlots: []program_planner.SelectedSlot{
+					{TimeRange: "8:00 M-F", Days: []string{"mo", "we"}, Selected: true, Fee: 100},
+					{TimeRange: "17:00 M-F", Days: []string{"fr"}, Selected: false, Fee: 40},
+				},
+			},
+			types: []string{ItemProgramPlaner},
+			total: 100,
+		},
+		{
+			name: "week changes and closures",
+			planner: &program_planner.ProgramPlaner{
+				SelectedSlots: []program_planner.SelectedSlot{
+					{TimeRange: "8:00 M-F", Days: []string{"mo"}, Selected: true, Fee: 100},
+				},
+				Weeks: []program_planner.WeekPlan{
+					{
+						WeekNumber: 23,
+						Slots: []program_planner.DailySlot{
+							{DayOfWeek: "mo", Time: "8:00", Selected: true, IsOriginal: true},
+							{DayOfWeek: "tu", Time: "8:00", Selected: true, Fee: 15},
+						},
+					},
+					{
+						WeekNumber: 24,
+						Slots: []program_planner.DailySlot{
+							{DayOfWeek: "we", Time: "11:00", Waitlisted: true, Fee: -12.5},
+						},
+						ClosedDays: []program_planner.ClosedDay{
+							{Date: closed, Name: "Holiday", Credit: 20},
+						},
+					},
+					{
+						WeekNumber: 25,
+						Slots: []program_planner.DailySlot{
+							{DayOfWeek: "mo", Time: "8:00", Selected: false, IsOriginal: true, Fee: -20},
+						},
+					},
+				},
+			},
+			types: []string{ItemProgramPlaner, ItemExtraSlot, ItemWaitlistedSlot, ItemClosure, ItemRemovedSlot},
+			total: 62.5,
+		},
+		{
+			name: "closed days without sessions are left out",
+			planner: &program_planner.ProgramPlaner{
+				SelectedSlots: []program_planner.SelectedSlot{
+					{TimeRange: "8:00 M-F", Days: []string{"mo"}, Selected: true, Fee: 100},
+				},
+				Weeks: []program_planner.WeekPlan{
+					{WeekNumber: 23, ClosedDays: []program_planner.ClosedDay{{Date: closed, Name: "Sunday", Credit: 0}}},
+				},
+			},
+			types: []string{ItemProgramPlaner},
+			total: 100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			tt.planner.ID = primitive.NewObjectID()
+			items := plannerItems(tt.planner)
+
+			if len(items) != len(tt.types) {
+				t.Fatalf("got %d items, want %d", len(items), len(tt.types))
+			}
+			for i, item := range items {
+				if item.Type != tt.types[i] {
+					t.Errorf("item %d type = %q, want %q", i, item.Type, tt.types[i])
+				}
+				if item.SourceID != tt.planner.ID.Hex() {
+					t.Errorf("item %d is not linked to the planner", i)
+				}
+			}
+
+			if _, _, total := totals(items); total != tt.total {
+				t.Errorf("total = %v, want %v", total, tt.total)
+			}
+		})
+	}
+}
+
+func TestTotals(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		amounts  []float64
+		subtotal float64
+		credits  float64
+		total    float64
+	}{
+		{name: "empty", amounts: nil},
+		{name: "charges only", amounts: []float64{100, 15.5}, subtotal: 115.5, total: 115.5},
+		{name: "charges and credits", amounts: []float64{100, -20, 15, -12.5}, subtotal: 115, credits: 32.5, total: 82.5},
+		{name: "rounded to cents", amounts: []float64{0.1, 0.2, -0.05}, subtotal: 0.3, credits: 0.05, total: 0.25},
+		{name: "credits above charges", amounts: []float64{10, -25}, subtotal: 10, credits: 25, total: -15},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var items []LineItem
+			for _, amount := range tt.amounts {
+				items = append(items, LineItem{Amount: amount})
+			}
+
+			subtotal, credits, total := totals(items)
+			if subtotal != tt.subtotal || credits != tt.credits || total != tt.total {
+				t.Errorf("totals = %v, %v, %v, want %v, %v, %v", subtotal, credits, total, tt.subtotal, tt.credits, tt.total)
+			}
+		})
+	}
+}
+
+func TestFeeItems(t *testing.T) {
+
+	assignment := &teacherassign.TeacherAssignment{
+		ID:         primitive.NewObjectID(),
+		Language:   &teacherassign.Data{Label: "English"},
+		MonthlyFee: 250.004,
+	}
+
+	program := &studyprogram.StudyProgram{
+		ID:           primitive.NewObjectID(),
+		MonthlyTotal: 480,
+	}
+
+	items := []LineItem{teacherAssignmentItem(assignment), studyProgramItem(program)}
+
+	if items[0].Description != "Teacher assignment (English)" || items[0].Amount != 250 {
+		t.Errorf("teacher assignment item = %+v", items[0])
+	}
+	if items[1].Description != "Study program" || items[1].Amount != 480 {
+		t.Errorf("study program item = %+v", items[1])
+	}
+
+	if _, _, total := totals(items); total != 730 {
+		t.Errorf("total = %v, want 730", total)
+	}
+}
+
+func TestClaimedSources(t *testing.T) {
+
+	invoice := &Invoice{ID: primitive.NewObjectID(), Sources: Sources{TeacherAssignmentID: "own"}}
+
+	others := []*Invoice{
+		invoice,
+		{ID: primitive.NewObjectID(), Status: StatusIssued, Sources: Sources{TeacherAssignmentID: "assignment", ProgramPlanerID: "planner"}},
+		{ID: primitive.NewObjectID(), Status: StatusDraft, Sources: Sources{StudyProgramID: "program"}},
+		{ID: primitive.NewObjectID(), Status: StatusVoid, Sources: Sources{TeacherAssignmentID: "voided"}},
+	}
+
+	claimed := claimedSources(others, invoice)
+
+	for _, id := range []string{"assignment", "program"} {
+		if !claimed[id] {
+			t.Errorf("%s is billed elsewhere but not claimed", id)
+		}
+	}
+	for _, id := range []string{"own", "voided", "planner"} {
+		if claimed[id] {
+			t.Errorf("%s is claimed", id)
+		}
+	}
+}
+
+func TestCheckTransition(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		comment string
+		wantErr bool
+	}{
+		{name: "issue draft", from: StatusDraft, to: StatusIssued},
+		{name: "void draft", from: StatusDraft, to: StatusVoid},
+		{name: "pay issued", from: StatusIssued, to: StatusPaid},
+		{name: "void issued needs a comment", from: StatusIssued, to: StatusVoid, wantErr: true},
+		{name: "void issued with comment", from: StatusIssued, to: StatusVoid, comment: "wrong month"},
+		{name: "draft cannot be paid", from: StatusDraft, to: StatusPaid, wantErr: true},
+		{name: "paid is final", from: StatusPaid, to: StatusVoid, comment: "refund", wantErr: true},
+		{name: "void is final", from: StatusVoid, to: StatusDraft, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkTransition(tt.from, &TransitionInvoiceRequest{Status: tt.to, Comment: tt.comment})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkTransition error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
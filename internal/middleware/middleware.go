@@ -30,9 +30,66 @@ func Secured() gin.HandlerFunc {
 			if userId, ok := claims[constants.UserID].(string); ok {
 				context.Set(constants.UserID, userId)
 			}
+			context.Set(constants.Roles, rolesFromClaims(claims))
 		}
 
 		context.Set(constants.Token, tokenString)
 		context.Next()
 	}
 }
+
+// RequireRoles rejects the request unless the token carries at least one of the given roles.
+func RequireRoles(roles ...string) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if !HasAnyRole(context, roles...) {
+			context.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		context.Next()
+	}
+}
+
+func HasAnyRole(context *gin.Context, roles ...string) bool {
+	return ContainsAnyRole(GetRoles(context), roles...)
+}
+
+// ContainsAnyRole reports whether roles holds at least one of the allowed roles.
+func ContainsAnyRole(roles []string, allowed ...string) bool {
+	for _, role := range roles {
+		for _, candidate := range allowed {
+			if role == candidate {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func GetRoles(context *gin.Context) []string {
+	roles, exists := context.Get(constants.Roles)
+	if !exists {
+		return nil
+	}
+	result, _ := roles.([]string)
+	return result
+}
+
+// rolesFromClaims accepts both a "roles" array and a single "role" claim.
+func rolesFromClaims(claims jwt.MapClaims) []string {
+
+	roles := make([]string, 0)
+
+	if list, ok := claims[constants.Roles].([]interface{}); ok {
+		for _, item := range list {
+			if role, ok := item.(string); ok && role != "" {
+				roles = append(roles, strings.ToLower(role))
+			}
+		}
+	}
+
+	if role, ok := claims["role"].(string); ok && role != "" {
+		roles = append(roles, strings.ToLower(role))
+	}
+
+	return roles
+}
@@ -0,0 +1,9 @@
+package organization
+
+// OrganizationInfor is the school identity printed on exported documents.
+type OrganizationInfor struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Color   string `json:"color"` // hex, e.g. #1F4E79
+	LogoURL string `json:"logo_url"`
+}
@@ -0,0 +1,139 @@
+package organization
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"portal/pkg/constants"
+	"portal/pkg/consul"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+type OrganizationService interface {
+	GetOrganization(ctx context.Context, id string) (*OrganizationInfor, error)
+}
+
+type organizationService struct {
+	client *callAPI
+}
+
+type callAPI struct {
+	client       consul.ServiceDiscovery
+	clientServer *api.CatalogService
+}
+
+var (
+	mainService = "go-main-service"
+)
+
+func NewOrganizationService(client *api.Client) OrganizationService {
+	mainServiceAPI := NewServiceAPI(client, mainService)
+	return &organizationService{
+		client: mainServiceAPI,
+	}
+}
+
+func NewServiceAPI(client *api.Client, serviceName string) *callAPI {
+	sd, err := consul.NewServiceDiscovery(client, serviceName)
+	if err != nil {
+		fmt.Printf("Error creating service discovery: %v\n", err)
+		return nil
+	}
+
+	var service *api.CatalogService
+
+	for i := 0; i < 10; i++ {
+		service, err = sd.DiscoverService()
+		if err == nil && service != nil {
+			break
+		}
+		fmt.Printf("Waiting for service %s... retry %d/10\n", serviceName, i+1)
+		time.Sleep(3 * time.Second)
+	}
+
+	if service == nil {
+		fmt.Printf("Service %s not found after retries, continuing anyway...\n", serviceName)
+	}
+
+	if os.Getenv("LOCAL_TEST") == "true" {
+		fmt.Println("Running in LOCAL_TEST mode — overriding service address to localhost")
+		service.ServiceAddress = "localhost"
+	}
+
+	return &callAPI{
+		client:       sd,
+		clientServer: service,
+	}
+}
+
+func (s *organizationService) GetOrganization(ctx context.Context, id string) (*OrganizationInfor, error) {
+
+	token, ok := ctx.Value(constants.TokenKey).(string)
+	if !ok {
+		return nil, fmt.Errorf("token not found in context")
+	}
+
+	data, err := s.client.getOrganization(token, id)
+	if err != nil {
+		log.Printf("[ERROR] organizationService.GetOrganization failed (id=%s): %v", id, err)
+		return nil, err
+	}
+
+	if data == nil {
+		return nil, fmt.Errorf("organization not found")
+	}
+
+	organizationID, _ := data["id"].(string)
+	name, _ := data["name"].(string)
+	color, _ := data["color"].(string)
+	logoURL, _ := data["logo_url"].(string)
+
+	if organizationID == "" || name == "" {
+		log.Printf("[ERROR] organizationService.GetOrganization invalid data: %+v", data)
+		return nil, fmt.Errorf("invalid organization data")
+	}
+
+	return &OrganizationInfor{
+		ID:      organizationID,
+		Name:    name,
+		Color:   color,
+		LogoURL: logoURL,
+	}, nil
+
+}
+
+func (c *callAPI) getOrganization(token, id string) (map[string]interface{}, error) {
+
+	endpoint := fmt.Sprintf("/api/v1/gateway/organizations/%s", id)
+
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": fmt.Sprintf("Bearer %s", token),
+	}
+
+	response, err := c.client.CallAPI(c.clientServer, endpoint, "GET", nil, headers)
+	if err != nil {
+		log.Printf("[ERROR] CallAPI failed: %v", err)
+		return nil, fmt.Errorf("call api organization service failed: %w", err)
+	}
+
+	var parse map[string]interface{}
+	if err := json.Unmarshal([]byte(response), &parse); err != nil {
+		log.Printf("[ERROR] JSON unmarshal failed: %v | raw=%s", err, response)
+		return nil, fmt.Errorf("invalid JSON response from organization service: %w", err)
+	}
+
+	dataRaw, ok := parse["data"].(map[string]interface{})
+	if !ok {
+		statusCode, _ := parse["status_code"].(float64)
+		errorMsg, _ := parse["error"].(string)
+		log.Printf("[ERROR] Unexpected response format from organization service (id=%s). status_code=%v, error=%s, raw=%+v", id, statusCode, errorMsg, parse)
+		return nil, fmt.Errorf("organization service returned error (status_code=%v, error=%s)", statusCode, errorMsg)
+	}
+
+	return dataRaw, nil
+}
@@ -0,0 +1,270 @@
+package program_planner
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// booking is a slot a planner holds on a date, or waits for when the slot was full.
+type booking struct {
+	Date       time.Time
+	Slot       *TimeSlot
+	Waitlisted bool
+}
+
+// occupancy counts the bookings of an organization per slot and date.
+type occupancy struct {
+	booked     map[string]int
+	waitlisted map[string]int
+}
+
+func newOccupancy() *occupancy {
+	return &occupancy{booked: map[string]int{}, waitlisted: map[string]int{}}
+}
+
+func (o *occupancy) add(b booking) {
+	if b.Waitlisted {
+		o.waitlisted[occupancyKey(b.Slot, b.Date)]++
+	} else {
+		o.booked[occupancyKey(b.Slot, b.Date)]++
+	}
+}
+
+// isFull reports whether one more booking would exceed the slot's capacity on the date.
+func (o *occupancy) isFull(slot *TimeSlot, date time.Time) bool {
+	return slot.Capacity > 0 && o.booked[occupancyKey(slot, date)] >= slot.Capacity
+}
+
+// slotKey identifies a catalog slot. The default catalog has no IDs, so its slots go by
+// start time.
+func slotKey(slot *TimeSlot) string {
+	if slot.ID.IsZero() {
+		return "@" + slot.StartTime
+	}
+	return slot.ID.Hex()
+}
+
+func occupancyKey(slot *TimeSlot, date time.Time) string {
+	return slotKey(slot) + "|" + date.Format("2006-01-02")
+}
+
+// slotDate is the date of the given day of the week, or false if the week, clipped to the
+// month, does not include it.
+func slotDate(week WeekPlan, code string) (time.Time, bool) {
+
+	monday := week.WeekStart
+	for monday.Weekday() != time.Monday {
+		monday = monday.AddDate(0, 0, -1)
+	}
+
+	date := monday.AddDate(0, 0, dayIndex(code))
+	if dayIndex(code) < 0 || date.Before(week.WeekStart) || date.After(week.WeekEnd) {
+		return date, false
+	}
+
+	return date, true
+}
+
+// catalogSlotFor finds the catalog entry a planner slot was booked from.
+func catalogSlotFor(catalog []*TimeSlot, slot DailySlot) *TimeSlot {
+
+	if slot.SlotID != "" {
+		return findTimeSlot(catalog, slot.SlotID, "")
+	}
+
+	return findSlotAt(catalog, slot.DayOfWeek, slot.Time)
+}
+
+// plannerBookings lists the booked and waitlisted slots of a planner.
+func plannerBookings(program *ProgramPlaner, catalog []*TimeSlot) []booking {
+
+	var bookings []booking
+
+	for _, week := range program.Weeks {
+		for _, slot := range week.Slots {
+
+			if !slot.Selected && !slot.Waitlisted {
+				continue
+			}
+
+			date, ok := slotDate(week, slot.DayOfWeek)
+			if !ok {
+				continue
+			}
+
+			catalogSlot := catalogSlotFor(catalog, slot)
+			if catalogSlot == nil {
+				continue
+			}
+
+			bookings = append(bookings, booking{Date: date, Slot: catalogSlot, Waitlisted: slot.Waitlisted})
+		}
+	}
+
+	return bookings
+}
+
+// eachBookedSlot calls fn for every booked slot of the planner with the date it falls on,
+// including the slots of closed days, which go back to the planner if the closure is lifted.
+func eachBookedSlot(program *ProgramPlaner, fn func(slot *DailySlot, date time.Time)) {
+
+	for w := range program.Weeks {
+
+		week := &program.Weeks[w]
+
+		for i := range week.Slots {
+			if !week.Slots[i].Selected {
+				continue
+			}
+			if date, ok := slotDate(*week, week.Slots[i].DayOfWeek); ok {
+				fn(&week.Slots[i], date)
+			}
+		}
+
+		for d := range week.ClosedDays {
+			closedDay := &week.ClosedDays[d]
+			for i := range closedDay.Slots {
+				if closedDay.Slots[i].Selected {
+					fn(&closedDay.Slots[i], closedDay.Date)
+				}
+			}
+		}
+	}
+}
+
+// heldBookings lists the bookings a planner holds capacity for.
+func heldBookings(program *ProgramPlaner, catalog []*TimeSlot) []booking {
+
+	var bookings []booking
+
+	eachBookedSlot(program, func(slot *DailySlot, date time.Time) {
+		if catalogSlot := catalogSlotFor(catalog, *slot); catalogSlot != nil {
+			bookings = append(bookings, booking{Date: date, Slot: catalogSlot})
+		}
+	})
+
+	return bookings
+}
+
+// removedBookings returns the bookings of before that after no longer holds.
+func removedBookings(before []booking, after []booking) []booking {
+
+	kept := map[string]int{}
+	for _, b := range after {
+		kept[occupancyKey(b.Slot, b.Date)]++
+	}
+
+	var removed []booking
+	for _, b := range before {
+		key := occupancyKey(b.Slot, b.Date)
+		if kept[key] > 0 {
+			kept[key]--
+			continue
+		}
+		removed = append(removed, b)
+	}
+
+	return removed
+}
+
+// counterID is the ID of the counter of the booking's slot and date.
+func counterID(organizationID string, b booking) string {
+	return organizationID + "|" + occupancyKey(b.Slot, b.Date)
+}
+
+// waitlistEntry is a waitlisted slot of a planner.
+type waitlistEntry struct {
+	Planner *ProgramPlaner
+	Week    int
+	Slot    int
+	Since   time.Time
+}
+
+// waitlistFor lists who waits for the slot on the date, longest waiting first.
+func waitlistFor(planners []*ProgramPlaner, catalog []*TimeSlot, b booking) []waitlistEntry {
+
+	var entries []waitlistEntry
+
+	for _, planner := range planners {
+		for w, week := range planner.Weeks {
+			for i, slot := range week.Slots {
+
+				if !slot.Waitlisted || slot.WaitlistedAt == nil {
+					continue
+				}
+
+				date, ok := slotDate(week, slot.DayOfWeek)
+				catalogSlot := catalogSlotFor(catalog, slot)
+				if !ok || catalogSlot == nil || occupancyKey(catalogSlot, date) != occupancyKey(b.Slot, b.Date) {
+					continue
+				}
+
+				entries = append(entries, waitlistEntry{Planner: planner, Week: w, Slot: i, Since: *slot.WaitlistedAt})
+			}
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Since.Before(entries[j].Since)
+	})
+
+	return entries
+}
+
+// promote books a waitlisted slot: an original slot is part of the monthly fee again, an
+// extra slot is charged what was asked for it.
+func (entry waitlistEntry) promote() {
+
+	week := &entry.Planner.Weeks[entry.Week]
+	slot := &week.Slots[entry.Slot]
+
+	slot.Selected = true
+	slot.Waitlisted = false
+	slot.WaitlistedAt = nil
+	if slot.IsOriginal {
+		slot.Fee = 0
+	} else {
+		slot.Fee = slot.WaitlistFee
+	}
+	slot.WaitlistFee = 0
+
+	week.WeekFee = calculateWeekFee(*week)
+}
+
+// overlaps reports whether two slots run at the same time. Slots without an end time, like
+// the default ones, only clash when they start together.
+func overlaps(a *TimeSlot, b *TimeSlot) bool {
+
+	aStart, errAStart := time.Parse("15:04", a.StartTime)
+	bStart, errBStart := time.Parse("15:04", b.StartTime)
+	aEnd, errAEnd := time.Parse("15:04", a.EndTime)
+	bEnd, errBEnd := time.Parse("15:04", b.EndTime)
+
+	if errAStart != nil || errBStart != nil || errAEnd != nil || errBEnd != nil {
+		return sameClock(a.StartTime, b.StartTime)
+	}
+
+	return aStart.Before(bEnd) && bStart.Before(aEnd)
+}
+
+// findClash returns an error for the first booking that overlaps another one of the student
+// on the same date.
+func findClash(bookings []booking, others []booking) error {
+
+	for _, b := range bookings {
+
+		if b.Waitlisted {
+			continue
+		}
+
+		for _, other := range others {
+			if other.Waitlisted || !other.Date.Equal(b.Date) || !overlaps(b.Slot, other.Slot) {
+				continue
+			}
+			return fmt.Errorf("student is already booked into %q on %s", other.Slot.Name, b.Date.Format("2006-01-02"))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,208 @@
+package program_planner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHeldBookings(t *testing.T) {
+
+	catalog := defaultTimeSlots("org")
+
+	tests := []struct {
+		name     string
+		closures []*Closure
+		edit     func(program *ProgramPlaner)
+		want     int
+	}{
+		{
+			name: "every monday of the month",
+			want: 5,
+		},
+		{
+			name:     "closed days still hold their place",
+			closures: []*Closure{closure("Holiday", day(2026, 6, 8), day(2026, 6, 8))},
+			want:     5,
+		},
+		{
+			name: "dropped and waitlisted slots hold nothing",
+			edit: func(program *ProgramPlaner) {
+				weekOf(program, day(2026, 6, 8)).Slots[0].Selected = false
+				slot := &weekOf(program, day(2026, 6, 15)).Slots[0]
+				slot.Selected = false
+				slot.Waitlisted = true
+			},
+			want: 3,
+		},
+		{
+			name: "extra slots hold a place",
+			edit: func(program *ProgramPlaner) {
+				week := weekOf(program, day(2026, 6, 10))
+				week.Slots = append(week.Slots, DailySlot{DayOfWeek: "we", Time: "11:00", Selected: true, Fee: 15})
+			},
+			want: 6,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			program := junePlanner(catalog)
+			if tt.edit != nil {
+				tt.edit(program)
+			}
+			reconcileClosures(program, tt.closures, catalog)
+
+			if got := heldBookings(program, catalog); len(got) != tt.want {
+				t.Errorf("held %d bookings, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestRemovedBookings(t *testing.T) {
+
+	catalog := defaultTimeSlots("org")
+	early, late := catalog[0], catalog[2]
+
+	monday := booking{Date: day(2026, 6, 8), Slot: early}
+	tuesday := booking{Date: day(2026, 6, 9), Slot: early}
+	evening := booking{Date: day(2026, 6, 8), Slot: late}
+
+	tests := []struct {
+		name   string
+		before []booking
+		after  []booking
+		want   []booking
+	}{
+		{name: "nothing held before", after: []booking{monday}},
+		{name: "unchanged", before: []booking{monday, tuesday}, after: []booking{tuesday, monday}},
+		{name: "dropped day", before: []booking{monday, tuesday}, after: []booking{monday}, want: []booking{tuesday}},
+		{name: "moved to another slot", before: []booking{monday}, after: []booking{evening}, want: []booking{monday}},
+		{name: "everything released", before: []booking{monday, evening}, want: []booking{monday, evening}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			got := removedBookings(tt.before, tt.after)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("removed %d bookings, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if occupancyKey(got[i].Slot, got[i].Date) != occupancyKey(tt.want[i].Slot, tt.want[i].Date) {
+					t.Errorf("removed %s, want %s", occupancyKey(got[i].Slot, got[i].Date), occupancyKey(tt.want[i].Slot, tt.want[i].Date))
+				}
+			}
+		})
+	}
+}
+
+func TestOccupancyIsFull(t *testing.T) {
+
+	date := day(2026, 6, 8)
+
+	tests := []struct {
+		name       string
+		capacity   int
+		booked     int
+		waitlisted int
+		want       bool
+	}{
+		{name: "unlimited", capacity: 0, booked: 40},
+		{name: "room left", capacity: 3, booked: 2},
+		{name: "full", capacity: 3, booked: 3, want: true},
+		{name: "waitlist does not take places", capacity: 3, booked: 2, waitlisted: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			slot := &TimeSlot{Name: "8:00 M-F", StartTime: "8:00", Weekdays: weekdaysMF, Capacity: tt.capacity}
+
+			occupied := newOccupancy()
+			for i := 0; i < tt.booked; i++ {
+				occupied.add(booking{Date: date, Slot: slot})
+			}
+			for i := 0; i < tt.waitlisted; i++ {
+				occupied.add(booking{Date: date, Slot: slot, Waitlisted: true})
+			}
+			// Other days do not count.
+			occupied.add(booking{Date: date.AddDate(0, 0, 1), Slot: slot})
+
+			if got := occupied.isFull(slot, date); got != tt.want {
+				t.Errorf("isFull = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaitlistFor(t *testing.T) {
+
+	catalog := defaultTimeSlots("org")
+	date := day(2026, 6, 8)
+
+	waitlisted := func(since *time.Time, fee float64) *ProgramPlaner {
+		program := junePlanner(catalog)
+		slot := &weekOf(program, date).Slots[0]
+		slot.Selected = false
+		slot.Waitlisted = true
+		slot.WaitlistedAt = since
+		slot.Fee = fee
+		return program
+	}
+
+	first := day(2026, 5, 20)
+	second := day(2026, 5, 25)
+
+	late := waitlisted(&second, -20)
+	early := waitlisted(&first, -20)
+	booked := junePlanner(catalog)
+
+	entries := waitlistFor([]*ProgramPlaner{late, booked, early}, catalog, booking{Date: date, Slot: catalog[0]})
+
+	if len(entries) != 2 {
+		t.Fatalf("got %d waitlist entries, want 2", len(entries))
+	}
+	for i, want := range []*ProgramPlaner{early, late} {
+		if entries[i].Planner != want {
+			t.Errorf("entry %d is waiting since %s, out of order", i, entries[i].Since.Format("2006-01-02"))
+		}
+	}
+
+	if other := waitlistFor([]*ProgramPlaner{late}, catalog, booking{Date: date.AddDate(0, 0, 7), Slot: catalog[0]}); len(other) != 0 {
+		t.Errorf("waitlist of another day has %d entries", len(other))
+	}
+
+	entries[0].promote()
+	slot := entries[0].Planner.Weeks[entries[0].Week].Slots[entries[0].Slot]
+	if !slot.Selected || slot.Waitlisted || slot.WaitlistedAt != nil || slot.Fee != 0 {
+		t.Errorf("promoted original slot = %+v, want booked at no extra fee", slot)
+	}
+	if fee := entries[0].Planner.Weeks[entries[0].Week].WeekFee; fee != 0 {
+		t.Errorf("week fee after promotion = %v, want 0", fee)
+	}
+}
+
+func TestPromoteExtraSlot(t *testing.T) {
+
+	now := time.Now()
+	program := &ProgramPlaner{
+		Weeks: []WeekPlan{{
+			WeekStart: day(2026, 6, 8),
+			WeekEnd:   day(2026, 6, 14),
+			Slots:     []DailySlot{{DayOfWeek: "tu", Time: "8:00", Waitlisted: true, WaitlistedAt: &now, WaitlistFee: 15}},
+		}},
+	}
+
+	waitlistEntry{Planner: program, Week: 0, Slot: 0}.promote()
+
+	slot := program.Weeks[0].Slots[0]
+	if !slot.Selected || slot.Waitlisted || slot.Fee != 15 || slot.WaitlistFee != 0 {
+		t.Errorf("promoted extra slot = %+v, want booked at its fee", slot)
+	}
+	if program.Weeks[0].WeekFee != 15 {
+		t.Errorf("week fee = %v, want 15", program.Weeks[0].WeekFee)
+	}
+}
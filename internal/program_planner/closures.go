@@ -0,0 +1,253 @@
+package program_planner
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	ClosureSourceManual  = "manual"
+	ClosureSourceHoliday = "holiday_service"
+
+	AdjustmentClosureAdded   = "closure_added"
+	AdjustmentClosureRemoved = "closure_removed"
+)
+
+func validateClosure(closure *Closure) error {
+
+	if strings.TrimSpace(closure.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if closure.EndDate.Before(closure.StartDate) {
+		return fmt.Errorf("end_date must not be before start_date")
+	}
+
+	return nil
+}
+
+func (closure *Closure) covers(day time.Time) bool {
+	return !day.Before(closure.StartDate) && !day.After(closure.EndDate)
+}
+
+// closureOn returns the closure covering the day, preferring the earliest created one so
+// overlapping closures always resolve the same way.
+func closureOn(closures []*Closure, day time.Time) *Closure {
+
+	var found *Closure
+	for _, closure := range closures {
+		if !closure.covers(day) {
+			continue
+		}
+		if found == nil || closure.CreatedAt.Before(found.CreatedAt) {
+			found = closure
+		}
+	}
+
+	return found
+}
+
+// sessionFee spreads the monthly fee of a selection over its sessions in the month, which
+// is what a family gets back for a session that does not take place.
+func sessionFee(selection SelectedSlot, year int, month int) float64 {
+
+	first := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+
+	sessions := 0
+	for day := first; day.Month() == first.Month(); day = day.AddDate(0, 0, 1) {
+		if containsDay(selection.Days, dayCode(day)) {
+			sessions++
+		}
+	}
+
+	if sessions == 0 {
+		return 0
+	}
+
+	return selection.Fee / float64(sessions)
+}
+
+// findSelection returns the selection an original slot was generated from.
+func findSelection(selections []SelectedSlot, catalog []*TimeSlot, slot DailySlot) *SelectedSlot {
+
+	for i, selection := range selections {
+
+		if !selection.Selected || !containsDay(selection.Days, slot.DayOfWeek) {
+			continue
+		}
+
+		if slot.SlotID != "" && selection.SlotID == slot.SlotID {
+			return &selections[i]
+		}
+
+		catalogSlot := findTimeSlot(catalog, selection.SlotID, selection.TimeRange)
+		if catalogSlot != nil && sameClock(catalogSlot.StartTime, slot.Time) {
+			return &selections[i]
+		}
+	}
+
+	return nil
+}
+
+// closureCredit is the fee given back for the slots of a closed day. Original slots the
+// family had already dropped keep the credit they were given; extra slots are charged by
+// the slot, so taking them away is credit enough.
+func closureCredit(program *ProgramPlaner, catalog []*TimeSlot, slots []DailySlot) float64 {
+
+	credit := 0.0
+
+	for _, slot := range slots {
+
+		if !slot.IsOriginal {
+			continue
+		}
+
+		if !slot.Selected {
+			credit -= slot.Fee
+			continue
+		}
+
+		if selection := findSelection(program.SelectedSlots, catalog, slot); selection != nil {
+			credit += sessionFee(*selection, program.Year, program.Month)
+		}
+	}
+
+	return roundFee(credit)
+}
+
+// reconcileClosures moves the slots of closed days out of the weeks and credits their fee,
+// and puts them back on days that are no longer closed. Week fees are recalculated and the
+// days that changed are returned.
+func reconcileClosures(program *ProgramPlaner, closures []*Closure, catalog []*TimeSlot) []time.Time {
+
+	var changed []time.Time
+
+	for w := range program.Weeks {
+
+		week := &program.Weeks[w]
+
+		for day := week.WeekStart; !day.After(week.WeekEnd); day = day.AddDate(0, 0, 1) {
+
+			closure := closureOn(closures, day)
+			index := closedDayIndex(week.ClosedDays, day)
+
+			if closure != nil {
+
+				if index >= 0 && week.ClosedDays[index].ClosureID == closure.ID.Hex() {
+					continue
+				}
+
+				var removed []DailySlot
+				if index >= 0 {
+					// Another closure now covers the day; its slots are already out.
+					removed = week.ClosedDays[index].Slots
+				} else {
+					week.Slots, removed = takeDaySlots(week.Slots, dayCode(day))
+				}
+
+				closedDay := ClosedDay{
+					Date:      day,
+					ClosureID: closure.ID.Hex(),
+					Name:      closure.Name,
+					Credit:    closureCredit(program, catalog, removed),
+					Slots:     removed,
+				}
+
+				if index >= 0 {
+					week.ClosedDays[index] = closedDay
+				} else {
+					week.ClosedDays = append(week.ClosedDays, closedDay)
+				}
+
+				changed = append(changed, day)
+
+			} else if index >= 0 {
+
+				week.Slots = append(week.Slots, week.ClosedDays[index].Slots...)
+				week.ClosedDays = append(week.ClosedDays[:index], week.ClosedDays[index+1:]...)
+
+				changed = append(changed, day)
+			}
+		}
+
+		week.WeekFee = calculateWeekFee(*week)
+	}
+
+	return changed
+}
+
+func takeDaySlots(slots []DailySlot, code string) ([]DailySlot, []DailySlot) {
+
+	kept := make([]DailySlot, 0, len(slots))
+	var taken []DailySlot
+
+	for _, slot := range slots {
+		if slot.DayOfWeek == code {
+			taken = append(taken, slot)
+		} else {
+			kept = append(kept, slot)
+		}
+	}
+
+	return kept, taken
+}
+
+func closedDayIndex(closedDays []ClosedDay, day time.Time) int {
+	for i, closedDay := range closedDays {
+		if closedDay.Date.Equal(day) {
+			return i
+		}
+	}
+	return -1
+}
+
+// isClosed reports whether the slot on the given day of the week falls on a closed day.
+func isClosed(week WeekPlan, code string) bool {
+	for _, closedDay := range week.ClosedDays {
+		if dayCode(closedDay.Date) == code {
+			return true
+		}
+	}
+	return false
+}
+
+// calculateWeekFee is what the week adds to the monthly fee: extra slots minus dropped
+// original slots and closed days.
+func calculateWeekFee(week WeekPlan) float64 {
+
+	total := 0.0
+
+	for _, s := range week.Slots {
+		total += s.Fee
+	}
+
+	for _, closedDay := range week.ClosedDays {
+		total -= closedDay.Credit
+	}
+
+	return roundFee(total)
+}
+
+func dayCode(day time.Time) string {
+	return dayCodes[(int(day.Weekday())+6)%7]
+}
+
+// monthsBetween lists the year and month of every month the range touches.
+func monthsBetween(from time.Time, to time.Time) [][2]int {
+
+	var months [][2]int
+
+	month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for !month.After(to) {
+		months = append(months, [2]int{month.Year(), int(month.Month())})
+		month = month.AddDate(0, 1, 0)
+	}
+
+	return months
+}
+
+func roundFee(fee float64) float64 {
+	return math.Round(fee*100) / 100
+}
@@ -0,0 +1,186 @@
+package program_planner
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func day(year int, month int, d int) time.Time {
+	return time.Date(year, time.Month(month), d, 0, 0, 0, 0, time.UTC)
+}
+
+func closure(name string, from time.Time, to time.Time) *Closure {
+	return &Closure{ID: primitive.NewObjectID(), Name: name, StartDate: from, EndDate: to}
+}
+
+// junePlanner books the default 8:00 slot on Mondays for 100 a month. June 2026 has five
+// Mondays, so each session is worth 20.
+func junePlanner(catalog []*TimeSlot) *ProgramPlaner {
+
+	selections := []SelectedSlot{{TimeRange: "8:00 M-F", Days: []string{"mo"}, Selected: true, Fee: 100}}
+
+	return &ProgramPlaner{
+		Month:         6,
+		Year:          2026,
+		SelectedSlots: selections,
+		Weeks:         generateWeeks(2026, 6, selections, catalog),
+	}
+}
+
+func weekOf(program *ProgramPlaner, date time.Time) *WeekPlan {
+	for w := range program.Weeks {
+		if !date.Before(program.Weeks[w].WeekStart) && !date.After(program.Weeks[w].WeekEnd) {
+			return &program.Weeks[w]
+		}
+	}
+	return nil
+}
+
+func TestSessionFee(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		selection SelectedSlot
+		want      float64
+	}{
+		{name: "five mondays", selection: SelectedSlot{Days: []string{"mo"}, Fee: 100}, want: 20},
+		{name: "four wednesdays", selection: SelectedSlot{Days: []string{"we"}, Fee: 80}, want: 20},
+		{name: "mondays and wednesdays", selection: SelectedSlot{Days: []string{"mo", "we"}, Fee: 90}, want: 10},
+		{name: "no sessions", selection: SelectedSlot{Days: []string{}, Fee: 90}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sessionFee(tt.selection, 2026, 6); got != tt.want {
+				t.Errorf("sessionFee = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcileClosures(t *testing.T) {
+
+	catalog := defaultTimeSlots("org")
+
+	tests := []struct {
+		name     string
+		closures []*Closure
+		edit     func(program *ProgramPlaner)
+		changed  int
+		total    float64
+	}{
+		{
+			name:  "no closures",
+			total: 100,
+		},
+		{
+			name:     "one closed monday is credited",
+			closures: []*Closure{closure("Holiday", day(2026, 6, 8), day(2026, 6, 8))},
+			changed:  1,
+			total:    80,
+		},
+		{
+			name:     "closed days without sessions cost nothing",
+			closures: []*Closure{closure("Long weekend", day(2026, 6, 5), day(2026, 6, 7))},
+			changed:  3,
+			total:    100,
+		},
+		{
+			name:     "whole month closed",
+			closures: []*Closure{closure("Renovation", day(2026, 6, 1), day(2026, 6, 30))},
+			changed:  30,
+			total:    0,
+		},
+		{
+			name:     "dropped session keeps its credit",
+			closures: []*Closure{closure("Holiday", day(2026, 6, 8), day(2026, 6, 8))},
+			edit: func(program *ProgramPlaner) {
+				week := weekOf(program, day(2026, 6, 8))
+				week.Slots[0].Selected = false
+				week.Slots[0].Fee = -20
+				week.WeekFee = calculateWeekFee(*week)
+			},
+			changed: 1,
+			total:   80,
+		},
+		{
+			name:     "extra session is taken away, not credited",
+			closures: []*Closure{closure("Holiday", day(2026, 6, 10), day(2026, 6, 10))},
+			edit: func(program *ProgramPlaner) {
+				week := weekOf(program, day(2026, 6, 10))
+				week.Slots = append(week.Slots, DailySlot{DayOfWeek: "we", Time: "8:00", Selected: true, Fee: 15})
+				week.WeekFee = calculateWeekFee(*week)
+			},
+			changed: 1,
+			total:   100,
+		},
+	}
+
+	service := &programPlannerService{}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			program := junePlanner(catalog)
+			if tt.edit != nil {
+				tt.edit(program)
+			}
+
+			changed := reconcileClosures(program, tt.closures, catalog)
+			if len(changed) != tt.changed {
+				t.Errorf("changed %d days, want %d", len(changed), tt.changed)
+			}
+
+			if total := roundFee(service.calculateTotalFee(program.Weeks, program.SelectedSlots)); total != tt.total {
+				t.Errorf("total = %v, want %v", total, tt.total)
+			}
+
+			// Lifting the closures gives the month back as it was.
+			reconcileClosures(program, nil, catalog)
+			for _, week := range program.Weeks {
+				if len(week.ClosedDays) != 0 {
+					t.Fatalf("week %d still has closed days", week.WeekNumber)
+				}
+			}
+			want := junePlanner(catalog)
+			if tt.edit != nil {
+				tt.edit(want)
+			}
+			if total, wantTotal := service.calculateTotalFee(program.Weeks, program.SelectedSlots), service.calculateTotalFee(want.Weeks, want.SelectedSlots); total != wantTotal {
+				t.Errorf("total after reopening = %v, want %v", total, wantTotal)
+			}
+		})
+	}
+}
+
+func TestCalculateTotalFee(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		selections []SelectedSlot
+		weekFees   []float64
+		want       float64
+	}{
+		{name: "selections only", selections: []SelectedSlot{{Selected: true, Fee: 100}, {Selected: true, Fee: 50}}, want: 150},
+		{name: "unselected slots are free", selections: []SelectedSlot{{Selected: true, Fee: 100}, {Selected: false, Fee: 50}}, want: 100},
+		{name: "week extras and credits", selections: []SelectedSlot{{Selected: true, Fee: 100}}, weekFees: []float64{15, -20, 0}, want: 95},
+	}
+
+	service := &programPlannerService{}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var weeks []WeekPlan
+			for _, fee := range tt.weekFees {
+				weeks = append(weeks, WeekPlan{WeekFee: fee})
+			}
+
+			if got := service.calculateTotalFee(weeks, tt.selections); got != tt.want {
+				t.Errorf("calculateTotalFee = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package program_planner
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"portal/helper"
@@ -86,6 +87,10 @@ func (handler *ProgramPlanerHandler) GetProgramPlaner(c *gin.Context) {
 	ctx := context.WithValue(c, constants.TokenKey, token)
 
 	programPlaner, err := handler.ProgramPlanerService.GetProgramPlaner(ctx, id)
+	if errors.Is(err, ErrPlannerNotFound) {
+		helper.SendError(c, 404, err, helper.ErrNotFound)
+		return
+	}
 	if err != nil {
 		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
 		return
@@ -115,6 +120,14 @@ func (handler *ProgramPlanerHandler) UpdateProgramPlaner(c *gin.Context) {
 	ctx := context.WithValue(c, constants.TokenKey, token)
 
 	err := handler.ProgramPlanerService.UpdateProgramPlaner(ctx, &req, id)
+	if errors.Is(err, ErrPlannerNotFound) {
+		helper.SendError(c, 404, err, helper.ErrNotFound)
+		return
+	}
+	if errors.Is(err, ErrPlannerConflict) {
+		helper.SendError(c, 409, err, helper.ErrVersionConflict)
+		return
+	}
 	if err != nil {
 		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
 		return
@@ -137,6 +150,10 @@ func (handler *ProgramPlanerHandler) DeleteProgramPlaner(c *gin.Context) {
 	ctx := context.WithValue(c, constants.TokenKey, token)
 
 	err := handler.ProgramPlanerService.DeleteProgramPlaner(ctx, id)
+	if errors.Is(err, ErrPlannerNotFound) {
+		helper.SendError(c, 404, err, helper.ErrNotFound)
+		return
+	}
 	if err != nil {
 		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
 		return
@@ -166,6 +183,14 @@ func (handler *ProgramPlanerHandler) UpdateProgramPlanerWeek(c *gin.Context) {
 	ctx := context.WithValue(c, constants.TokenKey, token)
 
 	err := handler.ProgramPlanerService.UpdateProgramPlanerWeek(ctx, &req, id)
+	if errors.Is(err, ErrPlannerNotFound) {
+		helper.SendError(c, 404, err, helper.ErrNotFound)
+		return
+	}
+	if errors.Is(err, ErrPlannerConflict) {
+		helper.SendError(c, 409, err, helper.ErrVersionConflict)
+		return
+	}
 	if err != nil {
 		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
 		return
@@ -173,4 +198,260 @@ func (handler *ProgramPlanerHandler) UpdateProgramPlanerWeek(c *gin.Context) {
 
 	helper.SendSuccess(c, 200, "Create week program planer successfully", nil)
 
-}
\ No newline at end of file
+}
+
+func (handler *ProgramPlanerHandler) CreateTimeSlot(c *gin.Context) {
+
+	var req CreateTimeSlotRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	slot, err := handler.ProgramPlanerService.CreateTimeSlot(ctx, &req, userID.(string))
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Create time slot successfully", slot)
+
+}
+
+func (handler *ProgramPlanerHandler) GetTimeSlots(c *gin.Context) {
+
+	organizationID := c.Query("organization_id")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	slots, err := handler.ProgramPlanerService.GetTimeSlots(ctx, organizationID)
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Get time slots successfully", slots)
+
+}
+
+func (handler *ProgramPlanerHandler) UpdateTimeSlot(c *gin.Context) {
+
+	id := c.Param("id")
+
+	var req UpdateTimeSlotRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	slot, err := handler.ProgramPlanerService.UpdateTimeSlot(ctx, id, &req)
+	if errors.Is(err, ErrTimeSlotInUse) {
+		helper.SendError(c, 409, err, helper.ErrInvalidOperation)
+		return
+	}
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Update time slot successfully", slot)
+
+}
+
+func (handler *ProgramPlanerHandler) DeleteTimeSlot(c *gin.Context) {
+
+	id := c.Param("id")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	err := handler.ProgramPlanerService.DeleteTimeSlot(ctx, id)
+	if errors.Is(err, ErrTimeSlotInUse) {
+		helper.SendError(c, 409, err, helper.ErrInvalidOperation)
+		return
+	}
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Delete time slot successfully", nil)
+
+}
+
+func (handler *ProgramPlanerHandler) CreateClosure(c *gin.Context) {
+
+	var req CreateClosureRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	res, err := handler.ProgramPlanerService.CreateClosure(ctx, &req, userID.(string))
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Create closure successfully", res)
+
+}
+
+func (handler *ProgramPlanerHandler) GetClosures(c *gin.Context) {
+
+	organizationID := c.Query("organization_id")
+	from := c.Query("from")
+	to := c.Query("to")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	closures, err := handler.ProgramPlanerService.GetClosures(ctx, organizationID, from, to)
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Get closures successfully", closures)
+
+}
+
+func (handler *ProgramPlanerHandler) DeleteClosure(c *gin.Context) {
+
+	id := c.Param("id")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	res, err := handler.ProgramPlanerService.DeleteClosure(ctx, id, userID.(string))
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Delete closure successfully", res)
+
+}
+
+func (handler *ProgramPlanerHandler) SyncClosures(c *gin.Context) {
+
+	var req SyncClosuresRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		helper.SendError(c, http.StatusBadRequest, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	userID, exists := c.Get(constants.UserID)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("user_id not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	res, err := handler.ProgramPlanerService.SyncClosures(ctx, &req, userID.(string))
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Sync closures successfully", res)
+
+}
+
+func (handler *ProgramPlanerHandler) GetOccupancy(c *gin.Context) {
+
+	organizationID := c.Query("organization_id")
+	month := c.Query("month")
+	year := c.Query("year")
+
+	token, exists := c.Get(constants.Token)
+	if !exists {
+		helper.SendError(c, 400, fmt.Errorf("token not found"), helper.ErrInvalidRequest)
+		return
+	}
+
+	ctx := context.WithValue(c, constants.TokenKey, token)
+
+	occupancy, err := handler.ProgramPlanerService.GetOccupancy(ctx, organizationID, month, year)
+	if err != nil {
+		helper.SendError(c, 400, err, helper.ErrInvalidRequest)
+		return
+	}
+
+	helper.SendSuccess(c, 200, "Get occupancy successfully", occupancy)
+
+}
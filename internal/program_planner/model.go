@@ -7,21 +7,23 @@ import (
 )
 
 type ProgramPlaner struct {
-	ID             primitive.ObjectID `json:"id" bson:"_id"`
-	OrganizationID string             `json:"organization_id" bson:"organization_id"`
-	StudentID      string             `json:"student_id" bson:"student_id"`
-	Month          int                `json:"month" bson:"month"`
-	Year           int                `json:"year" bson:"year"`
-	TotalFee       float64            `json:"total_fee" bson:"total_fee"`
-	SelectedSlots  []SelectedSlot     `json:"selected_slots" bson:"selected_slots"`
-	Weeks          []WeekPlan         `json:"weeks" bson:"weeks"`
-	CreatedBy      string             `json:"created_by" bson:"created_by"`
-	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
-	IsDeleted      bool               `json:"is_deleted" bson:"is_deleted"`
-	UpdatedAt      time.Time          `json:"updated_at" bson:"updated_at"`
+	ID             primitive.ObjectID  `json:"id" bson:"_id"`
+	OrganizationID string              `json:"organization_id" bson:"organization_id"`
+	StudentID      string              `json:"student_id" bson:"student_id"`
+	Month          int                 `json:"month" bson:"month"`
+	Year           int                 `json:"year" bson:"year"`
+	TotalFee       float64             `json:"total_fee" bson:"total_fee"`
+	SelectedSlots  []SelectedSlot      `json:"selected_slots" bson:"selected_slots"`
+	Weeks          []WeekPlan          `json:"weeks" bson:"weeks"`
+	Adjustments    []PlannerAdjustment `json:"adjustments,omitempty" bson:"adjustments,omitempty"`
+	CreatedBy      string              `json:"created_by" bson:"created_by"`
+	CreatedAt      time.Time           `json:"created_at" bson:"created_at"`
+	IsDeleted      bool                `json:"is_deleted" bson:"is_deleted"`
+	UpdatedAt      time.Time           `json:"updated_at" bson:"updated_at"`
 }
 
 type SelectedSlot struct {
+	SlotID    string   `json:"slot_id,omitempty" bson:"slot_id,omitempty"`
 	TimeRange string   `json:"time_range" bson:"time_range"`
 	Days      []string `json:"days" bson:"days"`
 	Selected  bool     `json:"selected" bson:"selected"`
@@ -34,12 +36,85 @@ type WeekPlan struct {
 	WeekEnd    time.Time   `json:"week_end" bson:"week_end"`
 	WeekFee    float64     `json:"week_fee" bson:"week_fee"`
 	Slots      []DailySlot `json:"slots" bson:"slots"`
+	ClosedDays []ClosedDay `json:"closed_days,omitempty" bson:"closed_days,omitempty"`
 }
 
 type DailySlot struct {
+	SlotID     string  `json:"slot_id,omitempty" bson:"slot_id,omitempty"`
 	DayOfWeek  string  `json:"day_of_week" bson:"day_of_week"`
 	Time       string  `json:"time" bson:"time"`
 	Selected   bool    `json:"selected" bson:"selected"`
 	Fee        float64 `json:"fee" bson:"fee"`
 	IsOriginal bool    `json:"is_original" bson:"is_original"`
+	Waitlisted bool    `json:"waitlisted,omitempty" bson:"waitlisted,omitempty"` // slot was full, not booked
+
+	// The waitlist is served in order, and an extra slot is charged its fee once it is booked.
+	WaitlistedAt *time.Time `json:"waitlisted_at,omitempty" bson:"waitlisted_at,omitempty"`
+	WaitlistFee  float64    `json:"waitlist_fee,omitempty" bson:"waitlist_fee,omitempty"`
+}
+
+// SlotCounter counts the bookings of a catalog slot on a date. Capacity is reserved with a
+// conditional increment on it, so concurrent bookings cannot overbook the slot.
+type SlotCounter struct {
+	ID             string    `json:"id" bson:"_id"` // organization|slot|date
+	OrganizationID string    `json:"organization_id" bson:"organization_id"`
+	SlotKey        string    `json:"slot_key" bson:"slot_key"`
+	Date           time.Time `json:"date" bson:"date"`
+	Booked         int       `json:"booked" bson:"booked"`
+	UpdatedAt      time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// TimeSlot is an entry of the organization's slot catalog. Planners can only book slots
+// from the catalog, on its weekdays.
+type TimeSlot struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id"`
+	OrganizationID string             `json:"organization_id" bson:"organization_id"`
+	Name           string             `json:"name" bson:"name"`             // e.g. "8:00 M-F", matched against SelectedSlot.TimeRange
+	StartTime      string             `json:"start_time" bson:"start_time"` // 15:04
+	EndTime        string             `json:"end_time" bson:"end_time"`
+	Weekdays       []string           `json:"weekdays" bson:"weekdays"` // mo, tu, we, th, fr, sa, su
+	DefaultFee     float64            `json:"default_fee" bson:"default_fee"`
+	Capacity       int                `json:"capacity" bson:"capacity"` // 0 means unlimited
+	IsDeleted      bool               `json:"is_deleted" bson:"is_deleted"`
+	CreatedBy      string             `json:"created_by" bson:"created_by"`
+	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// Closure is a period, inclusive of both dates, in which the organization is closed.
+type Closure struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id"`
+	OrganizationID string             `json:"organization_id" bson:"organization_id"`
+	Name           string             `json:"name" bson:"name"`
+	StartDate      time.Time          `json:"start_date" bson:"start_date"`
+	EndDate        time.Time          `json:"end_date" bson:"end_date"`
+	Source         string             `json:"source" bson:"source"`                               // manual, holiday_service
+	ExternalID     string             `json:"external_id,omitempty" bson:"external_id,omitempty"` // holiday-service ID of synced closures
+	IsDeleted      bool               `json:"is_deleted" bson:"is_deleted"`
+	CreatedBy      string             `json:"created_by" bson:"created_by"`
+	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// ClosedDay records the sessions of a week that do not take place because of a closure,
+// and the prorated fee credited for them.
+type ClosedDay struct {
+	Date      time.Time   `json:"date" bson:"date"`
+	ClosureID string      `json:"closure_id" bson:"closure_id"`
+	Name      string      `json:"name" bson:"name"`
+	Credit    float64     `json:"credit" bson:"credit"`
+	Slots     []DailySlot `json:"slots" bson:"slots"` // restored if the closure is removed
+}
+
+// PlannerAdjustment records a change made to an existing planner by a closure.
+type PlannerAdjustment struct {
+	Reason        string      `json:"reason" bson:"reason"` // closure_added, closure_removed
+	ClosureID     string      `json:"closure_id" bson:"closure_id"`
+	ClosureName   string      `json:"closure_name" bson:"closure_name"`
+	Dates         []time.Time `json:"dates" bson:"dates"`
+	PreviousTotal float64     `json:"previous_total" bson:"previous_total"`
+	NewTotal      float64     `json:"new_total" bson:"new_total"`
+	Amount        float64     `json:"amount" bson:"amount"`
+	CreatedBy     string      `json:"created_by" bson:"created_by"`
+	CreatedAt     time.Time   `json:"created_at" bson:"created_at"`
 }
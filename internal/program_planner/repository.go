@@ -2,12 +2,26 @@ package program_planner
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrPlannerConflict is returned when the planner changed since the caller read it.
+var ErrPlannerConflict = errors.New("program planer was modified by someone else, please reload")
+
+// ErrPlannerNotFound is returned for planners that do not exist or were deleted.
+var ErrPlannerNotFound = errors.New("program planer not found")
+
+// ErrTimeSlotInUse is returned when a slot cannot be retimed or deleted because planners
+// of this month or later book it.
+var ErrTimeSlotInUse = errors.New("time slot is used by program planers")
+
 type ProgramPlanerRepository interface {
 	CreateProgramPlaner(ctx context.Context, data *ProgramPlaner) (string, error)
 	GetAllProgramPlaner(ctx context.Context) ([]*ProgramPlaner, error)
@@ -16,15 +30,42 @@ type ProgramPlanerRepository interface {
 	DeleteProgramPlaner(ctx context.Context, id primitive.ObjectID) error
 
 	UpdateProgramPlanerWeek(ctx context.Context, data *ProgramPlaner, id primitive.ObjectID) error
+	AdjustProgramPlaner(ctx context.Context, data *ProgramPlaner, weeks []int, adjustment PlannerAdjustment) error
+
+	CreateTimeSlot(ctx context.Context, slot *TimeSlot) error
+	GetTimeSlots(ctx context.Context, organizationID string) ([]*TimeSlot, error)
+	GetTimeSlot(ctx context.Context, id primitive.ObjectID) (*TimeSlot, error)
+	UpdateTimeSlot(ctx context.Context, slot *TimeSlot) error
+	DeleteTimeSlot(ctx context.Context, id primitive.ObjectID) error
+	CountPlanersUsingSlot(ctx context.Context, slot *TimeSlot, month int, year int) (int64, error)
+
+	SeedSlotCounter(ctx context.Context, counter *SlotCounter) error
+	ReserveSlot(ctx context.Context, id string, capacity int) (bool, error)
+	ReleaseSlot(ctx context.Context, id string) error
+
+	GetProgramPlanerForMonth(ctx context.Context, organizationID string, studentID string, month int, year int) (*ProgramPlaner, error)
+	GetProgramPlanersForMonths(ctx context.Context, organizationID string, months [][2]int) ([]*ProgramPlaner, error)
+	GetStudentProgramPlaners(ctx context.Context, studentID string, month int, year int) ([]*ProgramPlaner, error)
+
+	CreateClosure(ctx context.Context, closure *Closure) error
+	GetClosures(ctx context.Context, organizationID string, from time.Time, to time.Time) ([]*Closure, error)
+	GetClosure(ctx context.Context, id primitive.ObjectID) (*Closure, error)
+	DeleteClosure(ctx context.Context, id primitive.ObjectID) error
 }
 
 type programPlannerRepository struct {
 	programPlanerCollection *mongo.Collection
+	timeSlotCollection      *mongo.Collection
+	closureCollection       *mongo.Collection
+	slotCounterCollection   *mongo.Collection
 }
 
-func NewProgramPlanerRepository(collection *mongo.Collection) ProgramPlanerRepository {
+func NewProgramPlanerRepository(collection *mongo.Collection, timeSlotCollection *mongo.Collection, closureCollection *mongo.Collection, slotCounterCollection *mongo.Collection) ProgramPlanerRepository {
 	return &programPlannerRepository{
 		programPlanerCollection: collection,
+		timeSlotCollection:      timeSlotCollection,
+		closureCollection:       closureCollection,
+		slotCounterCollection:   slotCounterCollection,
 	}
 }
 
@@ -75,11 +116,14 @@ func (repository *programPlannerRepository) GetProgramPlaner(ctx context.Context
 	var programPlaner ProgramPlaner
 
 	filter := bson.M{
-		"_id": id,
+		"_id":        id,
+		"is_deleted": bson.M{"$ne": true},
 	}
 
 	err := repository.programPlanerCollection.FindOne(ctx, filter).Decode(&programPlaner)
-
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrPlannerNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -89,45 +133,388 @@ func (repository *programPlannerRepository) GetProgramPlaner(ctx context.Context
 }
 
 func (repository *programPlannerRepository) UpdateProgramPlaner(ctx context.Context, data *ProgramPlaner, id primitive.ObjectID) error {
+	return repository.replaceProgramPlaner(ctx, data, id)
+}
 
-	filter := bson.M{"_id": id}
-	update := bson.M{"$set": data}
+func (repository *programPlannerRepository) DeleteProgramPlaner(ctx context.Context, id primitive.ObjectID) error {
 
-	_, err := repository.programPlanerCollection.UpdateOne(ctx, filter, update)
+	// Only the first delete counts, so the planner's bookings are released once.
+	filter := bson.M{"_id": id, "is_deleted": bson.M{"$ne": true}}
+	update := bson.M{"$set": bson.M{"is_deleted": true}}
+
+	result, err := repository.programPlanerCollection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return err
 	}
 
+	if result.MatchedCount == 0 {
+		return errors.New("program planer not found")
+	}
+
 	return nil
+}
 
+func (repository *programPlannerRepository) UpdateProgramPlanerWeek(ctx context.Context, data *ProgramPlaner, id primitive.ObjectID) error {
+	return repository.replaceProgramPlaner(ctx, data, id)
 }
 
-func (repository *programPlannerRepository) DeleteProgramPlaner(ctx context.Context, id primitive.ObjectID) error {
+// replaceProgramPlaner writes the whole planner back, provided nobody changed or deleted it
+// since it was read at data.UpdatedAt.
+func (repository *programPlannerRepository) replaceProgramPlaner(ctx context.Context, data *ProgramPlaner, id primitive.ObjectID) error {
 
-	filter := bson.M{"_id": id}
-	update := bson.M{"$set": bson.M{"is_deleted": true}}
+	// Mongo keeps milliseconds, and the next write compares against what was stored.
+	readAt := data.UpdatedAt
+	data.UpdatedAt = time.Now().Truncate(time.Millisecond)
 
-	_, err := repository.programPlanerCollection.UpdateOne(ctx, filter, update)
+	filter := bson.M{
+		"_id":        id,
+		"updated_at": readAt,
+		"is_deleted": bson.M{"$ne": true},
+	}
+
+	update := bson.M{"$set": data}
+
+	result, err := repository.programPlanerCollection.UpdateOne(ctx, filter, update)
 	if err != nil {
+		data.UpdatedAt = readAt
 		return err
 	}
 
+	if result.MatchedCount == 0 {
+		data.UpdatedAt = readAt
+		return ErrPlannerConflict
+	}
+
 	return nil
 }
 
-func (repository *programPlannerRepository) UpdateProgramPlanerWeek(ctx context.Context, data *ProgramPlaner, id primitive.ObjectID) error {
+// AdjustProgramPlaner saves the given weeks and the total of a planner a closure changed,
+// and records the adjustment. Like the other writes it fails with ErrPlannerConflict if
+// the planner changed since it was read.
+func (repository *programPlannerRepository) AdjustProgramPlaner(ctx context.Context, data *ProgramPlaner, weeks []int, adjustment PlannerAdjustment) error {
+
+	now := time.Now().Truncate(time.Millisecond)
+
+	set := bson.M{
+		"total_fee":  data.TotalFee,
+		"updated_at": now,
+	}
+	for _, w := range weeks {
+		set[fmt.Sprintf("weeks.%d", w)] = data.Weeks[w]
+	}
 
 	filter := bson.M{
-		"_id": id,
+		"_id":        data.ID,
+		"updated_at": data.UpdatedAt,
+		"is_deleted": bson.M{"$ne": true},
 	}
 
-	update := bson.M{"$set": data}
+	update := bson.M{
+		"$set":  set,
+		"$push": bson.M{"adjustments": adjustment},
+	}
 
-	_, err := repository.programPlanerCollection.UpdateOne(ctx, filter, update)
+	result, err := repository.programPlanerCollection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return err
 	}
 
+	if result.MatchedCount == 0 {
+		return ErrPlannerConflict
+	}
+
+	data.UpdatedAt = now
+	data.Adjustments = append(data.Adjustments, adjustment)
+
 	return nil
+}
+
+func (repository *programPlannerRepository) CreateTimeSlot(ctx context.Context, slot *TimeSlot) error {
+
+	_, err := repository.timeSlotCollection.InsertOne(ctx, slot)
+	return err
+
+}
+
+func (repository *programPlannerRepository) GetTimeSlots(ctx context.Context, organizationID string) ([]*TimeSlot, error) {
+
+	filter := bson.M{
+		"organization_id": organizationID,
+		"is_deleted":      bson.M{"$ne": true},
+	}
+
+	findOpts := options.Find().SetSort(bson.D{{Key: "start_time", Value: 1}, {Key: "name", Value: 1}})
+
+	cursor, err := repository.timeSlotCollection.Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var slots []*TimeSlot
+	if err := cursor.All(ctx, &slots); err != nil {
+		return nil, err
+	}
+
+	return slots, nil
+
+}
+
+func (repository *programPlannerRepository) GetTimeSlot(ctx context.Context, id primitive.ObjectID) (*TimeSlot, error) {
+
+	var slot TimeSlot
+
+	filter := bson.M{
+		"_id":        id,
+		"is_deleted": bson.M{"$ne": true},
+	}
+
+	err := repository.timeSlotCollection.FindOne(ctx, filter).Decode(&slot)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("time slot not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &slot, nil
+
+}
+
+func (repository *programPlannerRepository) UpdateTimeSlot(ctx context.Context, slot *TimeSlot) error {
+
+	_, err := repository.timeSlotCollection.UpdateOne(ctx, bson.M{"_id": slot.ID}, bson.M{"$set": slot})
+	return err
+
+}
+
+func (repository *programPlannerRepository) DeleteTimeSlot(ctx context.Context, id primitive.ObjectID) error {
+
+	update := bson.M{"$set": bson.M{"is_deleted": true, "updated_at": time.Now()}}
+
+	_, err := repository.timeSlotCollection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+
+}
+
+// CountPlanersUsingSlot counts the planners from the given month on that select or book the
+// slot, by ID or, for planners saved before slots had one, by name and start time.
+func (repository *programPlannerRepository) CountPlanersUsingSlot(ctx context.Context, slot *TimeSlot, month int, year int) (int64, error) {
+
+	filter := bson.M{
+		"organization_id": slot.OrganizationID,
+		"is_deleted":      bson.M{"$ne": true},
+		"$and": bson.A{
+			bson.M{"$or": bson.A{
+				bson.M{"year": bson.M{"$gt": year}},
+				bson.M{"year": year, "month": bson.M{"$gte": month}},
+			}},
+			bson.M{"$or": bson.A{
+				bson.M{"selected_slots.slot_id": slot.ID.Hex()},
+				bson.M{"weeks.slots.slot_id": slot.ID.Hex()},
+				bson.M{"selected_slots": bson.M{"$elemMatch": bson.M{"slot_id": bson.M{"$exists": false}, "time_range": slot.Name}}},
+				bson.M{"weeks.slots": bson.M{"$elemMatch": bson.M{"slot_id": bson.M{"$exists": false}, "time": slot.StartTime}}},
+			}},
+		},
+	}
+
+	return repository.programPlanerCollection.CountDocuments(ctx, filter)
+
+}
+
+// SeedSlotCounter creates the counter of a slot and date with the bookings planners already
+// hold, unless it exists.
+func (repository *programPlannerRepository) SeedSlotCounter(ctx context.Context, counter *SlotCounter) error {
+
+	update := bson.M{"$setOnInsert": counter}
+
+	_, err := repository.slotCounterCollection.UpdateOne(ctx, bson.M{"_id": counter.ID}, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// Seeded by a concurrent booking.
+		return nil
+	}
+
+	return err
+}
+
+// ReserveSlot takes one place on the counter if the slot has room. A capacity of 0 means
+// unlimited; the booking is still counted.
+func (repository *programPlannerRepository) ReserveSlot(ctx context.Context, id string, capacity int) (bool, error) {
+
+	filter := bson.M{"_id": id}
+	if capacity > 0 {
+		filter["booked"] = bson.M{"$lt": capacity}
+	}
+
+	update := bson.M{
+		"$inc": bson.M{"booked": 1},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+
+	result, err := repository.slotCounterCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount == 1, nil
+}
+
+// ReleaseSlot gives a place on the counter back.
+func (repository *programPlannerRepository) ReleaseSlot(ctx context.Context, id string) error {
+
+	filter := bson.M{"_id": id, "booked": bson.M{"$gt": 0}}
+
+	update := bson.M{
+		"$inc": bson.M{"booked": -1},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+
+	_, err := repository.slotCounterCollection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (repository *programPlannerRepository) GetProgramPlanersForMonths(ctx context.Context, organizationID string, months [][2]int) ([]*ProgramPlaner, error) {
+
+	if len(months) == 0 {
+		return nil, nil
+	}
+
+	periods := make(bson.A, 0, len(months))
+	for _, month := range months {
+		periods = append(periods, bson.M{"year": month[0], "month": month[1]})
+	}
+
+	filter := bson.M{
+		"organization_id": organizationID,
+		"is_deleted":      bson.M{"$ne": true},
+		"$or":             periods,
+	}
+
+	cursor, err := repository.programPlanerCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var programPlaners []*ProgramPlaner
+	if err := cursor.All(ctx, &programPlaners); err != nil {
+		return nil, err
+	}
+
+	return programPlaners, nil
+
+}
+
+// GetStudentProgramPlaners returns the student's planners for the month in every organization.
+func (repository *programPlannerRepository) GetStudentProgramPlaners(ctx context.Context, studentID string, month int, year int) ([]*ProgramPlaner, error) {
+
+	filter := bson.M{
+		"student_id": studentID,
+		"month":      month,
+		"year":       year,
+		"is_deleted": bson.M{"$ne": true},
+	}
+
+	cursor, err := repository.programPlanerCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var programPlaners []*ProgramPlaner
+	if err := cursor.All(ctx, &programPlaners); err != nil {
+		return nil, err
+	}
+
+	return programPlaners, nil
+
+}
+
+func (repository *programPlannerRepository) CreateClosure(ctx context.Context, closure *Closure) error {
+
+	_, err := repository.closureCollection.InsertOne(ctx, closure)
+	return err
+
+}
+
+// GetClosures returns the closures overlapping the given dates, both inclusive.
+func (repository *programPlannerRepository) GetClosures(ctx context.Context, organizationID string, from time.Time, to time.Time) ([]*Closure, error) {
+
+	filter := bson.M{
+		"organization_id": organizationID,
+		"is_deleted":      bson.M{"$ne": true},
+		"start_date":      bson.M{"$lte": to},
+		"end_date":        bson.M{"$gte": from},
+	}
+
+	findOpts := options.Find().SetSort(bson.D{{Key: "start_date", Value: 1}, {Key: "created_at", Value: 1}})
+
+	cursor, err := repository.closureCollection.Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var closures []*Closure
+	if err := cursor.All(ctx, &closures); err != nil {
+		return nil, err
+	}
+
+	return closures, nil
+
+}
+
+func (repository *programPlannerRepository) GetClosure(ctx context.Context, id primitive.ObjectID) (*Closure, error) {
+
+	var closure Closure
+
+	filter := bson.M{
+		"_id":        id,
+		"is_deleted": bson.M{"$ne": true},
+	}
+
+	err := repository.closureCollection.FindOne(ctx, filter).Decode(&closure)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("closure not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &closure, nil
+
+}
+
+func (repository *programPlannerRepository) DeleteClosure(ctx context.Context, id primitive.ObjectID) error {
+
+	update := bson.M{"$set": bson.M{"is_deleted": true, "updated_at": time.Now()}}
+
+	_, err := repository.closureCollection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+
+}
+
+// GetProgramPlanerForMonth returns the student's planner for the month, or nil.
+func (repository *programPlannerRepository) GetProgramPlanerForMonth(ctx context.Context, organizationID string, studentID string, month int, year int) (*ProgramPlaner, error) {
+
+	var programPlaner ProgramPlaner
+
+	filter := bson.M{
+		"organization_id": organizationID,
+		"student_id":      studentID,
+		"month":           month,
+		"year":            year,
+		"is_deleted":      bson.M{"$ne": true},
+	}
+
+	err := repository.programPlanerCollection.FindOne(ctx, filter).Decode(&programPlaner)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &programPlaner, nil
 
 }
@@ -7,12 +7,14 @@ type CreateProgramPlanerRequest struct {
 	Year           int            `json:"year" bson:"year"`
 	TotalFee       float64        `json:"total_fee" bson:"total_fee"`
 	SelectedSlots  []SelectedSlot `json:"selected_slots" bson:"selected_slots"`
+	Waitlist       bool           `json:"waitlist" bson:"waitlist"` // waitlist full slots instead of rejecting
 }
 
 type UpdateProgramPlanerRequest struct {
 	Month         int            `json:"month" bson:"month"`
 	Year          int            `json:"year" bson:"year"`
 	SelectedSlots []SelectedSlot `json:"selected_slots" bson:"selected_slots"`
+	Waitlist      bool           `json:"waitlist" bson:"waitlist"` // waitlist full slots instead of rejecting
 }
 
 type UpdateWeekProgramPlanerRequest struct {
@@ -20,4 +22,36 @@ type UpdateWeekProgramPlanerRequest struct {
 	DayOfWeek  string  `json:"day_of_week" binding:"required"`
 	Time       string  `json:"time" binding:"required"`
 	SlotFee    float64 `json:"slot_fee" binding:"required"`
+	Waitlist   bool    `json:"waitlist"`
+}
+
+type CreateTimeSlotRequest struct {
+	OrganizationID string   `json:"organization_id" bson:"organization_id"`
+	Name           string   `json:"name" bson:"name"`
+	StartTime      string   `json:"start_time" bson:"start_time"`
+	EndTime        string   `json:"end_time" bson:"end_time"`
+	Weekdays       []string `json:"weekdays" bson:"weekdays"`
+	DefaultFee     float64  `json:"default_fee" bson:"default_fee"`
+	Capacity       int      `json:"capacity" bson:"capacity"`
+}
+
+type UpdateTimeSlotRequest struct {
+	Name       *string  `json:"name" bson:"name"`
+	StartTime  *string  `json:"start_time" bson:"start_time"`
+	EndTime    *string  `json:"end_time" bson:"end_time"`
+	Weekdays   []string `json:"weekdays" bson:"weekdays"`
+	DefaultFee *float64 `json:"default_fee" bson:"default_fee"`
+	Capacity   *int     `json:"capacity" bson:"capacity"`
+}
+
+type CreateClosureRequest struct {
+	OrganizationID string `json:"organization_id" bson:"organization_id"`
+	Name           string `json:"name" bson:"name"`
+	StartDate      string `json:"start_date" bson:"start_date"`
+	EndDate        string `json:"end_date" bson:"end_date"` // defaults to start_date
+}
+
+type SyncClosuresRequest struct {
+	OrganizationID string `json:"organization_id" bson:"organization_id"`
+	Year           int    `json:"year" bson:"year"`
 }
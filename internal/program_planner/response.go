@@ -0,0 +1,39 @@
+package program_planner
+
+type ClosureResponse struct {
+	Closure          *Closure `json:"closure"`
+	AdjustedPlanners int      `json:"adjusted_planners"`
+}
+
+type SyncClosuresResponse struct {
+	Created          int        `json:"created"`
+	Removed          int        `json:"removed"`
+	Unchanged        int        `json:"unchanged"`
+	AdjustedPlanners int        `json:"adjusted_planners"`
+	Closures         []*Closure `json:"closures"`
+}
+
+type OccupancyResponse struct {
+	OrganizationID string           `json:"organization_id"`
+	Month          int              `json:"month"`
+	Year           int              `json:"year"`
+	Slots          []*SlotOccupancy `json:"slots"`
+}
+
+type SlotOccupancy struct {
+	SlotID    string          `json:"slot_id,omitempty"`
+	Name      string          `json:"name"`
+	StartTime string          `json:"start_time"`
+	EndTime   string          `json:"end_time"`
+	Capacity  int             `json:"capacity"` // 0 means unlimited
+	Days      []*DayOccupancy `json:"days"`
+}
+
+type DayOccupancy struct {
+	Date       string `json:"date"`
+	Booked     int    `json:"booked"`
+	Waitlisted int    `json:"waitlisted"`
+	Available  *int   `json:"available"` // nil when the slot is unlimited
+	Full       bool   `json:"full"`
+	Closed     bool   `json:"closed"`
+}
@@ -2,6 +2,7 @@ package program_planner
 
 import (
 	"portal/internal/middleware"
+	"portal/pkg/constants"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,5 +16,22 @@ func RegisterRoutes(r *gin.Engine, handler *ProgramPlanerHandler) {
 		group.PUT("/:id", handler.UpdateProgramPlaner)
 		group.DELETE("/:id", handler.DeleteProgramPlaner)
 		group.POST("/week/:id", handler.UpdateProgramPlanerWeek)
+		group.GET("/occupancy", handler.GetOccupancy)
+
+		group.GET("/slots", handler.GetTimeSlots)
+		slots := group.Group("/slots", middleware.RequireRoles(constants.StaffRoles...))
+		{
+			slots.POST("", handler.CreateTimeSlot)
+			slots.PUT("/:id", handler.UpdateTimeSlot)
+			slots.DELETE("/:id", handler.DeleteTimeSlot)
+		}
+
+		group.GET("/closures", handler.GetClosures)
+		closures := group.Group("/closures", middleware.RequireRoles(constants.StaffRoles...))
+		{
+			closures.POST("", handler.CreateClosure)
+			closures.POST("/sync", handler.SyncClosures)
+			closures.DELETE("/:id", handler.DeleteClosure)
+		}
 	}
 }
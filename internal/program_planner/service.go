@@ -4,8 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"strconv"
+	"strings"
 	"time"
 
+	"portal/internal/attendance"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -13,19 +18,33 @@ type ProgramPlanerService interface {
 	CreateProgramPlaner(ctx context.Context, req *CreateProgramPlanerRequest, userID string) (string, error)
 	GetAllProgramPlaner(ctx context.Context) ([]*ProgramPlaner, error)
 	GetProgramPlaner(ctx context.Context, id string) (*ProgramPlaner, error)
+	GetProgramPlanerForMonth(ctx context.Context, organizationID string, studentID string, month int, year int) (*ProgramPlaner, error)
 	UpdateProgramPlaner(ctx context.Context, req *UpdateProgramPlanerRequest, id string) error
 	DeleteProgramPlaner(ctx context.Context, id string) error
 
 	UpdateProgramPlanerWeek(ctx context.Context, req *UpdateWeekProgramPlanerRequest, id string) error
+	GetOccupancy(ctx context.Context, organizationID string, month string, year string) (*OccupancyResponse, error)
+
+	CreateTimeSlot(ctx context.Context, req *CreateTimeSlotRequest, userID string) (*TimeSlot, error)
+	GetTimeSlots(ctx context.Context, organizationID string) ([]*TimeSlot, error)
+	UpdateTimeSlot(ctx context.Context, id string, req *UpdateTimeSlotRequest) (*TimeSlot, error)
+	DeleteTimeSlot(ctx context.Context, id string) error
+
+	CreateClosure(ctx context.Context, req *CreateClosureRequest, userID string) (*ClosureResponse, error)
+	GetClosures(ctx context.Context, organizationID string, from string, to string) ([]*Closure, error)
+	DeleteClosure(ctx context.Context, id string, userID string) (*ClosureResponse, error)
+	SyncClosures(ctx context.Context, req *SyncClosuresRequest, userID string) (*SyncClosuresResponse, error)
 }
 
 type programPlannerService struct {
 	ProgramPlanerRepository ProgramPlanerRepository
+	HolidayService          attendance.AttendanceService
 }
 
-func NewProgramPlanerService(ProgramPlanerRepository ProgramPlanerRepository) ProgramPlanerService {
+func NewProgramPlanerService(ProgramPlanerRepository ProgramPlanerRepository, holidayService attendance.AttendanceService) ProgramPlanerService {
 	return &programPlannerService{
 		ProgramPlanerRepository: ProgramPlanerRepository,
+		HolidayService:          holidayService,
 	}
 }
 
@@ -44,88 +63,22 @@ func (service *programPlannerService) CreateProgramPlaner(ctx context.Context, r
 		return "", fmt.Errorf("selected_slots is required")
 	}
 
-	var totalFee float64
-	for _, slot := range req.SelectedSlots {
-		totalFee += slot.Fee
+	catalog, err := service.getCatalog(ctx, req.OrganizationID)
+	if err != nil {
+		return "", err
 	}
 
-	rawWeeks := getWeeksInMonth(req.Year, req.Month)
-
-	weeks := make([]WeekPlan, 0)
-	for _, w := range rawWeeks {
-		_, isoWeek := w.WeekStart.ISOWeek()
-		wp := WeekPlan{
-			WeekNumber: isoWeek,
-			WeekStart:  w.WeekStart,
-			WeekEnd:    w.WeekEnd,
-			WeekFee:    0,
-			Slots:      []DailySlot{},
-		}
-
-		dayCodes := []string{"mo", "tu", "we", "th", "fr", "sa", "su"}
-		baseMonday := w.WeekStart
-		for baseMonday.Weekday() != time.Monday {
-			baseMonday = baseMonday.AddDate(0, 0, -1)
-		}
-		dayDateMap := map[string]time.Time{}
-		for i, code := range dayCodes {
-			dayDate := baseMonday.AddDate(0, 0, i)
-			dayDateMap[code] = dayDate
-		}
-
-		for _, sel := range req.SelectedSlots {
-
-			if !sel.Selected {
-				continue
-			}
-
-			var timePart string
-			switch sel.TimeRange {
-			case "8:00 M-F":
-				timePart = "8:00"
-			case "11:00 M-F":
-				timePart = "11:00"
-			case "17:00 M-F":
-				timePart = "17:00"
-			case "20:00 M-F":
-				timePart = "20:00"
-			default:
-				continue
-			}
-
-			for _, code := range sel.Days {
-				dayDate := dayDateMap[code]
-				if dayDate.Before(w.WeekStart) || dayDate.After(w.WeekEnd) {
-					continue
-				}
-				wp.Slots = append(wp.Slots, DailySlot{
-					DayOfWeek:  code,
-					Time:       timePart,
-					Selected:   true,
-					Fee:        0,
-					IsOriginal: true,
-				})
-			}
+	selectedSlots, err := resolveSelections(req.SelectedSlots, catalog)
+	if err != nil {
+		return "", err
+	}
 
-			// for _, extra := range sel.Days {
-			// 	if extra != "sa" && extra != "su" {
-			// 		continue
-			// 	}
-			// 	dayDate := dayDateMap[extra]
-			// 	if dayDate.Before(w.WeekStart) || dayDate.After(w.WeekEnd) {
-			// 		continue
-			// 	}
-			// 	wp.Slots = append(wp.Slots, DailySlot{
-			// 		DayOfWeek:  extra,
-			// 		Time:       timePart,
-			// 		Selected:   true,
-			// 		Fee:        0,
-			// 		IsOriginal: true,
-			// 	})
-			// }
-		}
+	weeks := generateWeeks(req.Year, req.Month, selectedSlots, catalog)
 
-		weeks = append(weeks, wp)
+	monthStart := time.Date(req.Year, time.Month(req.Month), 1, 0, 0, 0, 0, time.UTC)
+	closures, err := service.ProgramPlanerRepository.GetClosures(ctx, req.OrganizationID, monthStart, monthStart.AddDate(0, 1, -1))
+	if err != nil {
+		return "", err
 	}
 
 	programPlaner := &ProgramPlaner{
@@ -134,8 +87,7 @@ func (service *programPlannerService) CreateProgramPlaner(ctx context.Context, r
 		OrganizationID: req.OrganizationID,
 		Month:          req.Month,
 		Year:           req.Year,
-		TotalFee:       totalFee,
-		SelectedSlots:  req.SelectedSlots,
+		SelectedSlots:  selectedSlots,
 		Weeks:          weeks,
 		CreatedBy:      userID,
 		IsDeleted:      false,
@@ -143,7 +95,41 @@ func (service *programPlannerService) CreateProgramPlaner(ctx context.Context, r
 		UpdatedAt:      time.Now(),
 	}
 
-	return service.ProgramPlanerRepository.CreateProgramPlaner(ctx, programPlaner)
+	// Closed days get no slots; their share of the fee comes off the month.
+	reconcileClosures(programPlaner, closures, catalog)
+
+	// The new planner replaces the student's planner for the month, and keeps its places.
+	replaced, err := service.ProgramPlanerRepository.GetProgramPlanerForMonth(ctx, req.OrganizationID, req.StudentID, req.Month, req.Year)
+	if err != nil {
+		return "", err
+	}
+
+	var held []booking
+	if replaced != nil {
+		held = heldBookings(replaced, catalog)
+	}
+
+	// The replaced planner's bookings are not the student's other bookings.
+	sameOrganization := func(other *ProgramPlaner) bool {
+		return other.OrganizationID == programPlaner.OrganizationID
+	}
+
+	reserved, err := service.bookSlots(ctx, programPlaner, held, catalog, req.Waitlist, sameOrganization)
+	if err != nil {
+		return "", err
+	}
+
+	programPlaner.TotalFee = roundFee(service.calculateTotalFee(programPlaner.Weeks, programPlaner.SelectedSlots))
+
+	id, err := service.ProgramPlanerRepository.CreateProgramPlaner(ctx, programPlaner)
+	if err != nil {
+		service.giveBack(ctx, programPlaner.OrganizationID, reserved)
+		return "", err
+	}
+
+	service.releaseBookings(ctx, programPlaner.OrganizationID, catalog, removedBookings(held, heldBookings(programPlaner, catalog)))
+
+	return id, nil
 }
 
 func (service *programPlannerService) GetAllProgramPlaner(ctx context.Context) ([]*ProgramPlaner, error) {
@@ -160,6 +146,10 @@ func (service *programPlannerService) GetProgramPlaner(ctx context.Context, id s
 	return service.ProgramPlanerRepository.GetProgramPlaner(ctx, objectID)
 }
 
+func (service *programPlannerService) GetProgramPlanerForMonth(ctx context.Context, organizationID string, studentID string, month int, year int) (*ProgramPlaner, error) {
+	return service.ProgramPlanerRepository.GetProgramPlanerForMonth(ctx, organizationID, studentID, month, year)
+}
+
 func (service *programPlannerService) UpdateProgramPlaner(ctx context.Context, req *UpdateProgramPlanerRequest, id string) error {
 
 	objectID, err := primitive.ObjectIDFromHex(id)
@@ -172,6 +162,8 @@ func (service *programPlannerService) UpdateProgramPlaner(ctx context.Context, r
 		return err
 	}
 
+	month, year := program.Month, program.Year
+
 	if req.Month != 0 {
 		program.Month = req.Month
 	}
@@ -180,19 +172,60 @@ func (service *programPlannerService) UpdateProgramPlaner(ctx context.Context, r
 		program.Year = req.Year
 	}
 
+	// Moving the planner must not leave the student two planners for one month.
+	if program.Month != month || program.Year != year {
+		existing, err := service.ProgramPlanerRepository.GetProgramPlanerForMonth(ctx, program.OrganizationID, program.StudentID, program.Month, program.Year)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return fmt.Errorf("a program planer for %d-%02d already exists", program.Year, program.Month)
+		}
+	}
+
+	catalog, err := service.getCatalog(ctx, program.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	held := heldBookings(program, catalog)
+
 	if len(req.SelectedSlots) != 0 {
-		program.SelectedSlots = req.SelectedSlots
+		program.SelectedSlots, err = resolveSelections(req.SelectedSlots, catalog)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The weeks are laid out from the selections for the month, so changing either starts
+	// them over; week edits made for the old selections do not carry over.
+	if len(req.SelectedSlots) != 0 || req.Month != 0 || req.Year != 0 {
+		program.Weeks = generateWeeks(program.Year, program.Month, program.SelectedSlots, catalog)
+	}
+
+	monthStart := time.Date(program.Year, time.Month(program.Month), 1, 0, 0, 0, 0, time.UTC)
+	closures, err := service.ProgramPlanerRepository.GetClosures(ctx, program.OrganizationID, monthStart, monthStart.AddDate(0, 1, -1))
+	if err != nil {
+		return err
 	}
 
-	var totalFeeUpdate float64
-	for _, slot := range program.SelectedSlots {
-		totalFeeUpdate += slot.Fee
+	reconcileClosures(program, closures, catalog)
+
+	reserved, err := service.bookSlots(ctx, program, held, catalog, req.Waitlist, nil)
+	if err != nil {
+		return err
 	}
 
-	program.TotalFee = totalFeeUpdate
+	program.TotalFee = roundFee(service.calculateTotalFee(program.Weeks, program.SelectedSlots))
+
+	if err := service.ProgramPlanerRepository.UpdateProgramPlaner(ctx, program, objectID); err != nil {
+		service.giveBack(ctx, program.OrganizationID, reserved)
+		return err
+	}
 
-	return service.ProgramPlanerRepository.UpdateProgramPlaner(ctx, program, objectID)
+	service.releaseBookings(ctx, program.OrganizationID, catalog, removedBookings(held, heldBookings(program, catalog)))
 
+	return nil
 }
 
 func (service *programPlannerService) DeleteProgramPlaner(ctx context.Context, id string) error {
@@ -202,7 +235,23 @@ func (service *programPlannerService) DeleteProgramPlaner(ctx context.Context, i
 		return err
 	}
 
-	return service.ProgramPlanerRepository.DeleteProgramPlaner(ctx, objectID)
+	program, err := service.ProgramPlanerRepository.GetProgramPlaner(ctx, objectID)
+	if err != nil {
+		return err
+	}
+
+	catalog, err := service.getCatalog(ctx, program.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	if err := service.ProgramPlanerRepository.DeleteProgramPlaner(ctx, objectID); err != nil {
+		return err
+	}
+
+	service.releaseBookings(ctx, program.OrganizationID, catalog, heldBookings(program, catalog))
+
+	return nil
 }
 
 func (service *programPlannerService) UpdateProgramPlanerWeek(ctx context.Context, req *UpdateWeekProgramPlanerRequest, id string) error {
@@ -234,69 +283,136 @@ func (service *programPlannerService) UpdateProgramPlanerWeek(ctx context.Contex
 		return fmt.Errorf("week %d not found", req.WeekNumber)
 	}
 
+	catalog, err := service.getCatalog(ctx, program.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	if isClosed(program.Weeks[weekIndex], req.DayOfWeek) {
+		return fmt.Errorf("the organization is closed on %q of week %d", req.DayOfWeek, req.WeekNumber)
+	}
+
+	catalogSlot := findSlotAt(catalog, req.DayOfWeek, req.Time)
+	if catalogSlot == nil {
+		return fmt.Errorf("no time slot at %s on %q in the catalog", req.Time, req.DayOfWeek)
+	}
+
+	slotID := ""
+	if !catalogSlot.ID.IsZero() {
+		slotID = catalogSlot.ID.Hex()
+	}
+
+	date, ok := slotDate(program.Weeks[weekIndex], req.DayOfWeek)
+	if !ok {
+		return fmt.Errorf("week %d has no %q in this month", req.WeekNumber, req.DayOfWeek)
+	}
+
 	slotIndex := -1
 
 	for i, slot := range program.Weeks[weekIndex].Slots {
-		if slot.DayOfWeek == req.DayOfWeek && slot.Time == req.Time {
+		if slot.DayOfWeek == req.DayOfWeek && sameClock(slot.Time, req.Time) {
 			slotIndex = i
 			break
 		}
 	}
 
 	slotFee := req.SlotFee
+	week := &program.Weeks[weekIndex]
+	now := time.Now()
+
+	var reserved, released []booking
+
+	switch {
+	case slotIndex != -1 && week.Slots[slotIndex].Waitlisted:
+		// Toggling a waitlisted slot leaves the waitlist; a dropped original slot keeps
+		// its credit.
+		slot := &week.Slots[slotIndex]
+		if slot.IsOriginal {
+			slot.Waitlisted = false
+			slot.WaitlistedAt = nil
+			slot.WaitlistFee = 0
+		} else {
+			week.Slots = append(week.Slots[:slotIndex], week.Slots[slotIndex+1:]...)
+		}
 
-	if slotIndex != -1 {
-		slot := &program.Weeks[weekIndex].Slots[slotIndex]
+	case slotIndex != -1 && week.Slots[slotIndex].Selected:
+		// A dropped original slot is credited its share of the monthly fee, not the
+		// client's slot fee.
+		slot := &week.Slots[slotIndex]
+		slot.Selected = false
+		slot.Fee = 0
 		if slot.IsOriginal {
-			if slot.Selected {
-				slot.Selected = false
-				slot.Fee = -slotFee
+			if selection := findSelection(program.SelectedSlots, catalog, *slot); selection != nil {
+				slot.Fee = -roundFee(sessionFee(*selection, program.Year, program.Month))
+			}
+		}
+		if held := catalogSlotFor(catalog, *slot); held != nil {
+			released = append(released, booking{Date: date, Slot: held})
+		}
+
+	default:
+		waitlisted, err := service.reserveBooking(ctx, program, catalog, catalogSlot, date, req.Waitlist)
+		if err != nil {
+			return err
+		}
+
+		if waitlisted {
+			// The slot stays unbooked, with whatever credit it already had.
+			if slotIndex != -1 {
+				week.Slots[slotIndex].Waitlisted = true
+				week.Slots[slotIndex].WaitlistedAt = &now
+				if !week.Slots[slotIndex].IsOriginal {
+					week.Slots[slotIndex].WaitlistFee = slotFee
+				}
 			} else {
-				slot.Selected = true
-				slot.Fee = 0
+				week.Slots = append(week.Slots, DailySlot{
+					SlotID:       slotID,
+					DayOfWeek:    req.DayOfWeek,
+					Time:         catalogSlot.StartTime,
+					Waitlisted:   true,
+					WaitlistedAt: &now,
+					WaitlistFee:  slotFee,
+				})
 			}
-		} else {
-			if slot.Selected {
-				slot.Selected = false
+			break
+		}
+
+		reserved = append(reserved, booking{Date: date, Slot: catalogSlot})
+
+		if slotIndex != -1 {
+			slot := &week.Slots[slotIndex]
+			slot.Selected = true
+			if slot.IsOriginal {
 				slot.Fee = 0
 			} else {
-				slot.Selected = true
 				slot.Fee = slotFee
 			}
+		} else {
+			// Extra slots are booked for this week only and charged through the week fee.
+			newSlot := DailySlot{
+				SlotID:     slotID,
+				DayOfWeek:  req.DayOfWeek,
+				Time:       catalogSlot.StartTime,
+				Selected:   true,
+				Fee:        slotFee,
+				IsOriginal: false,
+			}
+			week.Slots = append(week.Slots, newSlot)
 		}
-	} else {
-		newSlot := DailySlot{
-			DayOfWeek:  req.DayOfWeek,
-			Time:       req.Time,
-			Selected:   true,
-			Fee:        slotFee,
-			IsOriginal: false,
-		}
-		program.SelectedSlots = append(program.SelectedSlots, SelectedSlot{
-			TimeRange: req.Time,
-			Days:      []string{req.DayOfWeek},
-			Selected:  true,
-			Fee:       slotFee,
-		})
-		program.Weeks[weekIndex].Slots = append(program.Weeks[weekIndex].Slots, newSlot)
 	}
 
-	program.Weeks[weekIndex].WeekFee = service.calculateWeekFee(program.Weeks[weekIndex].Slots)
+	program.Weeks[weekIndex].WeekFee = calculateWeekFee(program.Weeks[weekIndex])
 
-	program.TotalFee = service.calculateTotalFee(program.Weeks, program.SelectedSlots)
+	program.TotalFee = roundFee(service.calculateTotalFee(program.Weeks, program.SelectedSlots))
 
-	return service.ProgramPlanerRepository.UpdateProgramPlanerWeek(ctx, program, objectID)
-}
-
-func (service *programPlannerService) calculateWeekFee(slots []DailySlot) float64 {
-
-	total := 0.0
-
-	for _, s := range slots {
-		total += s.Fee
+	if err := service.ProgramPlanerRepository.UpdateProgramPlanerWeek(ctx, program, objectID); err != nil {
+		service.giveBack(ctx, program.OrganizationID, reserved)
+		return err
 	}
 
-	return total
+	service.releaseBookings(ctx, program.OrganizationID, catalog, released)
+
+	return nil
 }
 
 func (service *programPlannerService) calculateTotalFee(weeks []WeekPlan, selectedSlots []SelectedSlot) float64 {
@@ -315,6 +431,913 @@ func (service *programPlannerService) calculateTotalFee(weeks []WeekPlan, select
 	return baseFee
 }
 
+func (service *programPlannerService) CreateTimeSlot(ctx context.Context, req *CreateTimeSlotRequest, userID string) (*TimeSlot, error) {
+
+	if req.OrganizationID == "" {
+		return nil, fmt.Errorf("organization_id is required")
+	}
+
+	slot := &TimeSlot{
+		ID:             primitive.NewObjectID(),
+		OrganizationID: req.OrganizationID,
+		Name:           strings.TrimSpace(req.Name),
+		StartTime:      req.StartTime,
+		EndTime:        req.EndTime,
+		Weekdays:       req.Weekdays,
+		DefaultFee:     req.DefaultFee,
+		Capacity:       req.Capacity,
+		CreatedBy:      userID,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+
+	if err := service.checkTimeSlot(ctx, slot); err != nil {
+		return nil, err
+	}
+
+	if err := service.ProgramPlanerRepository.CreateTimeSlot(ctx, slot); err != nil {
+		return nil, err
+	}
+
+	return slot, nil
+}
+
+func (service *programPlannerService) GetTimeSlots(ctx context.Context, organizationID string) ([]*TimeSlot, error) {
+
+	if organizationID == "" {
+		return nil, fmt.Errorf("organization_id is required")
+	}
+
+	return service.getCatalog(ctx, organizationID)
+}
+
+func (service *programPlannerService) UpdateTimeSlot(ctx context.Context, id string, req *UpdateTimeSlotRequest) (*TimeSlot, error) {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	slot, err := service.ProgramPlanerRepository.GetTimeSlot(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := *slot
+
+	if req.Name != nil {
+		slot.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.StartTime != nil {
+		slot.StartTime = *req.StartTime
+	}
+	if req.EndTime != nil {
+		slot.EndTime = *req.EndTime
+	}
+	if req.Weekdays != nil {
+		slot.Weekdays = req.Weekdays
+	}
+	if req.DefaultFee != nil {
+		slot.DefaultFee = *req.DefaultFee
+	}
+	if req.Capacity != nil {
+		slot.Capacity = *req.Capacity
+	}
+	slot.UpdatedAt = time.Now()
+
+	if err := service.checkTimeSlot(ctx, slot); err != nil {
+		return nil, err
+	}
+
+	// Planners match their slots by name, time and weekday, so booked slots keep them.
+	if retimed(&previous, slot) {
+		if err := service.checkSlotUnused(ctx, &previous); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := service.ProgramPlanerRepository.UpdateTimeSlot(ctx, slot); err != nil {
+		return nil, err
+	}
+
+	return slot, nil
+}
+
+func (service *programPlannerService) DeleteTimeSlot(ctx context.Context, id string) error {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	slot, err := service.ProgramPlanerRepository.GetTimeSlot(ctx, objectID)
+	if err != nil {
+		return err
+	}
+
+	catalog, err := service.ProgramPlanerRepository.GetTimeSlots(ctx, slot.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	// Without slots of its own the organization would fall back to the default catalog.
+	if len(catalog) <= 1 {
+		return fmt.Errorf("cannot delete the last time slot of the organization")
+	}
+
+	if err := service.checkSlotUnused(ctx, slot); err != nil {
+		return err
+	}
+
+	return service.ProgramPlanerRepository.DeleteTimeSlot(ctx, objectID)
+}
+
+// checkSlotUnused rejects changes to a slot that planners of this month or later book.
+func (service *programPlannerService) checkSlotUnused(ctx context.Context, slot *TimeSlot) error {
+
+	now := time.Now()
+
+	count, err := service.ProgramPlanerRepository.CountPlanersUsingSlot(ctx, slot, int(now.Month()), now.Year())
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return fmt.Errorf("%w: %q is booked by %d planers", ErrTimeSlotInUse, slot.Name, count)
+	}
+
+	return nil
+}
+
+// checkTimeSlot validates the slot and keeps names unique, and times apart, within the
+// organization.
+func (service *programPlannerService) checkTimeSlot(ctx context.Context, slot *TimeSlot) error {
+
+	if err := validateTimeSlot(slot); err != nil {
+		return err
+	}
+
+	existing, err := service.ProgramPlanerRepository.GetTimeSlots(ctx, slot.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	for _, other := range existing {
+		if other.ID == slot.ID {
+			continue
+		}
+		if strings.EqualFold(other.Name, slot.Name) {
+			return fmt.Errorf("time slot %q already exists", slot.Name)
+		}
+		if sharesWeekday(other, slot) && overlaps(other, slot) {
+			return fmt.Errorf("time slot %q overlaps %q", slot.Name, other.Name)
+		}
+	}
+
+	return nil
+}
+
+// getCatalog returns the organization's slot catalog, or the default slots if it has none.
+func (service *programPlannerService) getCatalog(ctx context.Context, organizationID string) ([]*TimeSlot, error) {
+
+	slots, err := service.ProgramPlanerRepository.GetTimeSlots(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(slots) == 0 {
+		return defaultTimeSlots(organizationID), nil
+	}
+
+	return slots, nil
+}
+
+// bookSlots checks the slots of a planner against the student's other bookings, leaving out
+// the planners skip matches, and reserves a place in every slot it does not hold yet; held
+// lists what the planner, or the one it replaces, held before. Full slots are waitlisted when asked for, with their share
+// of the monthly fee credited; otherwise the places reserved are given back and the planner
+// is rejected. It returns the places reserved.
+func (service *programPlannerService) bookSlots(ctx context.Context, program *ProgramPlaner, held []booking, catalog []*TimeSlot, waitlist bool, skip func(*ProgramPlaner) bool) ([]booking, error) {
+
+	others, err := service.studentBookings(ctx, program, skip)
+	if err != nil {
+		return nil, err
+	}
+
+	own := plannerBookings(program, catalog)
+	if err := findClash(own, others); err != nil {
+		return nil, err
+	}
+	for i := range own {
+		if err := findClash(own[i:i+1], own[i+1:]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := service.seedCounters(ctx, program.OrganizationID, program.Month, program.Year, catalog, heldBookings(program, catalog)); err != nil {
+		return nil, err
+	}
+
+	holding := map[string]int{}
+	for _, b := range held {
+		holding[occupancyKey(b.Slot, b.Date)]++
+	}
+
+	var reserved []booking
+	var full []string
+	now := time.Now()
+
+	eachBookedSlot(program, func(slot *DailySlot, date time.Time) {
+
+		catalogSlot := catalogSlotFor(catalog, *slot)
+		if err != nil || catalogSlot == nil {
+			return
+		}
+
+		b := booking{Date: date, Slot: catalogSlot}
+		if key := occupancyKey(catalogSlot, date); holding[key] > 0 {
+			holding[key]--
+			return
+		}
+
+		var ok bool
+		ok, err = service.ProgramPlanerRepository.ReserveSlot(ctx, counterID(program.OrganizationID, b), catalogSlot.Capacity)
+		if err != nil {
+			return
+		}
+		if ok {
+			reserved = append(reserved, b)
+			return
+		}
+
+		if !waitlist {
+			full = append(full, fmt.Sprintf("%s on %s", catalogSlot.Name, date.Format("2006-01-02")))
+			return
+		}
+
+		if !slot.IsOriginal {
+			slot.WaitlistFee = slot.Fee
+			slot.Fee = 0
+		} else if selection := findSelection(program.SelectedSlots, catalog, *slot); selection != nil {
+			slot.Fee = -roundFee(sessionFee(*selection, program.Year, program.Month))
+		}
+		slot.Selected = false
+		slot.Waitlisted = true
+		slot.WaitlistedAt = &now
+	})
+
+	if err != nil || len(full) > 0 {
+		service.giveBack(ctx, program.OrganizationID, reserved)
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("time slots are full: %s", strings.Join(full, ", "))
+	}
+
+	for w := range program.Weeks {
+		program.Weeks[w].WeekFee = calculateWeekFee(program.Weeks[w])
+	}
+
+	return reserved, nil
+}
+
+// reserveBooking checks one more booking of the planner's student and reserves its place.
+// It reports whether the booking has to be waitlisted because the slot is full.
+func (service *programPlannerService) reserveBooking(ctx context.Context, program *ProgramPlaner, catalog []*TimeSlot, catalogSlot *TimeSlot, date time.Time, waitlist bool) (bool, error) {
+
+	others, err := service.studentBookings(ctx, program, nil)
+	if err != nil {
+		return false, err
+	}
+
+	for _, own := range plannerBookings(program, catalog) {
+		if slotKey(own.Slot) != slotKey(catalogSlot) {
+			others = append(others, own)
+		}
+	}
+
+	b := booking{Date: date, Slot: catalogSlot}
+
+	if err := findClash([]booking{b}, others); err != nil {
+		return false, err
+	}
+
+	if err := service.seedCounters(ctx, program.OrganizationID, program.Month, program.Year, catalog, []booking{b}); err != nil {
+		return false, err
+	}
+
+	ok, err := service.ProgramPlanerRepository.ReserveSlot(ctx, counterID(program.OrganizationID, b), catalogSlot.Capacity)
+	if err != nil {
+		return false, err
+	}
+	if ok {
+		return false, nil
+	}
+
+	if !waitlist {
+		return false, fmt.Errorf("time slot %q is full on %s", catalogSlot.Name, date.Format("2006-01-02"))
+	}
+
+	return true, nil
+}
+
+// seedCounters makes sure the slots and dates of the bookings have a counter, starting from
+// what the saved planners of the month hold.
+func (service *programPlannerService) seedCounters(ctx context.Context, organizationID string, month int, year int, catalog []*TimeSlot, bookings []booking) error {
+
+	if len(bookings) == 0 {
+		return nil
+	}
+
+	planners, err := service.ProgramPlanerRepository.GetProgramPlanersForMonths(ctx, organizationID, [][2]int{{year, month}})
+	if err != nil {
+		return err
+	}
+
+	held := map[string]int{}
+	for _, planner := range planners {
+		for _, b := range heldBookings(planner, catalog) {
+			held[occupancyKey(b.Slot, b.Date)]++
+		}
+	}
+
+	seeded := map[string]bool{}
+
+	for _, b := range bookings {
+
+		key := occupancyKey(b.Slot, b.Date)
+		if seeded[key] {
+			continue
+		}
+		seeded[key] = true
+
+		counter := &SlotCounter{
+			ID:             counterID(organizationID, b),
+			OrganizationID: organizationID,
+			SlotKey:        slotKey(b.Slot),
+			Date:           b.Date,
+			Booked:         held[key],
+			UpdatedAt:      time.Now(),
+		}
+
+		if err := service.ProgramPlanerRepository.SeedSlotCounter(ctx, counter); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// giveBack releases places reserved for a change that was not saved.
+func (service *programPlannerService) giveBack(ctx context.Context, organizationID string, bookings []booking) {
+	for _, b := range bookings {
+		if err := service.ProgramPlanerRepository.ReleaseSlot(ctx, counterID(organizationID, b)); err != nil {
+			log.Printf("[programPlannerService] release %s: %v", counterID(organizationID, b), err)
+		}
+	}
+}
+
+// releaseBookings gives back the places a saved change let go of and offers each one to
+// the waitlist. The change is already saved, so failures are only logged.
+func (service *programPlannerService) releaseBookings(ctx context.Context, organizationID string, catalog []*TimeSlot, bookings []booking) {
+
+	service.giveBack(ctx, organizationID, bookings)
+
+	for _, b := range bookings {
+		if err := service.promoteWaitlist(ctx, organizationID, catalog, b); err != nil {
+			log.Printf("[programPlannerService] promote waitlist of %s: %v", counterID(organizationID, b), err)
+		}
+	}
+}
+
+// promoteWaitlist books a released place for the longest waiting student who can still
+// take it.
+func (service *programPlannerService) promoteWaitlist(ctx context.Context, organizationID string, catalog []*TimeSlot, b booking) error {
+
+	for attempt := 1; ; attempt++ {
+
+		planners, err := service.ProgramPlanerRepository.GetProgramPlanersForMonths(ctx, organizationID, [][2]int{{b.Date.Year(), int(b.Date.Month())}})
+		if err != nil {
+			return err
+		}
+
+		entry, err := service.nextOnWaitlist(ctx, planners, catalog, b)
+		if err != nil || entry == nil {
+			return err
+		}
+
+		ok, err := service.ProgramPlanerRepository.ReserveSlot(ctx, counterID(organizationID, b), b.Slot.Capacity)
+		if err != nil || !ok {
+			// Somebody booked the place in the meantime.
+			return err
+		}
+
+		entry.promote()
+		entry.Planner.TotalFee = roundFee(service.calculateTotalFee(entry.Planner.Weeks, entry.Planner.SelectedSlots))
+
+		err = service.ProgramPlanerRepository.UpdateProgramPlanerWeek(ctx, entry.Planner, entry.Planner.ID)
+		if err == nil {
+			return nil
+		}
+
+		service.giveBack(ctx, organizationID, []booking{b})
+
+		if !errors.Is(err, ErrPlannerConflict) || attempt == maxPlannerAttempts {
+			return err
+		}
+	}
+}
+
+// nextOnWaitlist returns the first waitlist entry for the booking whose student is not
+// booked elsewhere at the same time by now.
+func (service *programPlannerService) nextOnWaitlist(ctx context.Context, planners []*ProgramPlaner, catalog []*TimeSlot, b booking) (*waitlistEntry, error) {
+
+	for _, entry := range waitlistFor(planners, catalog, b) {
+
+		others, err := service.studentBookings(ctx, entry.Planner, nil)
+		if err != nil {
+			return nil, err
+		}
+		others = append(others, plannerBookings(entry.Planner, catalog)...)
+
+		if findClash([]booking{b}, others) == nil {
+			return &entry, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// studentBookings returns what the student has booked for the month in other planners,
+// in any organization.
+func (service *programPlannerService) studentBookings(ctx context.Context, program *ProgramPlaner, skip func(*ProgramPlaner) bool) ([]booking, error) {
+
+	planners, err := service.ProgramPlanerRepository.GetStudentProgramPlaners(ctx, program.StudentID, program.Month, program.Year)
+	if err != nil {
+		return nil, err
+	}
+
+	catalogs := map[string][]*TimeSlot{}
+	var bookings []booking
+
+	for _, other := range planners {
+
+		if other.ID == program.ID || (skip != nil && skip(other)) {
+			continue
+		}
+
+		catalog, ok := catalogs[other.OrganizationID]
+		if !ok {
+			catalog, err = service.getCatalog(ctx, other.OrganizationID)
+			if err != nil {
+				return nil, err
+			}
+			catalogs[other.OrganizationID] = catalog
+		}
+
+		bookings = append(bookings, plannerBookings(other, catalog)...)
+	}
+
+	return bookings, nil
+}
+
+// getOccupancy counts the organization's bookings for the month, leaving out the planners
+// skip matches.
+func (service *programPlannerService) getOccupancy(ctx context.Context, organizationID string, month int, year int, catalog []*TimeSlot, skip func(*ProgramPlaner) bool) (*occupancy, error) {
+
+	planners, err := service.ProgramPlanerRepository.GetProgramPlanersForMonths(ctx, organizationID, [][2]int{{year, month}})
+	if err != nil {
+		return nil, err
+	}
+
+	occupied := newOccupancy()
+
+	for _, planner := range planners {
+		if skip != nil && skip(planner) {
+			continue
+		}
+		for _, b := range plannerBookings(planner, catalog) {
+			occupied.add(b)
+		}
+	}
+
+	return occupied, nil
+}
+
+// GetOccupancy shows, for every slot of the catalog and every day of the month it runs,
+// how many students are booked and waiting.
+func (service *programPlannerService) GetOccupancy(ctx context.Context, organizationID string, month string, year string) (*OccupancyResponse, error) {
+
+	if organizationID == "" {
+		return nil, fmt.Errorf("organization_id is required")
+	}
+
+	monthNumber, err := strconv.Atoi(month)
+	if err != nil || monthNumber < 1 || monthNumber > 12 {
+		return nil, fmt.Errorf("month must be between 1 and 12")
+	}
+
+	yearNumber, err := strconv.Atoi(year)
+	if err != nil || yearNumber == 0 {
+		return nil, fmt.Errorf("year is required")
+	}
+
+	catalog, err := service.getCatalog(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	occupied, err := service.getOccupancy(ctx, organizationID, monthNumber, yearNumber, catalog, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	monthStart := time.Date(yearNumber, time.Month(monthNumber), 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := monthStart.AddDate(0, 1, -1)
+
+	closures, err := service.ProgramPlanerRepository.GetClosures(ctx, organizationID, monthStart, monthEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &OccupancyResponse{
+		OrganizationID: organizationID,
+		Month:          monthNumber,
+		Year:           yearNumber,
+		Slots:          make([]*SlotOccupancy, 0, len(catalog)),
+	}
+
+	for _, slot := range catalog {
+
+		slotOccupancy := &SlotOccupancy{
+			Name:      slot.Name,
+			StartTime: slot.StartTime,
+			EndTime:   slot.EndTime,
+			Capacity:  slot.Capacity,
+			Days:      []*DayOccupancy{},
+		}
+		if !slot.ID.IsZero() {
+			slotOccupancy.SlotID = slot.ID.Hex()
+		}
+
+		for day := monthStart; !day.After(monthEnd); day = day.AddDate(0, 0, 1) {
+
+			if !containsDay(slot.Weekdays, dayCode(day)) {
+				continue
+			}
+
+			key := occupancyKey(slot, day)
+			dayOccupancy := &DayOccupancy{
+				Date:       day.Format("2006-01-02"),
+				Booked:     occupied.booked[key],
+				Waitlisted: occupied.waitlisted[key],
+				Full:       occupied.isFull(slot, day),
+				Closed:     closureOn(closures, day) != nil,
+			}
+
+			if slot.Capacity > 0 {
+				available := slot.Capacity - dayOccupancy.Booked
+				if available < 0 {
+					available = 0
+				}
+				dayOccupancy.Available = &available
+			}
+
+			slotOccupancy.Days = append(slotOccupancy.Days, dayOccupancy)
+		}
+
+		res.Slots = append(res.Slots, slotOccupancy)
+	}
+
+	return res, nil
+}
+
+func (service *programPlannerService) CreateClosure(ctx context.Context, req *CreateClosureRequest, userID string) (*ClosureResponse, error) {
+
+	if req.OrganizationID == "" {
+		return nil, fmt.Errorf("organization_id is required")
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start_date format, expected YYYY-MM-DD")
+	}
+
+	endDate := startDate
+	if req.EndDate != "" {
+		endDate, err = time.Parse("2006-01-02", req.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end_date format, expected YYYY-MM-DD")
+		}
+	}
+
+	closure := &Closure{
+		ID:             primitive.NewObjectID(),
+		OrganizationID: req.OrganizationID,
+		Name:           strings.TrimSpace(req.Name),
+		StartDate:      startDate,
+		EndDate:        endDate,
+		Source:         ClosureSourceManual,
+		CreatedBy:      userID,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+
+	if err := validateClosure(closure); err != nil {
+		return nil, err
+	}
+
+	if err := service.ProgramPlanerRepository.CreateClosure(ctx, closure); err != nil {
+		return nil, err
+	}
+
+	adjusted, err := service.adjustPlanners(ctx, closure, AdjustmentClosureAdded, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ClosureResponse{Closure: closure, AdjustedPlanners: adjusted}, nil
+}
+
+func (service *programPlannerService) GetClosures(ctx context.Context, organizationID string, from string, to string) ([]*Closure, error) {
+
+	if organizationID == "" {
+		return nil, fmt.Errorf("organization_id is required")
+	}
+
+	now := time.Now()
+	fromDate := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	toDate := time.Date(now.Year(), 12, 31, 0, 0, 0, 0, time.UTC)
+
+	var err error
+	if from != "" {
+		fromDate, err = time.Parse("2006-01-02", from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from format, expected YYYY-MM-DD")
+		}
+	}
+	if to != "" {
+		toDate, err = time.Parse("2006-01-02", to)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to format, expected YYYY-MM-DD")
+		}
+	}
+
+	if toDate.Before(fromDate) {
+		return nil, fmt.Errorf("to must not be before from")
+	}
+
+	return service.ProgramPlanerRepository.GetClosures(ctx, organizationID, fromDate, toDate)
+}
+
+func (service *programPlannerService) DeleteClosure(ctx context.Context, id string, userID string) (*ClosureResponse, error) {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	closure, err := service.ProgramPlanerRepository.GetClosure(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := service.ProgramPlanerRepository.DeleteClosure(ctx, objectID); err != nil {
+		return nil, err
+	}
+
+	adjusted, err := service.adjustPlanners(ctx, closure, AdjustmentClosureRemoved, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	closure.IsDeleted = true
+
+	return &ClosureResponse{Closure: closure, AdjustedPlanners: adjusted}, nil
+}
+
+// SyncClosures makes the organization's holiday-service closures for the year match the
+// holiday-service calendar. Manual closures are left alone.
+func (service *programPlannerService) SyncClosures(ctx context.Context, req *SyncClosuresRequest, userID string) (*SyncClosuresResponse, error) {
+
+	if req.OrganizationID == "" {
+		return nil, fmt.Errorf("organization_id is required")
+	}
+	if req.Year == 0 {
+		return nil, fmt.Errorf("year is required")
+	}
+
+	holidays, err := service.HolidayService.GetHolidays(ctx, req.OrganizationID, req.Year)
+	if err != nil {
+		return nil, err
+	}
+
+	yearStart := time.Date(req.Year, 1, 1, 0, 0, 0, 0, time.UTC)
+	yearEnd := time.Date(req.Year, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	existing, err := service.ProgramPlanerRepository.GetClosures(ctx, req.OrganizationID, yearStart, yearEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	synced := map[string]*Closure{}
+	for _, closure := range existing {
+		if closure.Source == ClosureSourceHoliday {
+			synced[closure.ExternalID] = closure
+		}
+	}
+
+	result := &SyncClosuresResponse{Closures: []*Closure{}}
+
+	for _, holiday := range holidays {
+
+		startDate, err := time.Parse("2006-01-02", holiday.StartDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q for holiday %q", holiday.StartDate, holiday.Name)
+		}
+		endDate, err := time.Parse("2006-01-02", holiday.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q for holiday %q", holiday.EndDate, holiday.Name)
+		}
+
+		externalID := holiday.ID
+		if externalID == "" {
+			externalID = holiday.StartDate + "|" + holiday.Name
+		}
+
+		if current, ok := synced[externalID]; ok {
+			delete(synced, externalID)
+			if current.StartDate.Equal(startDate) && current.EndDate.Equal(endDate) && current.Name == holiday.Name {
+				result.Unchanged++
+				result.Closures = append(result.Closures, current)
+				continue
+			}
+			// The holiday moved: drop the old closure so planners get their days back.
+			if err := service.removeClosure(ctx, current, userID, result); err != nil {
+				return nil, err
+			}
+		}
+
+		closure := &Closure{
+			ID:             primitive.NewObjectID(),
+			OrganizationID: req.OrganizationID,
+			Name:           strings.TrimSpace(holiday.Name),
+			StartDate:      startDate,
+			EndDate:        endDate,
+			Source:         ClosureSourceHoliday,
+			ExternalID:     externalID,
+			CreatedBy:      userID,
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+		}
+
+		if err := validateClosure(closure); err != nil {
+			return nil, fmt.Errorf("holiday %q: %w", holiday.Name, err)
+		}
+
+		if err := service.ProgramPlanerRepository.CreateClosure(ctx, closure); err != nil {
+			return nil, err
+		}
+
+		adjusted, err := service.adjustPlanners(ctx, closure, AdjustmentClosureAdded, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Created++
+		result.AdjustedPlanners += adjusted
+		result.Closures = append(result.Closures, closure)
+	}
+
+	for _, closure := range synced {
+		if err := service.removeClosure(ctx, closure, userID, result); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func (service *programPlannerService) removeClosure(ctx context.Context, closure *Closure, userID string, result *SyncClosuresResponse) error {
+
+	if err := service.ProgramPlanerRepository.DeleteClosure(ctx, closure.ID); err != nil {
+		return err
+	}
+
+	adjusted, err := service.adjustPlanners(ctx, closure, AdjustmentClosureRemoved, userID)
+	if err != nil {
+		return err
+	}
+
+	result.Removed++
+	result.AdjustedPlanners += adjusted
+
+	return nil
+}
+
+// adjustPlanners brings the existing planners of the months a closure touches in line with
+// the organization's closures, recording an adjustment on every planner that changed.
+func (service *programPlannerService) adjustPlanners(ctx context.Context, closure *Closure, reason string, userID string) (int, error) {
+
+	programs, err := service.ProgramPlanerRepository.GetProgramPlanersForMonths(ctx, closure.OrganizationID, monthsBetween(closure.StartDate, closure.EndDate))
+	if err != nil {
+		return 0, err
+	}
+
+	if len(programs) == 0 {
+		return 0, nil
+	}
+
+	catalog, err := service.getCatalog(ctx, closure.OrganizationID)
+	if err != nil {
+		return 0, err
+	}
+
+	adjusted := 0
+
+	for _, program := range programs {
+
+		// Week edits can land while the planner is being adjusted; start over from the
+		// saved planner when one does.
+		for attempt := 0; ; attempt++ {
+
+			changed, err := service.adjustPlanner(ctx, program, closure, catalog, reason, userID)
+			if err == nil {
+				if changed {
+					adjusted++
+				}
+				break
+			}
+
+			if !errors.Is(err, ErrPlannerConflict) || attempt == maxPlannerAttempts-1 {
+				return adjusted, err
+			}
+
+			program, err = service.ProgramPlanerRepository.GetProgramPlaner(ctx, program.ID)
+			if errors.Is(err, ErrPlannerNotFound) {
+				// Deleted meanwhile; its bookings were given back with it.
+				break
+			}
+			if err != nil {
+				return adjusted, err
+			}
+		}
+	}
+
+	return adjusted, nil
+}
+
+// maxPlannerAttempts bounds how often a write is retried on a planner that keeps changing.
+const maxPlannerAttempts = 3
+
+// adjustPlanner reconciles one planner with the closures of its month and saves the weeks
+// that changed. It reports whether anything did.
+func (service *programPlannerService) adjustPlanner(ctx context.Context, program *ProgramPlaner, closure *Closure, catalog []*TimeSlot, reason string, userID string) (bool, error) {
+
+	monthStart := time.Date(program.Year, time.Month(program.Month), 1, 0, 0, 0, 0, time.UTC)
+	closures, err := service.ProgramPlanerRepository.GetClosures(ctx, program.OrganizationID, monthStart, monthStart.AddDate(0, 1, -1))
+	if err != nil {
+		return false, err
+	}
+
+	previousTotal := program.TotalFee
+
+	dates := reconcileClosures(program, closures, catalog)
+	if len(dates) == 0 {
+		return false, nil
+	}
+
+	var weeks []int
+	for w, week := range program.Weeks {
+		for _, date := range dates {
+			if !date.Before(week.WeekStart) && !date.After(week.WeekEnd) {
+				weeks = append(weeks, w)
+				break
+			}
+		}
+	}
+
+	program.TotalFee = roundFee(service.calculateTotalFee(program.Weeks, program.SelectedSlots))
+
+	adjustment := PlannerAdjustment{
+		Reason:        reason,
+		ClosureID:     closure.ID.Hex(),
+		ClosureName:   closure.Name,
+		Dates:         dates,
+		PreviousTotal: previousTotal,
+		NewTotal:      program.TotalFee,
+		Amount:        roundFee(program.TotalFee - previousTotal),
+		CreatedBy:     userID,
+		CreatedAt:     time.Now(),
+	}
+
+	if err := service.ProgramPlanerRepository.AdjustProgramPlaner(ctx, program, weeks, adjustment); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 func getWeeksInMonth(year int, month int) []struct {
 	WeekStart time.Time
 	WeekEnd   time.Time
@@ -0,0 +1,248 @@
+package program_planner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var dayCodes = []string{"mo", "tu", "we", "th", "fr", "sa", "su"}
+
+var weekdaysMF = []string{"mo", "tu", "we", "th", "fr"}
+
+// defaultTimeSlots is the catalog of organizations that have not set one up yet: the
+// four weekday slots the planner used to hardcode.
+func defaultTimeSlots(organizationID string) []*TimeSlot {
+
+	slots := make([]*TimeSlot, 0, 4)
+	for _, start := range []string{"8:00", "11:00", "17:00", "20:00"} {
+		slots = append(slots, &TimeSlot{
+			OrganizationID: organizationID,
+			Name:           start + " M-F",
+			StartTime:      start,
+			Weekdays:       weekdaysMF,
+		})
+	}
+
+	return slots
+}
+
+func validateTimeSlot(slot *TimeSlot) error {
+
+	if strings.TrimSpace(slot.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	start, err := time.Parse("15:04", slot.StartTime)
+	if err != nil {
+		return fmt.Errorf("start_time must be HH:MM")
+	}
+
+	end, err := time.Parse("15:04", slot.EndTime)
+	if err != nil {
+		return fmt.Errorf("end_time must be HH:MM")
+	}
+
+	if !end.After(start) {
+		return fmt.Errorf("end_time must be after start_time")
+	}
+
+	if len(slot.Weekdays) == 0 {
+		return fmt.Errorf("weekdays is required")
+	}
+
+	seen := map[string]bool{}
+	for _, day := range slot.Weekdays {
+		if !containsDay(dayCodes, day) {
+			return fmt.Errorf("invalid weekday %q", day)
+		}
+		if seen[day] {
+			return fmt.Errorf("duplicate weekday %q", day)
+		}
+		seen[day] = true
+	}
+
+	if slot.DefaultFee < 0 {
+		return fmt.Errorf("default_fee cannot be negative")
+	}
+
+	if slot.Capacity < 0 {
+		return fmt.Errorf("capacity cannot be negative")
+	}
+
+	return nil
+}
+
+// retimed reports whether the update changes when, or under which name, the slot runs.
+// Fee and capacity changes leave the bookings of planners as they are.
+func retimed(before *TimeSlot, after *TimeSlot) bool {
+
+	if before.Name != after.Name || before.StartTime != after.StartTime || before.EndTime != after.EndTime {
+		return true
+	}
+
+	for _, day := range before.Weekdays {
+		if !containsDay(after.Weekdays, day) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findTimeSlot looks a selection up by slot ID, or by name for clients that still send
+// only the time range.
+func findTimeSlot(catalog []*TimeSlot, slotID string, name string) *TimeSlot {
+	for _, slot := range catalog {
+		if slotID != "" && slot.ID.Hex() == slotID {
+			return slot
+		}
+		if slotID == "" && slot.Name == name {
+			return slot
+		}
+	}
+	return nil
+}
+
+// resolveSelections checks the selected slots against the catalog. Selections without
+// days take every weekday of the slot, and selections without a fee its default fee.
+func resolveSelections(selections []SelectedSlot, catalog []*TimeSlot) ([]SelectedSlot, error) {
+
+	resolved := make([]SelectedSlot, 0, len(selections))
+
+	for _, selection := range selections {
+
+		slot := findTimeSlot(catalog, selection.SlotID, selection.TimeRange)
+		if slot == nil {
+			name := selection.TimeRange
+			if selection.SlotID != "" {
+				name = selection.SlotID
+			}
+			return nil, fmt.Errorf("time slot %q is not in the catalog", name)
+		}
+
+		if !slot.ID.IsZero() {
+			selection.SlotID = slot.ID.Hex()
+		}
+		selection.TimeRange = slot.Name
+
+		if len(selection.Days) == 0 {
+			selection.Days = slot.Weekdays
+		}
+		for _, day := range selection.Days {
+			if !containsDay(slot.Weekdays, day) {
+				return nil, fmt.Errorf("time slot %q is not offered on %q", slot.Name, day)
+			}
+		}
+
+		if selection.Fee == 0 {
+			selection.Fee = slot.DefaultFee
+		}
+
+		resolved = append(resolved, selection)
+	}
+
+	return resolved, nil
+}
+
+// sharesWeekday reports whether the two slots run on a common day of the week.
+func sharesWeekday(a *TimeSlot, b *TimeSlot) bool {
+	for _, day := range a.Weekdays {
+		if containsDay(b.Weekdays, day) {
+			return true
+		}
+	}
+	return false
+}
+
+// findSlotAt returns the catalog slot starting at the given time on the given weekday.
+func findSlotAt(catalog []*TimeSlot, day string, startTime string) *TimeSlot {
+	for _, slot := range catalog {
+		if sameClock(slot.StartTime, startTime) && containsDay(slot.Weekdays, day) {
+			return slot
+		}
+	}
+	return nil
+}
+
+// generateWeeks lays the selected slots out over the weeks of the month.
+func generateWeeks(year int, month int, selections []SelectedSlot, catalog []*TimeSlot) []WeekPlan {
+
+	weeks := make([]WeekPlan, 0)
+
+	for _, w := range getWeeksInMonth(year, month) {
+
+		_, isoWeek := w.WeekStart.ISOWeek()
+		wp := WeekPlan{
+			WeekNumber: isoWeek,
+			WeekStart:  w.WeekStart,
+			WeekEnd:    w.WeekEnd,
+			WeekFee:    0,
+			Slots:      []DailySlot{},
+		}
+
+		baseMonday := w.WeekStart
+		for baseMonday.Weekday() != time.Monday {
+			baseMonday = baseMonday.AddDate(0, 0, -1)
+		}
+
+		for _, selection := range selections {
+
+			if !selection.Selected {
+				continue
+			}
+
+			slot := findTimeSlot(catalog, selection.SlotID, selection.TimeRange)
+			if slot == nil {
+				continue
+			}
+
+			for _, code := range selection.Days {
+				dayDate := baseMonday.AddDate(0, 0, dayIndex(code))
+				if dayDate.Before(w.WeekStart) || dayDate.After(w.WeekEnd) {
+					continue
+				}
+				wp.Slots = append(wp.Slots, DailySlot{
+					SlotID:     selection.SlotID,
+					DayOfWeek:  code,
+					Time:       slot.StartTime,
+					Selected:   true,
+					Fee:        0,
+					IsOriginal: true,
+				})
+			}
+		}
+
+		weeks = append(weeks, wp)
+	}
+
+	return weeks
+}
+
+func dayIndex(code string) int {
+	for i, day := range dayCodes {
+		if day == code {
+			return i
+		}
+	}
+	return -1
+}
+
+func containsDay(days []string, day string) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// sameClock compares times like "8:00" and "08:00".
+func sameClock(a string, b string) bool {
+	at, errA := time.Parse("15:04", a)
+	bt, errB := time.Parse("15:04", b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return at.Equal(bt)
+}
@@ -6,6 +6,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type StudyProgramRepository interface {
@@ -14,6 +15,7 @@ type StudyProgramRepository interface {
 	GetStudyProgram(ctx context.Context, id primitive.ObjectID) (*StudyProgram, error)
 	UpdateStudyProgram(ctx context.Context, id primitive.ObjectID, data *StudyProgram) error
 	DeleteStudyProgram(ctx context.Context, id primitive.ObjectID) error
+	GetStudyProgramForMonth(ctx context.Context, studentID string, month int, year int) (*StudyProgram, error)
 }
 
 type studyPrgramRepository struct {
@@ -101,3 +103,29 @@ func (r *studyPrgramRepository) DeleteStudyProgram(ctx context.Context, id primi
 
 	return nil
 }
+
+// GetStudyProgramForMonth returns the student's latest study program for the month, or nil.
+func (r *studyPrgramRepository) GetStudyProgramForMonth(ctx context.Context, studentID string, month int, year int) (*StudyProgram, error) {
+
+	var result *StudyProgram
+
+	filter := bson.M{
+		"student_id": studentID,
+		"month":      month,
+		"year":       year,
+		"is_deleted": bson.M{"$ne": true},
+	}
+
+	findOpts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	err := r.collection.FindOne(ctx, filter, findOpts).Decode(&result)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return result, nil
+
+}
@@ -14,6 +14,7 @@ type StudyProgramService interface {
 	GetStudyProgram(ctx context.Context, id string) (*StudyProgram, error)
 	UpdateStudyProgram(ctx context.Context, id string, req *UpdateStudyProgramRequest) error
 	DeleteStudyProgram(ctx context.Context, id string) error
+	GetStudyProgramForMonth(ctx context.Context, studentID string, month int, year int) (*StudyProgram, error)
 }
 
 type studyProgramService struct {
@@ -249,3 +250,7 @@ func (s *studyProgramService) DeleteStudyProgram(ctx context.Context, id string)
 	return s.StudyProgramRepository.DeleteStudyProgram(ctx, objectID)
 
 }
+
+func (s *studyProgramService) GetStudyProgramForMonth(ctx context.Context, studentID string, month int, year int) (*StudyProgram, error) {
+	return s.StudyProgramRepository.GetStudyProgramForMonth(ctx, studentID, month, year)
+}
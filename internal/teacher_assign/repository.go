@@ -6,6 +6,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type TeacherAssignmentRepository interface {
@@ -14,6 +15,7 @@ type TeacherAssignmentRepository interface {
 	GetTeacherAssignment(ctx context.Context, id primitive.ObjectID) (*TeacherAssignment, error)
 	UpdateTeacherAssignment(ctx context.Context, id primitive.ObjectID, data *TeacherAssignment) error
 	DeleteTeacherAssignment(ctx context.Context, id primitive.ObjectID) error
+	GetTeacherAssignmentForMonth(ctx context.Context, studentID string, month int, year int) (*TeacherAssignment, error)
 }
 
 type teacherAssignmentRepository struct {
@@ -87,4 +89,28 @@ func (r *teacherAssignmentRepository) UpdateTeacherAssignment(ctx context.Contex
 func (r *teacherAssignmentRepository) DeleteTeacherAssignment(ctx context.Context, id primitive.ObjectID) error {
 	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"is_deleted": true}})
 	return err
-}
\ No newline at end of file
+}
+// GetTeacherAssignmentForMonth returns the student's latest assignment for the month, or nil.
+func (r *teacherAssignmentRepository) GetTeacherAssignmentForMonth(ctx context.Context, studentID string, month int, year int) (*TeacherAssignment, error) {
+
+	var teacherAssignment TeacherAssignment
+
+	filter := bson.M{
+		"student_id": studentID,
+		"month":      month,
+		"year":       year,
+		"is_deleted": bson.M{"$ne": true},
+	}
+
+	findOpts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	err := r.collection.FindOne(ctx, filter, findOpts).Decode(&teacherAssignment)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &teacherAssignment, nil
+
+}
@@ -14,6 +14,7 @@ type TeacherAssignmentService interface {
 	GetTeacherAssignment(ctx context.Context, id string) (*TeacherAssignment, error)
 	UpdateTeacherAssignment(ctx context.Context, id string, req *UpdateTeacherAssignmentRequest) error
 	DeleteTeacherAssignment(ctx context.Context, id string) error
+	GetTeacherAssignmentForMonth(ctx context.Context, studentID string, month int, year int) (*TeacherAssignment, error)
 }
 
 type teacherAssignmentService struct {
@@ -190,3 +191,7 @@ func (s *teacherAssignmentService) DeleteTeacherAssignment(ctx context.Context,
 	}
 	return s.repository.DeleteTeacherAssignment(ctx, objectId)
 }
+
+func (s *teacherAssignmentService) GetTeacherAssignmentForMonth(ctx context.Context, studentID string, month int, year int) (*TeacherAssignment, error) {
+	return s.repository.GetTeacherAssignmentForMonth(ctx, studentID, month, year)
+}
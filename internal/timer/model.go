@@ -7,24 +7,43 @@ import (
 )
 
 type Timer struct {
-	ID                primitive.ObjectID `json:"id" bson:"_id"`
-	StudentID         string             `json:"student_id" bson:"student_id"`
-	StartColor        string             `json:"start_color" bson:"start_color"`
-	EndColor          string             `json:"end_color" bson:"end_color"`
-	Duration          int64              `json:"duration" bson:"duration"`
-	CenterLine        int64              `json:"center_line" bson:"center_line"`
-	NumberOfSound     int                `json:"number_of_sound" bson:"number_of_sound"`
-	ImageStartKey     string             `json:"image_start_key" bson:"image_start_key"`
-	ShowImageStart    bool               `json:"show_image_start" bson:"show_image_start"`
-	CaptionImageStart string             `json:"caption_image_start" bson:"caption_image_start"`
-	ImageEndKey       string             `json:"image_end_key" bson:"image_end_key"`
-	ShowImageEnd      bool               `json:"show_image_end" bson:"show_image_end"`
-	CaptionImageEnd   string             `json:"caption_image_end" bson:"caption_image_end"`
-	OpacityDuration   float64            `json:"opacity_duration" bson:"opacity_duration"`
-	TypePlay          string             `json:"type_play" bson:"type_play"`
-	CreatedBy         string             `json:"created_by" bson:"created_by"`
-	CreatedAt         time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt         time.Time          `json:"updated_at" bson:"updated_at"`
+	ID            primitive.ObjectID `json:"id" bson:"_id"`
+	StudentID     string             `json:"student_id" bson:"student_id"`
+	TimerSettings `bson:",inline"`
+	TemplateID    *primitive.ObjectID `json:"template_id,omitempty" bson:"template_id,omitempty"`
+	Overrides     []string            `json:"overrides,omitempty" bson:"overrides,omitempty"` // settings fields changed for this student only
+	CreatedBy     string              `json:"created_by" bson:"created_by"`
+	CreatedAt     time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+// TimerSettings holds the display settings shared by student timers and templates.
+type TimerSettings struct {
+	StartColor        string  `json:"start_color" bson:"start_color"`
+	EndColor          string  `json:"end_color" bson:"end_color"`
+	Duration          int64   `json:"duration" bson:"duration"`
+	CenterLine        int64   `json:"center_line" bson:"center_line"`
+	NumberOfSound     int     `json:"number_of_sound" bson:"number_of_sound"`
+	ImageStartKey     string  `json:"image_start_key" bson:"image_start_key"`
+	ShowImageStart    bool    `json:"show_image_start" bson:"show_image_start"`
+	CaptionImageStart string  `json:"caption_image_start" bson:"caption_image_start"`
+	ImageEndKey       string  `json:"image_end_key" bson:"image_end_key"`
+	ShowImageEnd      bool    `json:"show_image_end" bson:"show_image_end"`
+	CaptionImageEnd   string  `json:"caption_image_end" bson:"caption_image_end"`
+	OpacityDuration   float64 `json:"opacity_duration" bson:"opacity_duration"`
+	TypePlay          string  `json:"type_play" bson:"type_play"`
+}
+
+type TimerTemplate struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id"`
+	OrganizationID string             `json:"organization_id" bson:"organization_id"`
+	Name           string             `json:"name" bson:"name"`
+	Tags           []string           `json:"tags" bson:"tags"`
+	TimerSettings  `bson:",inline"`
+	IsDeleted      bool      `json:"is_deleted" bson:"is_deleted"`
+	CreatedBy      string    `json:"created_by" bson:"created_by"`
+	CreatedAt      time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" bson:"updated_at"`
 }
 
 type IsTime struct {
@@ -42,3 +61,47 @@ type IsTime struct {
 	CreatedAt         time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt         time.Time          `json:"updated_at" bson:"updated_at"`
 }
+
+// TimerRun is one use of a timer reported by a client, from start to completion or
+// cancellation.
+type TimerRun struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id"`
+	TimerID        primitive.ObjectID `json:"timer_id" bson:"timer_id"`
+	StudentID      string             `json:"student_id" bson:"student_id"`
+	Status         string             `json:"status" bson:"status"` // running, paused, completed, cancelled
+	PlannedSeconds int64              `json:"planned_seconds" bson:"planned_seconds"`
+	ActiveSeconds  int64              `json:"active_seconds" bson:"active_seconds"` // time running, excluding pauses
+	StartedAt      time.Time          `json:"started_at" bson:"started_at"`
+	EndedAt        *time.Time         `json:"ended_at" bson:"ended_at"`
+	Events         []TimerRunEvent    `json:"events" bson:"events"`
+	CreatedBy      string             `json:"created_by" bson:"created_by"`
+	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+type TimerRunEvent struct {
+	Type       string    `json:"type" bson:"type"` // started, paused, completed, cancelled
+	At         time.Time `json:"at" bson:"at"`
+	ReportedBy string    `json:"reported_by" bson:"reported_by"`
+}
+
+// Schedule orders a student's IsTime cards into a visual schedule. The current step is the
+// first step that has not been completed yet.
+type Schedule struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	StudentID   string             `json:"student_id" bson:"student_id"`
+	Name        string             `json:"name" bson:"name"`
+	Steps       []ScheduleStep     `json:"steps" bson:"steps"`
+	Version     int                `json:"version" bson:"version"`
+	StartedAt   *time.Time         `json:"started_at" bson:"started_at"`
+	CompletedAt *time.Time         `json:"completed_at" bson:"completed_at"`
+	IsDeleted   bool               `json:"is_deleted" bson:"is_deleted"`
+	CreatedBy   string             `json:"created_by" bson:"created_by"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+type ScheduleStep struct {
+	IsTimeID    primitive.ObjectID `json:"is_time_id" bson:"is_time_id"`
+	CompletedAt *time.Time         `json:"completed_at" bson:"completed_at"`
+}
@@ -2,6 +2,9 @@ package timer
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -12,6 +15,29 @@ import (
 type TimerRepository interface {
 	CreateTimer(ctx context.Context, timer *Timer) (string, error)
 	GetTimers(ctx context.Context, studentID string) ([]*Timer, error)
+	GetTimer(ctx context.Context, id primitive.ObjectID) (*Timer, error)
+	UpdateTimer(ctx context.Context, id primitive.ObjectID, timer *Timer) error
+	DeleteTimer(ctx context.Context, id primitive.ObjectID) error
+	CreateTimers(ctx context.Context, timers []*Timer) ([]string, error)
+	CountImageUsage(ctx context.Context, imageKey string) (int64, error)
+	CreateTimerTemplate(ctx context.Context, template *TimerTemplate) (string, error)
+	GetTimerTemplates(ctx context.Context, organizationID string, search string, tag string) ([]*TimerTemplate, error)
+	GetTimerTemplate(ctx context.Context, id primitive.ObjectID) (*TimerTemplate, error)
+	UpdateTimerTemplate(ctx context.Context, id primitive.ObjectID, template *TimerTemplate) error
+	DeleteTimerTemplate(ctx context.Context, id primitive.ObjectID) error
+	PropagateTimerTemplate(ctx context.Context, templateID primitive.ObjectID, values bson.M) error
+	ResetTimerOverrides(ctx context.Context, id primitive.ObjectID, settings TimerSettings) error
+	GetTemplateStudents(ctx context.Context, templateID primitive.ObjectID) ([]string, error)
+	CreateTimerRun(ctx context.Context, run *TimerRun) (string, error)
+	GetTimerRun(ctx context.Context, id primitive.ObjectID) (*TimerRun, error)
+	UpdateTimerRun(ctx context.Context, run *TimerRun, previousEvents int) error
+	GetTimerRuns(ctx context.Context, studentID string, timerID *primitive.ObjectID, from *time.Time, to *time.Time) ([]*TimerRun, error)
+	GetIsTimesByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*IsTime, error)
+	CreateSchedule(ctx context.Context, schedule *Schedule) (string, error)
+	GetSchedules(ctx context.Context, studentID string) ([]*Schedule, error)
+	GetSchedule(ctx context.Context, id primitive.ObjectID) (*Schedule, error)
+	SaveSchedule(ctx context.Context, schedule *Schedule) error
+	DeleteSchedule(ctx context.Context, id primitive.ObjectID) error
 	CreateIsTime(ctx context.Context, isTime *IsTime) error
 	GetIsTimes(ctx context.Context, studentID string) ([]*IsTime, error)
 }
@@ -19,12 +45,18 @@ type TimerRepository interface {
 type timerRepository struct {
 	collection *mongo.Collection
 	IsTimeCollection *mongo.Collection
+	TemplateCollection *mongo.Collection
+	RunCollection *mongo.Collection
+	ScheduleCollection *mongo.Collection
 }
 
-func NewTimerRepository(collection *mongo.Collection, IsTimeCollection *mongo.Collection) TimerRepository {
+func NewTimerRepository(collection *mongo.Collection, IsTimeCollection *mongo.Collection, TemplateCollection *mongo.Collection, RunCollection *mongo.Collection, ScheduleCollection *mongo.Collection) TimerRepository {
 	return &timerRepository{
 		collection: collection,
 		IsTimeCollection: IsTimeCollection,
+		TemplateCollection: TemplateCollection,
+		RunCollection: RunCollection,
+		ScheduleCollection: ScheduleCollection,
 	}
 }
 
@@ -63,6 +95,268 @@ func (t *timerRepository) GetTimers(ctx context.Context, studentID string) ([]*T
 	return timers, nil
 }
 
+func (t *timerRepository) GetTimer(ctx context.Context, id primitive.ObjectID) (*Timer, error) {
+
+	var timer Timer
+
+	err := t.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&timer)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("timer not found")
+		}
+		return nil, err
+	}
+
+	return &timer, nil
+}
+
+func (t *timerRepository) UpdateTimer(ctx context.Context, id primitive.ObjectID, timer *Timer) error {
+
+	result, err := t.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": timer})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("timer not found")
+	}
+
+	return nil
+}
+
+func (t *timerRepository) DeleteTimer(ctx context.Context, id primitive.ObjectID) error {
+
+	result, err := t.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("timer not found")
+	}
+
+	return nil
+}
+
+func (t *timerRepository) CreateTimers(ctx context.Context, timers []*Timer) ([]string, error) {
+
+	docs := make([]interface{}, 0, len(timers))
+	for _, timer := range timers {
+		docs = append(docs, timer)
+	}
+
+	result, err := t.collection.InsertMany(ctx, docs)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(result.InsertedIDs))
+	for _, id := range result.InsertedIDs {
+		ids = append(ids, id.(primitive.ObjectID).Hex())
+	}
+
+	return ids, nil
+}
+
+// CountImageUsage counts the timers and is-time cards still pointing at an image key.
+// Duplicated timers share their images, so a key is only orphaned once this reaches zero.
+func (t *timerRepository) CountImageUsage(ctx context.Context, imageKey string) (int64, error) {
+
+	timers, err := t.collection.CountDocuments(ctx, bson.M{
+		"$or": []bson.M{
+			{"image_start_key": imageKey},
+			{"image_end_key": imageKey},
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	isTimes, err := t.IsTimeCollection.CountDocuments(ctx, bson.M{
+		"$or": []bson.M{
+			{"image_key": imageKey},
+			{"reward_image": imageKey},
+			{"behaviour_image": imageKey},
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	templates, err := t.TemplateCollection.CountDocuments(ctx, bson.M{
+		"is_deleted": bson.M{"$ne": true},
+		"$or": []bson.M{
+			{"image_start_key": imageKey},
+			{"image_end_key": imageKey},
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return timers + isTimes + templates, nil
+}
+
+func (t *timerRepository) CreateTimerTemplate(ctx context.Context, template *TimerTemplate) (string, error) {
+
+	result, err := t.TemplateCollection.InsertOne(ctx, template)
+	if err != nil {
+		return "", err
+	}
+
+	return result.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+func (t *timerRepository) GetTimerTemplates(ctx context.Context, organizationID string, search string, tag string) ([]*TimerTemplate, error) {
+
+	filter := bson.M{
+		"organization_id": organizationID,
+		"is_deleted":      bson.M{"$ne": true},
+	}
+
+	if search != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(search), Options: "i"}
+		filter["$or"] = []bson.M{
+			{"name": pattern},
+			{"tags": pattern},
+		}
+	}
+
+	if tag != "" {
+		filter["tags"] = tag
+	}
+
+	findOpts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	var templates []*TimerTemplate
+
+	cursor, err := t.TemplateCollection.Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &templates)
+	if err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+func (t *timerRepository) GetTimerTemplate(ctx context.Context, id primitive.ObjectID) (*TimerTemplate, error) {
+
+	var template TimerTemplate
+
+	err := t.TemplateCollection.FindOne(ctx, bson.M{"_id": id, "is_deleted": bson.M{"$ne": true}}).Decode(&template)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("timer template not found")
+		}
+		return nil, err
+	}
+
+	return &template, nil
+}
+
+func (t *timerRepository) UpdateTimerTemplate(ctx context.Context, id primitive.ObjectID, template *TimerTemplate) error {
+
+	result, err := t.TemplateCollection.UpdateOne(ctx, bson.M{"_id": id, "is_deleted": bson.M{"$ne": true}}, bson.M{"$set": template})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("timer template not found")
+	}
+
+	return nil
+}
+
+// DeleteTimerTemplate soft deletes the template. Timers already assigned from it are kept
+// as standalone timers with their current settings.
+func (t *timerRepository) DeleteTimerTemplate(ctx context.Context, id primitive.ObjectID) error {
+
+	result, err := t.TemplateCollection.UpdateOne(ctx,
+		bson.M{"_id": id, "is_deleted": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{"is_deleted": true, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("timer template not found")
+	}
+
+	_, err = t.collection.UpdateMany(ctx,
+		bson.M{"template_id": id},
+		bson.M{"$unset": bson.M{"template_id": "", "overrides": ""}},
+	)
+
+	return err
+}
+
+// PropagateTimerTemplate pushes changed template fields to the assigned timers that have
+// not overridden them.
+func (t *timerRepository) PropagateTimerTemplate(ctx context.Context, templateID primitive.ObjectID, values bson.M) error {
+
+	now := time.Now()
+
+	for field, value := range values {
+
+		filter := bson.M{
+			"template_id": templateID,
+			"overrides":   bson.M{"$ne": field},
+		}
+
+		update := bson.M{
+			"$set": bson.M{
+				field:        value,
+				"updated_at": now,
+			},
+		}
+
+		if _, err := t.collection.UpdateMany(ctx, filter, update); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *timerRepository) ResetTimerOverrides(ctx context.Context, id primitive.ObjectID, settings TimerSettings) error {
+
+	update := bson.M{
+		"$set": bson.M{
+			"start_color":         settings.StartColor,
+			"end_color":           settings.EndColor,
+			"duration":            settings.Duration,
+			"center_line":         settings.CenterLine,
+			"number_of_sound":     settings.NumberOfSound,
+			"image_start_key":     settings.ImageStartKey,
+			"show_image_start":    settings.ShowImageStart,
+			"caption_image_start": settings.CaptionImageStart,
+			"image_end_key":       settings.ImageEndKey,
+			"show_image_end":      settings.ShowImageEnd,
+			"caption_image_end":   settings.CaptionImageEnd,
+			"opacity_duration":    settings.OpacityDuration,
+			"type_play":           settings.TypePlay,
+			"updated_at":          time.Now(),
+		},
+		"$unset": bson.M{"overrides": ""},
+	}
+
+	result, err := t.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("timer not found")
+	}
+
+	return nil
+}
+
 func (t *timerRepository) CreateIsTime(ctx context.Context, isTime *IsTime) error {
 
 	_, err := t.IsTimeCollection.InsertOne(ctx, isTime)
@@ -97,4 +391,236 @@ func (t *timerRepository) GetIsTimes(ctx context.Context, studentID string) ([]*
 
 	return isTimes, nil
 	
-}
\ No newline at end of file
+}
+
+func (t *timerRepository) GetTemplateStudents(ctx context.Context, templateID primitive.ObjectID) ([]string, error) {
+
+	values, err := t.collection.Distinct(ctx, "student_id", bson.M{"template_id": templateID})
+	if err != nil {
+		return nil, err
+	}
+
+	studentIDs := make([]string, 0, len(values))
+	for _, value := range values {
+		if studentID, ok := value.(string); ok {
+			studentIDs = append(studentIDs, studentID)
+		}
+	}
+
+	return studentIDs, nil
+}
+
+func (t *timerRepository) CreateTimerRun(ctx context.Context, run *TimerRun) (string, error) {
+
+	result, err := t.RunCollection.InsertOne(ctx, run)
+	if err != nil {
+		return "", err
+	}
+
+	return result.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+func (t *timerRepository) GetTimerRun(ctx context.Context, id primitive.ObjectID) (*TimerRun, error) {
+
+	var run TimerRun
+
+	err := t.RunCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&run)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("timer run not found")
+		}
+		return nil, err
+	}
+
+	return &run, nil
+}
+
+// UpdateTimerRun saves the run only if nobody reported events since it was read, so two
+// devices driving the same run cannot interleave their events.
+func (t *timerRepository) UpdateTimerRun(ctx context.Context, run *TimerRun, previousEvents int) error {
+
+	filter := bson.M{
+		"_id":    run.ID,
+		"events": bson.M{"$size": previousEvents},
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":         run.Status,
+			"active_seconds": run.ActiveSeconds,
+			"started_at":     run.StartedAt,
+			"ended_at":       run.EndedAt,
+			"events":         run.Events,
+			"updated_at":     run.UpdatedAt,
+		},
+	}
+
+	result, err := t.RunCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("timer run was updated concurrently, please retry")
+	}
+
+	return nil
+}
+
+func (t *timerRepository) GetTimerRuns(ctx context.Context, studentID string, timerID *primitive.ObjectID, from *time.Time, to *time.Time) ([]*TimerRun, error) {
+
+	filter := bson.M{}
+
+	if studentID != "" {
+		filter["student_id"] = studentID
+	}
+
+	if timerID != nil {
+		filter["timer_id"] = *timerID
+	}
+
+	dateFilter := bson.M{}
+	if from != nil {
+		dateFilter["$gte"] = *from
+	}
+	if to != nil {
+		dateFilter["$lt"] = to.Add(24 * time.Hour)
+	}
+	if len(dateFilter) > 0 {
+		filter["started_at"] = dateFilter
+	}
+
+	findOpts := options.Find().SetSort(bson.D{{Key: "started_at", Value: 1}})
+	var runs []*TimerRun
+
+	cursor, err := t.RunCollection.Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &runs)
+	if err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+func (t *timerRepository) GetIsTimesByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*IsTime, error) {
+
+	var isTimes []*IsTime
+
+	cursor, err := t.IsTimeCollection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &isTimes)
+	if err != nil {
+		return nil, err
+	}
+
+	return isTimes, nil
+}
+
+func (t *timerRepository) CreateSchedule(ctx context.Context, schedule *Schedule) (string, error) {
+
+	result, err := t.ScheduleCollection.InsertOne(ctx, schedule)
+	if err != nil {
+		return "", err
+	}
+
+	return result.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+func (t *timerRepository) GetSchedules(ctx context.Context, studentID string) ([]*Schedule, error) {
+
+	filter := bson.M{"is_deleted": bson.M{"$ne": true}}
+
+	if studentID != "" {
+		filter["student_id"] = studentID
+	}
+
+	findOpts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	var schedules []*Schedule
+
+	cursor, err := t.ScheduleCollection.Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &schedules)
+	if err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+func (t *timerRepository) GetSchedule(ctx context.Context, id primitive.ObjectID) (*Schedule, error) {
+
+	var schedule Schedule
+
+	err := t.ScheduleCollection.FindOne(ctx, bson.M{"_id": id, "is_deleted": bson.M{"$ne": true}}).Decode(&schedule)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("schedule not found")
+		}
+		return nil, err
+	}
+
+	return &schedule, nil
+}
+
+// SaveSchedule writes the steps and progress back if the schedule is still at the version
+// it was read at, then bumps the version. A teacher editing steps while the classroom
+// device advances gets a conflict instead of silently losing one of the changes.
+func (t *timerRepository) SaveSchedule(ctx context.Context, schedule *Schedule) error {
+
+	filter := bson.M{
+		"_id":        schedule.ID,
+		"version":    schedule.Version,
+		"is_deleted": bson.M{"$ne": true},
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"name":         schedule.Name,
+			"steps":        schedule.Steps,
+			"started_at":   schedule.StartedAt,
+			"completed_at": schedule.CompletedAt,
+			"updated_at":   schedule.UpdatedAt,
+		},
+		"$inc": bson.M{"version": 1},
+	}
+
+	result, err := t.ScheduleCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("schedule was updated concurrently, please reload")
+	}
+
+	schedule.Version++
+
+	return nil
+}
+
+func (t *timerRepository) DeleteSchedule(ctx context.Context, id primitive.ObjectID) error {
+
+	result, err := t.ScheduleCollection.UpdateOne(ctx,
+		bson.M{"_id": id, "is_deleted": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{"is_deleted": true, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("schedule not found")
+	}
+
+	return nil
+}
@@ -17,6 +17,53 @@ type CreateTimerRequest struct {
 	TypePlay          string  `json:"type_play" bson:"type_play"`
 }
 
+type UpdateTimerRequest struct {
+	StartColor        *string  `json:"start_color" bson:"start_color"`
+	EndColor          *string  `json:"end_color" bson:"end_color"`
+	Duration          *int64   `json:"duration" bson:"duration"`
+	LineCenter        *int64   `json:"line_center" bson:"line_center"`
+	OpacityDuration   *float64 `json:"opacity_duration" bson:"opacity_duration"`
+	NumberOfSound     *int     `json:"number_of_sound" bson:"number_of_sound"`
+	ImageStartKey     *string  `json:"image_start_key" bson:"image_start_key"`
+	ShowImageStart    *bool    `json:"show_image_start" bson:"show_image_start"`
+	CaptionImageStart *string  `json:"caption_image_start" bson:"caption_image_start"`
+	ImageEndKey       *string  `json:"image_end_key" bson:"image_end_key"`
+	ShowImageEnd      *bool    `json:"show_image_end" bson:"show_image_end"`
+	CaptionImageEnd   *string  `json:"caption_image_end" bson:"caption_image_end"`
+	TypePlay          *string  `json:"type_play" bson:"type_play"`
+}
+
+type DuplicateTimerRequest struct {
+	StudentIDs []string `json:"student_ids" bson:"student_ids"`
+}
+
+type CreateTimerTemplateRequest struct {
+	OrganizationID string   `json:"organization_id" bson:"organization_id"`
+	Name           string   `json:"name" bson:"name"`
+	Tags           []string `json:"tags" bson:"tags"`
+	TimerSettings
+}
+
+type UpdateTimerTemplateRequest struct {
+	Name *string  `json:"name" bson:"name"`
+	Tags []string `json:"tags" bson:"tags"` // replaces the tags when present
+	UpdateTimerRequest
+}
+
+type AssignTimerTemplateRequest struct {
+	StudentIDs []string `json:"student_ids" bson:"student_ids"`
+}
+
+type ReportTimerRunRequest struct {
+	RunID  string                 `json:"run_id" bson:"run_id"` // empty to start a new run
+	Events []TimerRunEventRequest `json:"events" bson:"events"`
+}
+
+type TimerRunEventRequest struct {
+	Type string `json:"type" bson:"type"`
+	At   string `json:"at" bson:"at"` // RFC 3339, defaults to the time the event is received
+}
+
 type CreateIsTimeRequest struct {
 	StudentID         string `json:"student_id" bson:"student_id"`
 	IndexImage        int    `json:"index_image" bson:"index_image"`
@@ -29,3 +76,22 @@ type CreateIsTimeRequest struct {
 	BehaviourImageKey string `json:"behaviour_image" bson:"behaviour_image"`
 	CreatedBy         string `json:"created_by" bson:"created_by"`
 }
+
+type CreateScheduleRequest struct {
+	StudentID string   `json:"student_id" bson:"student_id"`
+	Name      string   `json:"name" bson:"name"`
+	IsTimeIDs []string `json:"is_time_ids" bson:"is_time_ids"`
+}
+
+type ReorderScheduleStepsRequest struct {
+	IsTimeIDs []string `json:"is_time_ids" bson:"is_time_ids"`
+}
+
+type InsertScheduleStepRequest struct {
+	IsTimeID string `json:"is_time_id" bson:"is_time_id"`
+	Position *int   `json:"position" bson:"position"` // zero-based, appended when empty
+}
+
+type AdvanceScheduleRequest struct {
+	Step *int `json:"step" bson:"step"` // the step the device believes is current
+}
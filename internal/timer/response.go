@@ -8,24 +8,59 @@ import (
 )
 
 type TimerResponse struct {
-	ID                primitive.ObjectID `json:"id" bson:"_id"`
-	Student           *user.UserInfor    `json:"student" bson:"student"`
-	StartColor        string             `json:"start_color" bson:"start_color"`
-	EndColor          string             `json:"end_color" bson:"end_color"`
-	Duration          int64              `json:"duration" bson:"duration"`
-	CenterLine        int64              `json:"center_line" bson:"center_line"`
-	OpacityDuration   float64            `json:"opacity_duration" bson:"opacity_duration"`
-	NumberOfSound     int                `json:"number_of_sound" bson:"number_of_sound"`
-	ImageStartKey     string             `json:"image_start_key" bson:"image_start_key"`
-	ShowImageStart    bool               `json:"show_image_start" bson:"show_image_start"`
-	CaptionImageStart string             `json:"caption_image_start" bson:"caption_image_start"`
-	ImageEndKey       string             `json:"image_end_key" bson:"image_end_key"`
-	ShowImageEnd      bool               `json:"show_image_end" bson:"show_image_end"`
-	CaptionImageEnd   string             `json:"caption_image_end" bson:"caption_image_end"`
-	TypePlay          string             `json:"type_play" bson:"type_play"`
-	Teacher           *user.UserInfor    `json:"teacher" bson:"teacher"`
-	CreatedAt         time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt         time.Time          `json:"updated_at" bson:"updated_at"`
+	ID                primitive.ObjectID  `json:"id" bson:"_id"`
+	Student           *user.UserInfor     `json:"student" bson:"student"`
+	StartColor        string              `json:"start_color" bson:"start_color"`
+	EndColor          string              `json:"end_color" bson:"end_color"`
+	Duration          int64               `json:"duration" bson:"duration"`
+	CenterLine        int64               `json:"center_line" bson:"center_line"`
+	OpacityDuration   float64             `json:"opacity_duration" bson:"opacity_duration"`
+	NumberOfSound     int                 `json:"number_of_sound" bson:"number_of_sound"`
+	ImageStartKey     string              `json:"image_start_key" bson:"image_start_key"`
+	ShowImageStart    bool                `json:"show_image_start" bson:"show_image_start"`
+	CaptionImageStart string              `json:"caption_image_start" bson:"caption_image_start"`
+	ImageEndKey       string              `json:"image_end_key" bson:"image_end_key"`
+	ShowImageEnd      bool                `json:"show_image_end" bson:"show_image_end"`
+	CaptionImageEnd   string              `json:"caption_image_end" bson:"caption_image_end"`
+	TypePlay          string              `json:"type_play" bson:"type_play"`
+	TemplateID        *primitive.ObjectID `json:"template_id,omitempty" bson:"template_id,omitempty"`
+	Overrides         []string            `json:"overrides,omitempty" bson:"overrides,omitempty"`
+	Teacher           *user.UserInfor     `json:"teacher" bson:"teacher"`
+	CreatedAt         time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt         time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+type TimerTemplateResponse struct {
+	ID             primitive.ObjectID `json:"id"`
+	OrganizationID string             `json:"organization_id"`
+	Name           string             `json:"name"`
+	Tags           []string           `json:"tags"`
+	TimerSettings
+	ImageStartUrl string          `json:"image_start_url"`
+	ImageEndUrl   string          `json:"image_end_url"`
+	Teacher       *user.UserInfor `json:"teacher"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+type TimerUsageResponse struct {
+	From   string        `json:"from"`
+	To     string        `json:"to"`
+	Timers []*TimerUsage `json:"timers"`
+}
+
+type TimerUsage struct {
+	TimerID               primitive.ObjectID `json:"timer_id"`
+	StudentID             string             `json:"student_id"`
+	Student               *user.UserInfor    `json:"student"`
+	Runs                  int                `json:"runs"`
+	Completed             int                `json:"completed"`
+	Cancelled             int                `json:"cancelled"`
+	InProgress            int                `json:"in_progress"`
+	CompletionRate        float64            `json:"completion_rate"` // completed share of finished runs, in percent
+	Overruns              int                `json:"overruns"`
+	AverageOverrunSeconds float64            `json:"average_overrun_seconds"`
+	LastRunAt             *time.Time         `json:"last_run_at"`
 }
 
 type IsTimeResponse struct {
@@ -43,3 +78,48 @@ type IsTimeResponse struct {
 	CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt    time.Time          `json:"updated_at" bson:"updated_at"`
 }
+
+type ScheduleResponse struct {
+	ID             primitive.ObjectID      `json:"id"`
+	Student        *user.UserInfor         `json:"student"`
+	Name           string                  `json:"name"`
+	Steps          []*ScheduleStepResponse `json:"steps"`
+	CurrentStep    int                     `json:"current_step"`
+	TotalSteps     int                     `json:"total_steps"`
+	CompletedSteps int                     `json:"completed_steps"`
+	Progress       float64                 `json:"progress"` // percent of steps completed
+	Version        int                     `json:"version"`
+	StartedAt      *time.Time              `json:"started_at"`
+	CompletedAt    *time.Time              `json:"completed_at"`
+	CreatedAt      time.Time               `json:"created_at"`
+	UpdatedAt      time.Time               `json:"updated_at"`
+}
+
+type ScheduleStepResponse struct {
+	Position     int                `json:"position"`
+	IsTimeID     primitive.ObjectID `json:"is_time_id"`
+	IndexImage   int                `json:"index_image"`
+	Sentence     string             `json:"sentence"`
+	Mode         string             `json:"mode"`
+	ImageUrl     string             `json:"image_url"`
+	RewardUrl    string             `json:"reward_url"`
+	BehaviourUrl string             `json:"behaviour_url"`
+	CaptionImage string             `json:"caption_image"`
+	ImageSize    string             `json:"image_size"`
+	IsCurrent    bool               `json:"is_current"`
+	CompletedAt  *time.Time         `json:"completed_at"`
+}
+
+type ScheduleProgressResponse struct {
+	ScheduleID     primitive.ObjectID    `json:"schedule_id"`
+	Name           string                `json:"name"`
+	Student        *user.UserInfor       `json:"student"`
+	CurrentStep    int                   `json:"current_step"`
+	TotalSteps     int                   `json:"total_steps"`
+	CompletedSteps int                   `json:"completed_steps"`
+	Progress       float64               `json:"progress"`
+	Current        *ScheduleStepResponse `json:"current"`
+	Next           *ScheduleStepResponse `json:"next"`
+	StartedAt      *time.Time            `json:"started_at"`
+	CompletedAt    *time.Time            `json:"completed_at"`
+}
@@ -11,9 +11,33 @@ func RegisterRoutes(r *gin.Engine, TimerHandler *TimerHandler) {
 	{
 		group.GET("", TimerHandler.GetTimers)
 		group.POST("", TimerHandler.CreateTimer)
+		group.PUT("/:id", TimerHandler.UpdateTimer)
+		group.DELETE("/:id", TimerHandler.DeleteTimer)
+		group.POST("/:id/duplicate", TimerHandler.DuplicateTimer)
+		group.POST("/:id/reset", TimerHandler.ResetTimerOverrides)
+		group.POST("/:id/runs", TimerHandler.ReportTimerRun)
+		group.GET("/analytics", TimerHandler.GetTimerUsage)
+
+		group.GET("/templates", TimerHandler.GetTimerTemplates)
+		group.POST("/templates", TimerHandler.CreateTimerTemplate)
+		group.GET("/templates/:id", TimerHandler.GetTimerTemplate)
+		group.PUT("/templates/:id", TimerHandler.UpdateTimerTemplate)
+		group.DELETE("/templates/:id", TimerHandler.DeleteTimerTemplate)
+		group.POST("/templates/:id/assign", TimerHandler.AssignTimerTemplate)
 
 
 		group.POST("/is-time", TimerHandler.CreateIsTime)
 		group.GET("/is-time", TimerHandler.GetIsTimes)
+
+		group.GET("/schedules", TimerHandler.GetSchedules)
+		group.POST("/schedules", TimerHandler.CreateSchedule)
+		group.GET("/schedules/:id", TimerHandler.GetSchedule)
+		group.DELETE("/schedules/:id", TimerHandler.DeleteSchedule)
+		group.GET("/schedules/:id/progress", TimerHandler.GetScheduleProgress)
+		group.PUT("/schedules/:id/steps", TimerHandler.ReorderScheduleSteps)
+		group.POST("/schedules/:id/steps", TimerHandler.InsertScheduleStep)
+		group.DELETE("/schedules/:id/steps/:is_time_id", TimerHandler.RemoveScheduleStep)
+		group.POST("/schedules/:id/advance", TimerHandler.AdvanceSchedule)
+		group.POST("/schedules/:id/restart", TimerHandler.RestartSchedule)
 	}
 }
\ No newline at end of file
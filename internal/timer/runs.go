@@ -0,0 +1,145 @@
+package timer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	RunEventStarted   = "started" // also resumes a paused run
+	RunEventPaused    = "paused"
+	RunEventCompleted = "completed"
+	RunEventCancelled = "cancelled"
+
+	RunStatusRunning   = "running"
+	RunStatusPaused    = "paused"
+	RunStatusCompleted = "completed"
+	RunStatusCancelled = "cancelled"
+)
+
+// applyRunEvent moves the run to the status implied by the event and keeps the active
+// time up to date. Events must be applied in chronological order.
+func applyRunEvent(run *TimerRun, event TimerRunEvent) error {
+
+	if len(run.Events) > 0 && event.At.Before(run.Events[len(run.Events)-1].At) {
+		return fmt.Errorf("event %s at %s is before the previous event", event.Type, event.At.Format(time.RFC3339))
+	}
+
+	switch event.Type {
+	case RunEventStarted:
+		if run.Status != "" && run.Status != RunStatusPaused {
+			return fmt.Errorf("cannot start a %s run", run.Status)
+		}
+		if run.Status == "" {
+			run.StartedAt = event.At
+		}
+		run.Status = RunStatusRunning
+
+	case RunEventPaused, RunEventCompleted, RunEventCancelled:
+		if run.Status != RunStatusRunning && !(run.Status == RunStatusPaused && event.Type != RunEventPaused) {
+			return fmt.Errorf("cannot mark a %s run as %s", statusOrNew(run.Status), event.Type)
+		}
+		if run.Status == RunStatusRunning {
+			run.ActiveSeconds += int64(event.At.Sub(run.lastStartedAt()).Seconds())
+		}
+		switch event.Type {
+		case RunEventPaused:
+			run.Status = RunStatusPaused
+		case RunEventCompleted:
+			run.Status = RunStatusCompleted
+		case RunEventCancelled:
+			run.Status = RunStatusCancelled
+		}
+		if run.Status != RunStatusPaused {
+			endedAt := event.At
+			run.EndedAt = &endedAt
+		}
+
+	default:
+		return fmt.Errorf("event type must be started, paused, completed or cancelled")
+	}
+
+	run.Events = append(run.Events, event)
+
+	return nil
+}
+
+func (r *TimerRun) lastStartedAt() time.Time {
+	for i := len(r.Events) - 1; i >= 0; i-- {
+		if r.Events[i].Type == RunEventStarted {
+			return r.Events[i].At
+		}
+	}
+	return r.StartedAt
+}
+
+func statusOrNew(status string) string {
+	if status == "" {
+		return "new"
+	}
+	return status
+}
+
+// buildTimerUsage groups runs per timer and student. Overrun is how long a completed run
+// stayed active past the timer's configured duration; runs finished early count as zero.
+func buildTimerUsage(runs []*TimerRun) []*TimerUsage {
+
+	usages := map[string]*TimerUsage{}
+	overrunTotals := map[string]int64{}
+	order := make([]string, 0)
+
+	for _, run := range runs {
+
+		key := run.TimerID.Hex() + "|" + run.StudentID
+
+		usage, ok := usages[key]
+		if !ok {
+			usage = &TimerUsage{TimerID: run.TimerID, StudentID: run.StudentID}
+			usages[key] = usage
+			order = append(order, key)
+		}
+
+		usage.Runs++
+		if usage.LastRunAt == nil || run.StartedAt.After(*usage.LastRunAt) {
+			startedAt := run.StartedAt
+			usage.LastRunAt = &startedAt
+		}
+
+		switch run.Status {
+		case RunStatusCompleted:
+			usage.Completed++
+			if overrun := run.ActiveSeconds - run.PlannedSeconds; overrun > 0 {
+				overrunTotals[key] += overrun
+				usage.Overruns++
+			}
+		case RunStatusCancelled:
+			usage.Cancelled++
+		default:
+			usage.InProgress++
+		}
+	}
+
+	result := make([]*TimerUsage, 0, len(order))
+	for _, key := range order {
+		usage := usages[key]
+		if finished := usage.Completed + usage.Cancelled; finished > 0 {
+			usage.CompletionRate = round2(float64(usage.Completed) / float64(finished) * 100)
+		}
+		if usage.Completed > 0 {
+			usage.AverageOverrunSeconds = round2(float64(overrunTotals[key]) / float64(usage.Completed))
+		}
+		result = append(result, usage)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Runs > result[j].Runs
+	})
+
+	return result
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,154 @@
+package timer
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// currentStep returns the index of the first step not completed yet, or the number of
+// steps once the whole schedule is done.
+func (s *Schedule) currentStep() int {
+	for i, step := range s.Steps {
+		if step.CompletedAt == nil {
+			return i
+		}
+	}
+	return len(s.Steps)
+}
+
+func (s *Schedule) completedSteps() int {
+	count := 0
+	for _, step := range s.Steps {
+		if step.CompletedAt != nil {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *Schedule) stepIndex(isTimeID primitive.ObjectID) int {
+	for i, step := range s.Steps {
+		if step.IsTimeID == isTimeID {
+			return i
+		}
+	}
+	return -1
+}
+
+// reorderSteps puts the steps in the given order. The order must list every step exactly
+// once; completion state travels with the step.
+func (s *Schedule) reorderSteps(order []primitive.ObjectID) error {
+
+	if len(order) != len(s.Steps) {
+		return fmt.Errorf("is_time_ids must list every step of the schedule")
+	}
+
+	steps := make([]ScheduleStep, 0, len(order))
+	seen := map[primitive.ObjectID]bool{}
+
+	for _, id := range order {
+		index := s.stepIndex(id)
+		if index < 0 || seen[id] {
+			return fmt.Errorf("is_time_ids must list every step of the schedule")
+		}
+		seen[id] = true
+		steps = append(steps, s.Steps[index])
+	}
+
+	s.Steps = steps
+	s.refreshCompletion()
+
+	return nil
+}
+
+// insertStep adds a card at the position, or at the end when position is nil or past it.
+func (s *Schedule) insertStep(isTimeID primitive.ObjectID, position *int) error {
+
+	if s.stepIndex(isTimeID) >= 0 {
+		return fmt.Errorf("card is already a step of the schedule")
+	}
+
+	index := len(s.Steps)
+	if position != nil {
+		if *position < 0 {
+			return fmt.Errorf("position must not be negative")
+		}
+		if *position < index {
+			index = *position
+		}
+	}
+
+	s.Steps = append(s.Steps, ScheduleStep{})
+	copy(s.Steps[index+1:], s.Steps[index:])
+	s.Steps[index] = ScheduleStep{IsTimeID: isTimeID}
+	s.refreshCompletion()
+
+	return nil
+}
+
+func (s *Schedule) removeStep(isTimeID primitive.ObjectID) error {
+
+	index := s.stepIndex(isTimeID)
+	if index < 0 {
+		return fmt.Errorf("step not found")
+	}
+
+	s.Steps = append(s.Steps[:index], s.Steps[index+1:]...)
+	s.refreshCompletion()
+
+	return nil
+}
+
+// advance completes the current step. When step is given it must match the current step,
+// so a device retrying the same request does not skip a card.
+func (s *Schedule) advance(step *int, now time.Time) error {
+
+	current := s.currentStep()
+
+	if current >= len(s.Steps) {
+		return fmt.Errorf("schedule is already completed")
+	}
+
+	if step != nil && *step != current {
+		return fmt.Errorf("current step is %d, not %d", current, *step)
+	}
+
+	if s.StartedAt == nil {
+		s.StartedAt = &now
+	}
+
+	completedAt := now
+	s.Steps[current].CompletedAt = &completedAt
+	s.refreshCompletion()
+
+	return nil
+}
+
+// restart clears the completion of every step so the schedule can be run again.
+func (s *Schedule) restart() {
+	for i := range s.Steps {
+		s.Steps[i].CompletedAt = nil
+	}
+	s.StartedAt = nil
+	s.CompletedAt = nil
+}
+
+func (s *Schedule) refreshCompletion() {
+
+	if len(s.Steps) == 0 || s.currentStep() < len(s.Steps) {
+		s.CompletedAt = nil
+		return
+	}
+
+	if s.CompletedAt == nil {
+		var last time.Time
+		for _, step := range s.Steps {
+			if step.CompletedAt.After(last) {
+				last = *step.CompletedAt
+			}
+		}
+		s.CompletedAt = &last
+	}
+}
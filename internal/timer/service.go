@@ -5,18 +5,45 @@ import (
 	"fmt"
 	"portal/internal/user"
 	"portal/pkg/uploader"
+	"strings"
+	"sync"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type TimerService interface {
 	CreateTimer(ctx context.Context, req *CreateTimerRequest, userID string) (string, error)
 	GetTimers(ctx context.Context, studentID string) ([]*TimerResponse, error)
+	UpdateTimer(ctx context.Context, id string, req *UpdateTimerRequest) error
+	DeleteTimer(ctx context.Context, id string) error
+	DuplicateTimer(ctx context.Context, id string, req *DuplicateTimerRequest, userID string) ([]string, error)
+	ResetTimerOverrides(ctx context.Context, id string) error
+	CreateTimerTemplate(ctx context.Context, req *CreateTimerTemplateRequest, userID string) (string, error)
+	GetTimerTemplates(ctx context.Context, organizationID string, search string, tag string) ([]*TimerTemplateResponse, error)
+	GetTimerTemplate(ctx context.Context, id string) (*TimerTemplateResponse, error)
+	UpdateTimerTemplate(ctx context.Context, id string, req *UpdateTimerTemplateRequest) error
+	DeleteTimerTemplate(ctx context.Context, id string) error
+	AssignTimerTemplate(ctx context.Context, id string, req *AssignTimerTemplateRequest, userID string) ([]string, error)
+	ReportTimerRun(ctx context.Context, timerID string, req *ReportTimerRunRequest, userID string) (string, error)
+	GetTimerUsage(ctx context.Context, studentID string, timerID string, from string, to string) (*TimerUsageResponse, error)
+	CreateSchedule(ctx context.Context, req *CreateScheduleRequest, userID string) (string, error)
+	GetSchedules(ctx context.Context, studentID string) ([]*ScheduleResponse, error)
+	GetSchedule(ctx context.Context, id string) (*ScheduleResponse, error)
+	GetScheduleProgress(ctx context.Context, id string) (*ScheduleProgressResponse, error)
+	ReorderScheduleSteps(ctx context.Context, id string, req *ReorderScheduleStepsRequest) error
+	InsertScheduleStep(ctx context.Context, id string, req *InsertScheduleStepRequest) error
+	RemoveScheduleStep(ctx context.Context, id string, isTimeID string) error
+	AdvanceSchedule(ctx context.Context, id string, req *AdvanceScheduleRequest) (*ScheduleProgressResponse, error)
+	RestartSchedule(ctx context.Context, id string) error
+	DeleteSchedule(ctx context.Context, id string) error
 	CreateIsTime(ctx context.Context, req *CreateIsTimeRequest, userID string) error
 	GetIsTimes(ctx context.Context, studentID string) ([]*IsTimeResponse, error)
 }
 
+const maxConcurrentUserLookups = 8
+
 type timerService struct {
 	TimerRepository TimerRepository
 	UserService     user.UserService
@@ -38,24 +65,26 @@ func (s *timerService) CreateTimer(ctx context.Context, req *CreateTimerRequest,
 	}
 
 	timer := &Timer{
-		ID:                primitive.NewObjectID(),
-		StudentID:         req.StudentID,
-		StartColor:        req.StartColor,
-		EndColor:          req.EndColor,
-		Duration:          req.Duration,
-		NumberOfSound:     req.NumberOfSound,
-		CenterLine:        req.LineCenter,
-		OpacityDuration:   req.OpacityDuration,
-		ImageStartKey:     req.ImageStartKey,
-		ShowImageStart:    req.ShowImageStart,
-		CaptionImageStart: req.CaptionImageStart,
-		ImageEndKey:       req.ImageEndKey,
-		ShowImageEnd:      req.ShowImageEnd,
-		CaptionImageEnd:   req.CaptionImageEnd,
-		TypePlay:          req.TypePlay,
-		CreatedBy:         userID,
-		CreatedAt:         time.Now(),
-		UpdatedAt:         time.Now(),
+		ID:        primitive.NewObjectID(),
+		StudentID: req.StudentID,
+		TimerSettings: TimerSettings{
+			StartColor:        req.StartColor,
+			EndColor:          req.EndColor,
+			Duration:          req.Duration,
+			NumberOfSound:     req.NumberOfSound,
+			CenterLine:        req.LineCenter,
+			OpacityDuration:   req.OpacityDuration,
+			ImageStartKey:     req.ImageStartKey,
+			ShowImageStart:    req.ShowImageStart,
+			CaptionImageStart: req.CaptionImageStart,
+			ImageEndKey:       req.ImageEndKey,
+			ShowImageEnd:      req.ShowImageEnd,
+			CaptionImageEnd:   req.CaptionImageEnd,
+			TypePlay:          req.TypePlay,
+		},
+		CreatedBy: userID,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
 	}
 
 	id, err := s.TimerRepository.CreateTimer(ctx, timer)
@@ -70,62 +99,58 @@ func (s *timerService) CreateTimer(ctx context.Context, req *CreateTimerRequest,
 
 func (s *timerService) GetTimers(ctx context.Context, studentID string) ([]*TimerResponse, error) {
 
-	if studentID == "" {
-		studentID = ""
-	}
-
 	timers, err := s.TimerRepository.GetTimers(ctx, studentID)
 
 	if err != nil {
 		return nil, err
 	}
 
-	var result []*TimerResponse
+	imageKeys := make([]string, 0, len(timers)*2)
+	studentIDs := make([]string, 0, len(timers))
+	teacherIDs := make([]string, 0, len(timers))
 	for _, timer := range timers {
+		imageKeys = append(imageKeys, timer.ImageStartKey, timer.ImageEndKey)
+		studentIDs = append(studentIDs, timer.StudentID)
+		teacherIDs = append(teacherIDs, timer.CreatedBy)
+	}
 
-		student, err := s.UserService.GetStudentInfor(ctx, timer.StudentID)
-		if err != nil {
-			return nil, err
-		}
+	images, err := s.ImageService.GetImageKeys(ctx, imageKeys)
+	if err != nil {
+		return nil, err
+	}
 
-		teacher, err := s.UserService.GetUserInfor(ctx, timer.CreatedBy)
-		if err != nil {
-			return nil, err
-		}
+	students, err := lookupUsers(ctx, studentIDs, s.UserService.GetStudentInfor)
+	if err != nil {
+		return nil, err
+	}
 
-		if timer.ImageStartKey != "" {
-			imageUrlStart, err := s.ImageService.GetImageKey(ctx, timer.ImageStartKey)
-			if err != nil {
-				return nil, err
-			}
-			timer.ImageStartKey = imageUrlStart.Url
-		}
+	teachers, err := lookupUsers(ctx, teacherIDs, s.UserService.GetUserInfor)
+	if err != nil {
+		return nil, err
+	}
 
-		if timer.ImageEndKey != "" {
-			imageUrlEnd, err := s.ImageService.GetImageKey(ctx, timer.ImageEndKey)
-			if err != nil {
-				return nil, err
-			}
-			timer.ImageEndKey = imageUrlEnd.Url
-		}
+	var result []*TimerResponse
+	for _, timer := range timers {
 
 		result = append(result, &TimerResponse{
 			ID:                timer.ID,
-			Student:           student,
+			Student:           students[timer.StudentID],
 			StartColor:        timer.StartColor,
 			EndColor:          timer.EndColor,
 			Duration:          timer.Duration,
 			NumberOfSound:     timer.NumberOfSound,
 			CenterLine:        timer.CenterLine,
 			OpacityDuration:   timer.OpacityDuration,
-			ImageStartKey:     timer.ImageStartKey,
+			ImageStartKey:     imageUrl(images, timer.ImageStartKey),
 			ShowImageStart:    timer.ShowImageStart,
 			CaptionImageStart: timer.CaptionImageStart,
-			ImageEndKey:       timer.ImageEndKey,
+			ImageEndKey:       imageUrl(images, timer.ImageEndKey),
 			ShowImageEnd:      timer.ShowImageEnd,
 			CaptionImageEnd:   timer.CaptionImageEnd,
 			TypePlay:          timer.TypePlay,
-			Teacher:           teacher,
+			TemplateID:        timer.TemplateID,
+			Overrides:         timer.Overrides,
+			Teacher:           teachers[timer.CreatedBy],
 			CreatedAt:         timer.CreatedAt,
 			UpdatedAt:         timer.UpdatedAt,
 		})
@@ -134,101 +159,1160 @@ func (s *timerService) GetTimers(ctx context.Context, studentID string) ([]*Time
 	return result, nil
 }
 
-func (s *timerService) CreateIsTime(ctx context.Context, req *CreateIsTimeRequest, userID string) error {
+func (s *timerService) UpdateTimer(ctx context.Context, id string, req *UpdateTimerRequest) error {
 
-	if req.StudentID == "" {
-		return fmt.Errorf("student_id is required")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
 	}
 
-	if userID == "" {
-		return fmt.Errorf("created_by is required")
+	timer, err := s.TimerRepository.GetTimer(ctx, objectID)
+	if err != nil {
+		return err
 	}
 
-	data := &IsTime{
-		ID:                primitive.NewObjectID(),
-		StudentID:         req.StudentID,
-		IndexImage:        req.IndexImage,
-		Sentence:          req.Sentence,
-		Mode:              req.Mode,
-		ImageKey:          req.ImageKey,
-		CaptionImage:      req.CaptionImage,
-		ImageSize:         req.ImageSize,
-		RewardImageKey:    req.RewardImageKey,
-		BehaviourImageKey: req.BehaviourImageKey,
-		CreatedBy:         userID,
-		CreatedAt:         time.Now(),
-		UpdatedAt:         time.Now(),
+	changed, replacedImages, err := applyTimerUpdate(&timer.TimerSettings, req)
+	if err != nil {
+		return err
 	}
 
-	return s.TimerRepository.CreateIsTime(ctx, data)
+	// Fields edited on a timer assigned from a template stop following the template.
+	if timer.TemplateID != nil {
+		for _, field := range changed {
+			if !containsString(timer.Overrides, field) {
+				timer.Overrides = append(timer.Overrides, field)
+			}
+		}
+	}
+
+	timer.UpdatedAt = time.Now()
+
+	if err := s.TimerRepository.UpdateTimer(ctx, objectID, timer); err != nil {
+		return err
+	}
+
+	return s.deleteOrphanedImages(ctx, replacedImages...)
 }
 
-func (s *timerService) GetIsTimes(ctx context.Context, studentID string) ([]*IsTimeResponse, error) {
+// applyTimerUpdate copies the fields set on the request into the settings. It returns the
+// bson names of the fields whose value changed and the image keys that were replaced.
+func applyTimerUpdate(settings *TimerSettings, req *UpdateTimerRequest) ([]string, []string, error) {
+
+	changed := make([]string, 0)
+	replacedImages := make([]string, 0)
+
+	if req.StartColor != nil && *req.StartColor != settings.StartColor {
+		settings.StartColor = *req.StartColor
+		changed = append(changed, "start_color")
+	}
+	if req.EndColor != nil && *req.EndColor != settings.EndColor {
+		settings.EndColor = *req.EndColor
+		changed = append(changed, "end_color")
+	}
+	if req.Duration != nil && *req.Duration != settings.Duration {
+		if *req.Duration == 0 {
+			return nil, nil, fmt.Errorf("duration is required")
+		}
+		settings.Duration = *req.Duration
+		changed = append(changed, "duration")
+	}
+	if req.LineCenter != nil && *req.LineCenter != settings.CenterLine {
+		settings.CenterLine = *req.LineCenter
+		changed = append(changed, "center_line")
+	}
+	if req.OpacityDuration != nil && *req.OpacityDuration != settings.OpacityDuration {
+		settings.OpacityDuration = *req.OpacityDuration
+		changed = append(changed, "opacity_duration")
+	}
+	if req.NumberOfSound != nil && *req.NumberOfSound != settings.NumberOfSound {
+		settings.NumberOfSound = *req.NumberOfSound
+		changed = append(changed, "number_of_sound")
+	}
+	if req.ImageStartKey != nil && *req.ImageStartKey != settings.ImageStartKey {
+		if settings.ImageStartKey != "" {
+			replacedImages = append(replacedImages, settings.ImageStartKey)
+		}
+		settings.ImageStartKey = *req.ImageStartKey
+		changed = append(changed, "image_start_key")
+	}
+	if req.ShowImageStart != nil && *req.ShowImageStart != settings.ShowImageStart {
+		settings.ShowImageStart = *req.ShowImageStart
+		changed = append(changed, "show_image_start")
+	}
+	if req.CaptionImageStart != nil && *req.CaptionImageStart != settings.CaptionImageStart {
+		settings.CaptionImageStart = *req.CaptionImageStart
+		changed = append(changed, "caption_image_start")
+	}
+	if req.ImageEndKey != nil && *req.ImageEndKey != settings.ImageEndKey {
+		if settings.ImageEndKey != "" {
+			replacedImages = append(replacedImages, settings.ImageEndKey)
+		}
+		settings.ImageEndKey = *req.ImageEndKey
+		changed = append(changed, "image_end_key")
+	}
+	if req.ShowImageEnd != nil && *req.ShowImageEnd != settings.ShowImageEnd {
+		settings.ShowImageEnd = *req.ShowImageEnd
+		changed = append(changed, "show_image_end")
+	}
+	if req.CaptionImageEnd != nil && *req.CaptionImageEnd != settings.CaptionImageEnd {
+		settings.CaptionImageEnd = *req.CaptionImageEnd
+		changed = append(changed, "caption_image_end")
+	}
+	if req.TypePlay != nil && *req.TypePlay != settings.TypePlay {
+		settings.TypePlay = *req.TypePlay
+		changed = append(changed, "type_play")
+	}
+
+	return changed, replacedImages, nil
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *timerService) DeleteTimer(ctx context.Context, id string) error {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
 
-	if studentID == "" {
-		studentID = ""
+	timer, err := s.TimerRepository.GetTimer(ctx, objectID)
+	if err != nil {
+		return err
 	}
 
-	isTimes, err := s.TimerRepository.GetIsTimes(ctx, studentID)
+	if err := s.TimerRepository.DeleteTimer(ctx, objectID); err != nil {
+		return err
+	}
+
+	return s.deleteOrphanedImages(ctx, timer.ImageStartKey, timer.ImageEndKey)
+}
+
+func (s *timerService) DuplicateTimer(ctx context.Context, id string, req *DuplicateTimerRequest, userID string) ([]string, error) {
+
+	if userID == "" {
+		return nil, fmt.Errorf("created_by is required")
+	}
+
+	if len(req.StudentIDs) == 0 {
+		return nil, fmt.Errorf("student_ids is required")
+	}
 
+	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, err
 	}
 
-	var result []*IsTimeResponse
+	source, err := s.TimerRepository.GetTimer(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, isTime := range isTimes {
+	now := time.Now()
+	seen := map[string]bool{}
+	timers := make([]*Timer, 0, len(req.StudentIDs))
 
-		if isTime.ImageKey != "" {
-			imageUrl, err := s.ImageService.GetImageKey(ctx, isTime.ImageKey)
-			if err != nil {
-				return nil, err
-			}
-			if imageUrl != nil {
-				isTime.ImageKey = imageUrl.Url
-			} else {
-				isTime.ImageKey = "" 
-			}
-		} else {
-			isTime.ImageKey = ""
-		}
+	for _, studentID := range req.StudentIDs {
 
-		if isTime.RewardImageKey == "" {
-			isTime.RewardImageKey = ""
+		if studentID == "" || seen[studentID] {
+			continue
 		}
+		seen[studentID] = true
 
-		if isTime.BehaviourImageKey == "" {
-			isTime.BehaviourImageKey = ""
-		}
+		timer := *source
+		timer.ID = primitive.NewObjectID()
+		timer.StudentID = studentID
+		timer.CreatedBy = userID
+		timer.CreatedAt = now
+		timer.UpdatedAt = now
+
+		timers = append(timers, &timer)
+	}
+
+	if len(timers) == 0 {
+		return nil, fmt.Errorf("student_ids is required")
+	}
+
+	return s.TimerRepository.CreateTimers(ctx, timers)
+}
+
+func (s *timerService) ResetTimerOverrides(ctx context.Context, id string) error {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	timer, err := s.TimerRepository.GetTimer(ctx, objectID)
+	if err != nil {
+		return err
+	}
+
+	if timer.TemplateID == nil {
+		return fmt.Errorf("timer is not assigned from a template")
+	}
+
+	template, err := s.TimerRepository.GetTimerTemplate(ctx, *timer.TemplateID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.TimerRepository.ResetTimerOverrides(ctx, objectID, template.TimerSettings); err != nil {
+		return err
+	}
+
+	return s.deleteOrphanedImages(ctx, timer.ImageStartKey, timer.ImageEndKey)
+}
+
+func (s *timerService) CreateTimerTemplate(ctx context.Context, req *CreateTimerTemplateRequest, userID string) (string, error) {
+
+	if req.OrganizationID == "" {
+		return "", fmt.Errorf("organization_id is required")
+	}
+
+	if strings.TrimSpace(req.Name) == "" {
+		return "", fmt.Errorf("name is required")
+	}
+
+	if req.Duration == 0 {
+		return "", fmt.Errorf("duration is required")
+	}
+
+	template := &TimerTemplate{
+		ID:             primitive.NewObjectID(),
+		OrganizationID: req.OrganizationID,
+		Name:           strings.TrimSpace(req.Name),
+		Tags:           normalizeTags(req.Tags),
+		TimerSettings:  req.TimerSettings,
+		CreatedBy:      userID,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+
+	return s.TimerRepository.CreateTimerTemplate(ctx, template)
+}
+
+func (s *timerService) GetTimerTemplates(ctx context.Context, organizationID string, search string, tag string) ([]*TimerTemplateResponse, error) {
 
-		student, err := s.UserService.GetStudentInfor(ctx, isTime.StudentID)
+	if organizationID == "" {
+		return nil, fmt.Errorf("organization_id is required")
+	}
+
+	templates, err := s.TimerRepository.GetTimerTemplates(ctx, organizationID, strings.TrimSpace(search), strings.ToLower(strings.TrimSpace(tag)))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*TimerTemplateResponse, 0, len(templates))
+	for _, template := range templates {
+		response, err := s.toTimerTemplateResponse(ctx, template)
 		if err != nil {
 			return nil, err
 		}
+		result = append(result, response)
+	}
+
+	return result, nil
+}
+
+func (s *timerService) GetTimerTemplate(ctx context.Context, id string) (*TimerTemplateResponse, error) {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := s.TimerRepository.GetTimerTemplate(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toTimerTemplateResponse(ctx, template)
+}
+
+func (s *timerService) UpdateTimerTemplate(ctx context.Context, id string, req *UpdateTimerTemplateRequest) error {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	template, err := s.TimerRepository.GetTimerTemplate(ctx, objectID)
+	if err != nil {
+		return err
+	}
+
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			return fmt.Errorf("name is required")
+		}
+		template.Name = strings.TrimSpace(*req.Name)
+	}
+
+	if req.Tags != nil {
+		template.Tags = normalizeTags(req.Tags)
+	}
+
+	changed, replacedImages, err := applyTimerUpdate(&template.TimerSettings, &req.UpdateTimerRequest)
+	if err != nil {
+		return err
+	}
 
-		teacher, err := s.UserService.GetUserInfor(ctx, isTime.CreatedBy)
+	template.UpdatedAt = time.Now()
+
+	if err := s.TimerRepository.UpdateTimerTemplate(ctx, objectID, template); err != nil {
+		return err
+	}
+
+	if len(changed) > 0 {
+		values, err := settingsValues(template.TimerSettings, changed)
 		if err != nil {
-			return nil, err
+			return err
 		}
+		if err := s.TimerRepository.PropagateTimerTemplate(ctx, objectID, values); err != nil {
+			return err
+		}
+	}
 
-		result = append(result, &IsTimeResponse{
-			ID:           isTime.ID,
-			Student:      student,
-			IndexImage:   isTime.IndexImage,
-			Sentence:     isTime.Sentence,
-			Mode:         isTime.Mode,
-			ImageUrl:     isTime.ImageKey,
-			RewardUrl:    isTime.RewardImageKey,
-			BehaviourUrl: isTime.BehaviourImageKey,
-			CaptionImage: isTime.CaptionImage,
-			ImageSize:    isTime.ImageSize,
-			Teacher:      teacher,
-			CreatedAt:    isTime.CreatedAt,
-			UpdatedAt:    isTime.UpdatedAt,
+	return s.deleteOrphanedImages(ctx, replacedImages...)
+}
+
+func (s *timerService) DeleteTimerTemplate(ctx context.Context, id string) error {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	template, err := s.TimerRepository.GetTimerTemplate(ctx, objectID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.TimerRepository.DeleteTimerTemplate(ctx, objectID); err != nil {
+		return err
+	}
+
+	return s.deleteOrphanedImages(ctx, template.ImageStartKey, template.ImageEndKey)
+}
+
+// AssignTimerTemplate creates a timer following the template for each student that does
+// not have one yet.
+func (s *timerService) AssignTimerTemplate(ctx context.Context, id string, req *AssignTimerTemplateRequest, userID string) ([]string, error) {
+
+	if userID == "" {
+		return nil, fmt.Errorf("created_by is required")
+	}
+
+	if len(req.StudentIDs) == 0 {
+		return nil, fmt.Errorf("student_ids is required")
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := s.TimerRepository.GetTimerTemplate(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	assigned, err := s.TimerRepository.GetTemplateStudents(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for _, studentID := range assigned {
+		seen[studentID] = true
+	}
+
+	now := time.Now()
+	timers := make([]*Timer, 0, len(req.StudentIDs))
+
+	for _, studentID := range req.StudentIDs {
+
+		if studentID == "" || seen[studentID] {
+			continue
+		}
+		seen[studentID] = true
+
+		templateID := template.ID
+		timers = append(timers, &Timer{
+			ID:            primitive.NewObjectID(),
+			StudentID:     studentID,
+			TimerSettings: template.TimerSettings,
+			TemplateID:    &templateID,
+			CreatedBy:     userID,
+			CreatedAt:     now,
+			UpdatedAt:     now,
 		})
+	}
 
+	if len(timers) == 0 {
+		return []string{}, nil
 	}
 
-	return result, nil
+	return s.TimerRepository.CreateTimers(ctx, timers)
+}
+
+func (s *timerService) toTimerTemplateResponse(ctx context.Context, template *TimerTemplate) (*TimerTemplateResponse, error) {
+
+	teacher, err := s.UserService.GetUserInfor(ctx, template.CreatedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &TimerTemplateResponse{
+		ID:             template.ID,
+		OrganizationID: template.OrganizationID,
+		Name:           template.Name,
+		Tags:           template.Tags,
+		TimerSettings:  template.TimerSettings,
+		Teacher:        teacher,
+		CreatedAt:      template.CreatedAt,
+		UpdatedAt:      template.UpdatedAt,
+	}
 
+	if template.ImageStartKey != "" {
+		image, err := s.ImageService.GetImageKey(ctx, template.ImageStartKey)
+		if err != nil {
+			return nil, err
+		}
+		if image != nil {
+			response.ImageStartUrl = image.Url
+		}
+	}
+
+	if template.ImageEndKey != "" {
+		image, err := s.ImageService.GetImageKey(ctx, template.ImageEndKey)
+		if err != nil {
+			return nil, err
+		}
+		if image != nil {
+			response.ImageEndUrl = image.Url
+		}
+	}
+
+	return response, nil
+}
+
+// settingsValues picks the given bson fields out of the settings.
+func settingsValues(settings TimerSettings, fields []string) (bson.M, error) {
+
+	data, err := bson.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	all := bson.M{}
+	if err := bson.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	values := bson.M{}
+	for _, field := range fields {
+		values[field] = all[field]
+	}
+
+	return values, nil
+}
+
+func normalizeTags(tags []string) []string {
+
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !containsString(result, tag) {
+			result = append(result, tag)
+		}
+	}
+
+	return result
+}
+
+// ReportTimerRun records client events against a run of the timer. Without a run ID the
+// first event must be "started" and a new run is created. It returns the run ID.
+func (s *timerService) ReportTimerRun(ctx context.Context, timerID string, req *ReportTimerRunRequest, userID string) (string, error) {
+
+	if userID == "" {
+		return "", fmt.Errorf("created_by is required")
+	}
+
+	if len(req.Events) == 0 {
+		return "", fmt.Errorf("events is required")
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(timerID)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	events := make([]TimerRunEvent, 0, len(req.Events))
+	for _, item := range req.Events {
+		at := now
+		if item.At != "" {
+			at, err = time.Parse(time.RFC3339, item.At)
+			if err != nil {
+				return "", fmt.Errorf("invalid event time format")
+			}
+		}
+		if at.After(now.Add(time.Minute)) {
+			return "", fmt.Errorf("event time must not be in the future")
+		}
+		events = append(events, TimerRunEvent{Type: item.Type, At: at, ReportedBy: userID})
+	}
+
+	if req.RunID == "" {
+
+		timer, err := s.TimerRepository.GetTimer(ctx, objectID)
+		if err != nil {
+			return "", err
+		}
+
+		run := &TimerRun{
+			ID:             primitive.NewObjectID(),
+			TimerID:        timer.ID,
+			StudentID:      timer.StudentID,
+			PlannedSeconds: timer.Duration,
+			Events:         []TimerRunEvent{},
+			CreatedBy:      userID,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+
+		for _, event := range events {
+			if err := applyRunEvent(run, event); err != nil {
+				return "", err
+			}
+		}
+
+		return s.TimerRepository.CreateTimerRun(ctx, run)
+	}
+
+	runID, err := primitive.ObjectIDFromHex(req.RunID)
+	if err != nil {
+		return "", err
+	}
+
+	run, err := s.TimerRepository.GetTimerRun(ctx, runID)
+	if err != nil {
+		return "", err
+	}
+
+	if run.TimerID != objectID {
+		return "", fmt.Errorf("timer run does not belong to this timer")
+	}
+
+	previousEvents := len(run.Events)
+
+	for _, event := range events {
+		if err := applyRunEvent(run, event); err != nil {
+			return "", err
+		}
+	}
+
+	run.UpdatedAt = now
+
+	if err := s.TimerRepository.UpdateTimerRun(ctx, run, previousEvents); err != nil {
+		return "", err
+	}
+
+	return run.ID.Hex(), nil
+}
+
+func (s *timerService) GetTimerUsage(ctx context.Context, studentID string, timerID string, from string, to string) (*TimerUsageResponse, error) {
+
+	var timerObjectID *primitive.ObjectID
+	var fromDate, toDate *time.Time
+
+	if timerID != "" {
+		objectID, err := primitive.ObjectIDFromHex(timerID)
+		if err != nil {
+			return nil, err
+		}
+		timerObjectID = &objectID
+	}
+
+	if from != "" {
+		parseDate, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from date format")
+		}
+		fromDate = &parseDate
+	}
+
+	if to != "" {
+		parseDate, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to date format")
+		}
+		toDate = &parseDate
+	}
+
+	runs, err := s.TimerRepository.GetTimerRuns(ctx, studentID, timerObjectID, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+
+	usages := buildTimerUsage(runs)
+
+	students := map[string]*user.UserInfor{}
+	for _, usage := range usages {
+		student, ok := students[usage.StudentID]
+		if !ok {
+			student, err = s.UserService.GetStudentInfor(ctx, usage.StudentID)
+			if err != nil {
+				return nil, err
+			}
+			students[usage.StudentID] = student
+		}
+		usage.Student = student
+	}
+
+	return &TimerUsageResponse{
+		From:   from,
+		To:     to,
+		Timers: usages,
+	}, nil
+}
+
+func (s *timerService) CreateSchedule(ctx context.Context, req *CreateScheduleRequest, userID string) (string, error) {
+
+	if userID == "" {
+		return "", fmt.Errorf("created_by is required")
+	}
+
+	if req.StudentID == "" {
+		return "", fmt.Errorf("student_id is required")
+	}
+
+	if strings.TrimSpace(req.Name) == "" {
+		return "", fmt.Errorf("name is required")
+	}
+
+	ids, err := parseObjectIDs(req.IsTimeIDs)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := s.getStudentCards(ctx, req.StudentID, ids); err != nil {
+		return "", err
+	}
+
+	schedule := &Schedule{
+		ID:        primitive.NewObjectID(),
+		StudentID: req.StudentID,
+		Name:      strings.TrimSpace(req.Name),
+		Steps:     []ScheduleStep{},
+		CreatedBy: userID,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	for _, id := range ids {
+		if err := schedule.insertStep(id, nil); err != nil {
+			return "", err
+		}
+	}
+
+	return s.TimerRepository.CreateSchedule(ctx, schedule)
+}
+
+func (s *timerService) GetSchedules(ctx context.Context, studentID string) ([]*ScheduleResponse, error) {
+
+	schedules, err := s.TimerRepository.GetSchedules(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*ScheduleResponse, 0, len(schedules))
+	for _, schedule := range schedules {
+		response, err := s.toScheduleResponse(ctx, schedule)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, response)
+	}
+
+	return result, nil
+}
+
+func (s *timerService) GetSchedule(ctx context.Context, id string) (*ScheduleResponse, error) {
+
+	schedule, err := s.getSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toScheduleResponse(ctx, schedule)
+}
+
+func (s *timerService) GetScheduleProgress(ctx context.Context, id string) (*ScheduleProgressResponse, error) {
+
+	schedule, err := s.getSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toScheduleProgress(ctx, schedule)
+}
+
+func (s *timerService) ReorderScheduleSteps(ctx context.Context, id string, req *ReorderScheduleStepsRequest) error {
+
+	ids, err := parseObjectIDs(req.IsTimeIDs)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.updateSchedule(ctx, id, func(schedule *Schedule) error {
+		return schedule.reorderSteps(ids)
+	})
+
+	return err
+}
+
+func (s *timerService) InsertScheduleStep(ctx context.Context, id string, req *InsertScheduleStepRequest) error {
+
+	if req.IsTimeID == "" {
+		return fmt.Errorf("is_time_id is required")
+	}
+
+	isTimeID, err := primitive.ObjectIDFromHex(req.IsTimeID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.updateSchedule(ctx, id, func(schedule *Schedule) error {
+		if _, err := s.getStudentCards(ctx, schedule.StudentID, []primitive.ObjectID{isTimeID}); err != nil {
+			return err
+		}
+		return schedule.insertStep(isTimeID, req.Position)
+	})
+
+	return err
+}
+
+func (s *timerService) RemoveScheduleStep(ctx context.Context, id string, isTimeID string) error {
+
+	objectID, err := primitive.ObjectIDFromHex(isTimeID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.updateSchedule(ctx, id, func(schedule *Schedule) error {
+		return schedule.removeStep(objectID)
+	})
+
+	return err
+}
+
+func (s *timerService) AdvanceSchedule(ctx context.Context, id string, req *AdvanceScheduleRequest) (*ScheduleProgressResponse, error) {
+
+	schedule, err := s.updateSchedule(ctx, id, func(schedule *Schedule) error {
+		return schedule.advance(req.Step, time.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toScheduleProgress(ctx, schedule)
+}
+
+func (s *timerService) RestartSchedule(ctx context.Context, id string) error {
+
+	_, err := s.updateSchedule(ctx, id, func(schedule *Schedule) error {
+		schedule.restart()
+		return nil
+	})
+
+	return err
+}
+
+func (s *timerService) DeleteSchedule(ctx context.Context, id string) error {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	return s.TimerRepository.DeleteSchedule(ctx, objectID)
+}
+
+func (s *timerService) getSchedule(ctx context.Context, id string) (*Schedule, error) {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.TimerRepository.GetSchedule(ctx, objectID)
+}
+
+// updateSchedule loads the schedule, applies the change and saves it at the version read.
+func (s *timerService) updateSchedule(ctx context.Context, id string, change func(schedule *Schedule) error) (*Schedule, error) {
+
+	schedule, err := s.getSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := change(schedule); err != nil {
+		return nil, err
+	}
+
+	schedule.UpdatedAt = time.Now()
+
+	if err := s.TimerRepository.SaveSchedule(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// getStudentCards loads the IsTime cards and checks they all belong to the student.
+func (s *timerService) getStudentCards(ctx context.Context, studentID string, ids []primitive.ObjectID) (map[primitive.ObjectID]*IsTime, error) {
+
+	cards := map[primitive.ObjectID]*IsTime{}
+
+	if len(ids) == 0 {
+		return cards, nil
+	}
+
+	isTimes, err := s.TimerRepository.GetIsTimesByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, isTime := range isTimes {
+		cards[isTime.ID] = isTime
+	}
+
+	for _, id := range ids {
+		card, ok := cards[id]
+		if !ok {
+			return nil, fmt.Errorf("is time %s not found", id.Hex())
+		}
+		if card.StudentID != studentID {
+			return nil, fmt.Errorf("is time %s belongs to another student", id.Hex())
+		}
+	}
+
+	return cards, nil
+}
+
+func (s *timerService) toScheduleSteps(ctx context.Context, schedule *Schedule) ([]*ScheduleStepResponse, error) {
+
+	ids := make([]primitive.ObjectID, 0, len(schedule.Steps))
+	for _, step := range schedule.Steps {
+		ids = append(ids, step.IsTimeID)
+	}
+
+	isTimes, err := s.TimerRepository.GetIsTimesByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	cards := map[primitive.ObjectID]*IsTime{}
+	imageKeys := make([]string, 0, len(isTimes)*3)
+	for _, isTime := range isTimes {
+		cards[isTime.ID] = isTime
+		imageKeys = append(imageKeys, isTime.ImageKey, isTime.RewardImageKey, isTime.BehaviourImageKey)
+	}
+
+	images, err := s.ImageService.GetImageKeys(ctx, imageKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	current := schedule.currentStep()
+	steps := make([]*ScheduleStepResponse, 0, len(schedule.Steps))
+
+	for i, step := range schedule.Steps {
+
+		response := &ScheduleStepResponse{
+			Position:    i,
+			IsTimeID:    step.IsTimeID,
+			IsCurrent:   i == current,
+			CompletedAt: step.CompletedAt,
+		}
+
+		if card, ok := cards[step.IsTimeID]; ok {
+			response.IndexImage = card.IndexImage
+			response.Sentence = card.Sentence
+			response.Mode = card.Mode
+			response.CaptionImage = card.CaptionImage
+			response.ImageSize = card.ImageSize
+			response.ImageUrl = imageUrl(images, card.ImageKey)
+			response.RewardUrl = imageUrl(images, card.RewardImageKey)
+			response.BehaviourUrl = imageUrl(images, card.BehaviourImageKey)
+		}
+
+		steps = append(steps, response)
+	}
+
+	return steps, nil
+}
+
+func (s *timerService) toScheduleResponse(ctx context.Context, schedule *Schedule) (*ScheduleResponse, error) {
+
+	student, err := s.UserService.GetStudentInfor(ctx, schedule.StudentID)
+	if err != nil {
+		return nil, err
+	}
+
+	steps, err := s.toScheduleSteps(ctx, schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ScheduleResponse{
+		ID:             schedule.ID,
+		Student:        student,
+		Name:           schedule.Name,
+		Steps:          steps,
+		CurrentStep:    schedule.currentStep(),
+		TotalSteps:     len(schedule.Steps),
+		CompletedSteps: schedule.completedSteps(),
+		Progress:       scheduleProgress(schedule),
+		Version:        schedule.Version,
+		StartedAt:      schedule.StartedAt,
+		CompletedAt:    schedule.CompletedAt,
+		CreatedAt:      schedule.CreatedAt,
+		UpdatedAt:      schedule.UpdatedAt,
+	}, nil
+}
+
+func (s *timerService) toScheduleProgress(ctx context.Context, schedule *Schedule) (*ScheduleProgressResponse, error) {
+
+	student, err := s.UserService.GetStudentInfor(ctx, schedule.StudentID)
+	if err != nil {
+		return nil, err
+	}
+
+	steps, err := s.toScheduleSteps(ctx, schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	current := schedule.currentStep()
+
+	response := &ScheduleProgressResponse{
+		ScheduleID:     schedule.ID,
+		Name:           schedule.Name,
+		Student:        student,
+		CurrentStep:    current,
+		TotalSteps:     len(schedule.Steps),
+		CompletedSteps: schedule.completedSteps(),
+		Progress:       scheduleProgress(schedule),
+		StartedAt:      schedule.StartedAt,
+		CompletedAt:    schedule.CompletedAt,
+	}
+
+	if current < len(steps) {
+		response.Current = steps[current]
+	}
+	if current+1 < len(steps) {
+		response.Next = steps[current+1]
+	}
+
+	return response, nil
+}
+
+func scheduleProgress(schedule *Schedule) float64 {
+	if len(schedule.Steps) == 0 {
+		return 0
+	}
+	return round2(float64(schedule.completedSteps()) / float64(len(schedule.Steps)) * 100)
+}
+
+func parseObjectIDs(values []string) ([]primitive.ObjectID, error) {
+
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, value := range values {
+		id, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid id %s", value)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// deleteOrphanedImages removes images that no timer or is-time card refers to anymore.
+func (s *timerService) deleteOrphanedImages(ctx context.Context, keys ...string) error {
+
+	for _, key := range keys {
+
+		if key == "" {
+			continue
+		}
+
+		count, err := s.TimerRepository.CountImageUsage(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		if count > 0 {
+			continue
+		}
+
+		if err := s.ImageService.DeleteImageKey(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *timerService) CreateIsTime(ctx context.Context, req *CreateIsTimeRequest, userID string) error {
+
+	if req.StudentID == "" {
+		return fmt.Errorf("student_id is required")
+	}
+
+	if userID == "" {
+		return fmt.Errorf("created_by is required")
+	}
+
+	data := &IsTime{
+		ID:                primitive.NewObjectID(),
+		StudentID:         req.StudentID,
+		IndexImage:        req.IndexImage,
+		Sentence:          req.Sentence,
+		Mode:              req.Mode,
+		ImageKey:          req.ImageKey,
+		CaptionImage:      req.CaptionImage,
+		ImageSize:         req.ImageSize,
+		RewardImageKey:    req.RewardImageKey,
+		BehaviourImageKey: req.BehaviourImageKey,
+		CreatedBy:         userID,
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
+	}
+
+	return s.TimerRepository.CreateIsTime(ctx, data)
+}
+
+func (s *timerService) GetIsTimes(ctx context.Context, studentID string) ([]*IsTimeResponse, error) {
+
+	isTimes, err := s.TimerRepository.GetIsTimes(ctx, studentID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	imageKeys := make([]string, 0, len(isTimes))
+	studentIDs := make([]string, 0, len(isTimes))
+	teacherIDs := make([]string, 0, len(isTimes))
+	for _, isTime := range isTimes {
+		imageKeys = append(imageKeys, isTime.ImageKey)
+		studentIDs = append(studentIDs, isTime.StudentID)
+		teacherIDs = append(teacherIDs, isTime.CreatedBy)
+	}
+
+	images, err := s.ImageService.GetImageKeys(ctx, imageKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	students, err := lookupUsers(ctx, studentIDs, s.UserService.GetStudentInfor)
+	if err != nil {
+		return nil, err
+	}
+
+	teachers, err := lookupUsers(ctx, teacherIDs, s.UserService.GetUserInfor)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*IsTimeResponse
+
+	for _, isTime := range isTimes {
+
+		result = append(result, &IsTimeResponse{
+			ID:           isTime.ID,
+			Student:      students[isTime.StudentID],
+			IndexImage:   isTime.IndexImage,
+			Sentence:     isTime.Sentence,
+			Mode:         isTime.Mode,
+			ImageUrl:     imageUrl(images, isTime.ImageKey),
+			RewardUrl:    isTime.RewardImageKey,
+			BehaviourUrl: isTime.BehaviourImageKey,
+			CaptionImage: isTime.CaptionImage,
+			ImageSize:    isTime.ImageSize,
+			Teacher:      teachers[isTime.CreatedBy],
+			CreatedAt:    isTime.CreatedAt,
+			UpdatedAt:    isTime.UpdatedAt,
+		})
+
+	}
+
+	return result, nil
+
+}
+
+// imageUrl returns the resolved URL of the key, or an empty string when the key is empty
+// or the image could not be resolved.
+func imageUrl(images map[string]*uploader.Avatar, key string) string {
+	if image, ok := images[key]; ok && image != nil {
+		return image.Url
+	}
+	return ""
+}
+
+// lookupUsers fetches each distinct user once, with a bounded number of calls in flight.
+func lookupUsers(ctx context.Context, ids []string, fetch func(ctx context.Context, id string) (*user.UserInfor, error)) (map[string]*user.UserInfor, error) {
+
+	result := map[string]*user.UserInfor{}
+	pending := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := result[id]; ok {
+			continue
+		}
+		result[id] = nil
+		pending = append(pending, id)
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+
+	limit := make(chan struct{}, maxConcurrentUserLookups)
+
+	for _, id := range pending {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(id string) {
+			defer wg.Done()
+			defer func() { <-limit }()
+			info, err := fetch(ctx, id)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			result[id] = info
+		}(id)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return result, nil
 }
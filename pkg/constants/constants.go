@@ -106,6 +106,18 @@ const (
 	MaximumUsageTime = "maximum_usage_time"
 
 	UserID = "user_id"
+	Roles  = "roles"
+
+	RoleAdmin       = "admin"
+	RoleStaff       = "staff"
+	RoleTeacher     = "teacher"
+	RoleCoordinator = "coordinator"
+	RoleParent      = "parent"
+	RoleStudent     = "student"
+)
+
+var (
+	StaffRoles = []string{RoleAdmin, RoleStaff, RoleTeacher, RoleCoordinator}
 )
 
 type contextKey string
@@ -0,0 +1,130 @@
+package uploader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultURLTTL is used when the URL carries no expiry, e.g. permanent public links.
+	defaultURLTTL = 15 * time.Minute
+
+	// expirySafetyMargin drops cached URLs a little before they expire so a client never
+	// receives a link that stops working while the page loads.
+	expirySafetyMargin = 30 * time.Second
+
+	// maxConcurrentLookups bounds the calls made to the image service by a batch.
+	maxConcurrentLookups = 8
+)
+
+type cachedURL struct {
+	avatar    *Avatar
+	expiresAt time.Time
+}
+
+type urlCache struct {
+	mu      sync.RWMutex
+	entries map[string]cachedURL
+}
+
+func newURLCache() *urlCache {
+	return &urlCache{entries: map[string]cachedURL{}}
+}
+
+func (c *urlCache) get(key string, now time.Time) (*Avatar, bool) {
+
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	if !now.Before(entry.expiresAt) {
+		c.mu.Lock()
+		delete(c.entries, key)
+		c.mu.Unlock()
+		return nil, false
+	}
+
+	return entry.avatar, true
+}
+
+func (c *urlCache) set(key string, avatar *Avatar, now time.Time) {
+
+	expiresAt := urlExpiry(avatar.Url, now).Add(-expirySafetyMargin)
+	if !now.Before(expiresAt) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Sweep expired entries now and then so the map does not grow without bound.
+	if len(c.entries)%256 == 255 {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+	}
+
+	c.entries[key] = cachedURL{avatar: avatar, expiresAt: expiresAt}
+}
+
+// removeImage drops every cached URL of the image, whatever the mode or caller.
+func (c *urlCache) removeImage(imageKey string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if strings.HasSuffix(key, "|"+imageKey) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// cacheKey scopes private URLs to the caller's token, so a signed link fetched for one
+// user is never handed to another.
+func cacheKey(key string, mode string, token string) string {
+	if mode == modePrivate {
+		sum := sha256.Sum256([]byte(token))
+		return mode + "|" + hex.EncodeToString(sum[:8]) + "|" + key
+	}
+	return mode + "|" + key
+}
+
+// urlExpiry reads the expiry of S3 and GCS style signed URLs, falling back to the default
+// TTL for anything else.
+func urlExpiry(rawURL string, now time.Time) time.Time {
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return now.Add(defaultURLTTL)
+	}
+
+	query := parsed.Query()
+
+	for _, prefix := range []string{"X-Amz-", "X-Goog-"} {
+		signedAt, err := time.Parse("20060102T150405Z", query.Get(prefix+"Date"))
+		if err != nil {
+			continue
+		}
+		seconds, err := strconv.ParseInt(query.Get(prefix+"Expires"), 10, 64)
+		if err != nil {
+			continue
+		}
+		return signedAt.Add(time.Duration(seconds) * time.Second)
+	}
+
+	if expires, err := strconv.ParseInt(query.Get("Expires"), 10, 64); err == nil {
+		return time.Unix(expires, 0)
+	}
+
+	return now.Add(defaultURLTTL)
+}
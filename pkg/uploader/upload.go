@@ -8,6 +8,8 @@ import (
 	"portal/pkg/consul"
 	"net/http"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/consul/api"
 )
@@ -22,11 +24,15 @@ type ImageKey struct {
 
 type ImageService interface {
 	GetImageKey(ctx context.Context, key string) (*Avatar, error)
+	GetPrivateImageKey(ctx context.Context, key string) (*Avatar, error)
+	GetImageKeys(ctx context.Context, keys []string) (map[string]*Avatar, error)
+	GetPrivateImageKeys(ctx context.Context, keys []string) (map[string]*Avatar, error)
 	DeleteImageKey(ctx context.Context, key string) error
 }
 
 type imageService struct {
 	client *callAPI
+	cache  *urlCache
 }
 
 type callAPI struct {
@@ -38,10 +44,16 @@ var (
 	imageServiceStr = "go-main-service"
 )
 
+const (
+	modePublic  = "public"
+	modePrivate = "private"
+)
+
 func NewImageService(client *api.Client) ImageService {
 	mainServiceAPI := NewServiceAPI(client, imageServiceStr)
 	return &imageService{
 		client: mainServiceAPI,
+		cache:  newURLCache(),
 	}
 }
 
@@ -70,13 +82,101 @@ func NewServiceAPI(client *api.Client, serviceName string) *callAPI {
 }
 
 func (s *imageService) GetImageKey(ctx context.Context, key string) (*Avatar, error) {
+	return s.getImage(ctx, key, modePublic)
+}
+
+// GetPrivateImageKey resolves a key stored in private mode, for which the main service
+// returns a short-lived signed URL instead of a permanent public one.
+func (s *imageService) GetPrivateImageKey(ctx context.Context, key string) (*Avatar, error) {
+	return s.getImage(ctx, key, modePrivate)
+}
+
+// GetImageKeys resolves many keys at once. Keys that do not resolve are left out of the
+// returned map.
+func (s *imageService) GetImageKeys(ctx context.Context, keys []string) (map[string]*Avatar, error) {
+	return s.getImages(ctx, keys, modePublic)
+}
+
+func (s *imageService) GetPrivateImageKeys(ctx context.Context, keys []string) (map[string]*Avatar, error) {
+	return s.getImages(ctx, keys, modePrivate)
+}
+
+// getImages serves what it can from the cache and looks up the rest through a bounded
+// pool of workers.
+func (s *imageService) getImages(ctx context.Context, keys []string, mode string) (map[string]*Avatar, error) {
+
+	result := map[string]*Avatar{}
+	pending := make([]string, 0, len(keys))
+	seen := map[string]bool{}
+
+	for _, key := range keys {
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		pending = append(pending, key)
+	}
+
+	if len(pending) == 0 {
+		return result, nil
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+
+	jobs := make(chan string)
+	workers := maxConcurrentLookups
+	if len(pending) < workers {
+		workers = len(pending)
+	}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range jobs {
+				image, err := s.getImage(ctx, key, mode)
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				if image != nil {
+					result[key] = image
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, key := range pending {
+		jobs <- key
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return result, nil
+}
+
+func (s *imageService) getImage(ctx context.Context, key string, mode string) (*Avatar, error) {
 	
     token, ok := ctx.Value(constants.TokenKey).(string)
     if !ok || token == "" {
         return nil, fmt.Errorf("token not found in context")
     }
 
-    image, err := s.client.getImageKey(key, token)
+    entryKey := cacheKey(key, mode, token)
+    if image, ok := s.cache.get(entryKey, time.Now()); ok {
+        return image, nil
+    }
+
+    image, err := s.client.getImageKey(key, mode, token)
     if err != nil {
         return nil, err
     }
@@ -95,7 +195,10 @@ func (s *imageService) GetImageKey(ctx context.Context, key string) (*Avatar, er
         return nil, nil
     }
 
-    return &Avatar{Url: innerData}, nil
+    avatar := &Avatar{Url: innerData}
+    s.cache.set(entryKey, avatar, time.Now())
+
+    return avatar, nil
 }
 
 
@@ -112,6 +215,8 @@ func (s *imageService) DeleteImageKey(ctx context.Context, key string) error {
 		return err
 	}
 
+	s.cache.removeImage(key)
+
 	return nil
 
 }
@@ -143,14 +248,14 @@ func (c *callAPI) deleleImage(key string, token string) (error) {
 	return nil
 }
 
-func (c *callAPI) getImageKey(key string, token string) (map[string]interface{}, error) {
+func (c *callAPI) getImageKey(key string, mode string, token string) (map[string]interface{}, error) {
 
     endpoint := "/v1/images"
     header := map[string]string{
         "Content-Type":  "application/json",
         "Authorization": "Bearer " + token,
     }
-    body := map[string]string{"key": key, "mode": "public"}
+    body := map[string]string{"key": key, "mode": mode}
 
     jsonBody, err := json.Marshal(body)
     if err != nil {